@@ -75,6 +75,9 @@ type StackReference interface {
 	// name may not uniquely identify the stack (e.g. the cloud backend embeds owner information in the StackReference
 	// but that information is not part of the StackName() we pass to the engine.
 	Name() tokens.QName
+	// Project is the name of the project that the stack belongs to. It returns false if the backend does not record
+	// the stack's project, e.g. for self-managed backends that use the legacy layout.
+	Project() (tokens.PackageName, bool)
 }
 
 // PolicyPackReference is an opaque type that refers to a PolicyPack managed by a backend. The CLI
@@ -152,8 +155,9 @@ type Backend interface {
 	// can be used to refer to the newly renamed stack.
 	RenameStack(ctx context.Context, stack Stack, newName tokens.QName) (StackReference, error)
 
-	// Preview shows what would be updated given the current workspace's contents.
-	Preview(ctx context.Context, stack Stack, op UpdateOperation) (engine.ResourceChanges, result.Result)
+	// Preview shows what would be updated given the current workspace's contents, and returns the plan of the
+	// operations that an update would perform.
+	Preview(ctx context.Context, stack Stack, op UpdateOperation) (*deploy.Plan, engine.ResourceChanges, result.Result)
 	// Update updates the target stack with the current workspace's contents (config and code).
 	Update(ctx context.Context, stack Stack, op UpdateOperation) (engine.ResourceChanges, result.Result)
 	// Import imports resources into a stack.
@@ -120,6 +120,9 @@ func RenderDiffEvent(action apitype.UpdateKind, event engine.Event,
 		return renderDiffResourceOutputsEvent(event.Payload().(engine.ResourceOutputsEventPayload), seen, opts)
 	case engine.ResourcePreEvent:
 		return renderDiffResourcePreEvent(event.Payload().(engine.ResourcePreEventPayload), seen, opts)
+	case engine.ResourceWaitingEvent:
+		// Steps are only rendered once they begin executing.
+		return ""
 	case engine.DiagEvent:
 		return renderDiffDiagEvent(event.Payload().(engine.DiagEventPayload), opts)
 	case engine.PolicyViolationEvent:
@@ -126,12 +126,19 @@ func isRootURN(urn resource.URN) bool {
 	return urn != "" && urn.Type() == resource.RootStackType
 }
 
+// isRetainedDelete returns true if the step deletes a resource that is retained on deletion, which is only removed
+// from the stack's state rather than being deleted by its provider.
+func isRetainedDelete(step engine.StepEventMetadata) bool {
+	return (step.Op == deploy.OpDelete || step.Op == deploy.OpDeleteReplaced) &&
+		step.Old != nil && step.Old.RetainOnDelete
+}
+
 // shouldShow returns true if a step should show in the output.
 func shouldShow(step engine.StepEventMetadata, opts Options) bool {
 	// For certain operations, whether they are tracked is controlled by flags (to cut down on superfluous output).
 	if step.Op == deploy.OpSame {
 		// If the op is the same, it is possible that the resource's metadata changed.  In that case, still show it.
-		if step.Old.Protect != step.New.Protect {
+		if step.Old.Protect != step.New.Protect || step.Old.RetainOnDelete != step.New.RetainOnDelete {
 			return true
 		}
 		return opts.ShowSameResources
@@ -108,6 +108,17 @@ func ConvertEngineEvent(e engine.Event) (apitype.EngineEvent, error) {
 			Planning: p.Planning,
 		}
 
+	case engine.ResourceWaitingEvent:
+		p, ok := e.Payload().(engine.ResourceWaitingEventPayload)
+		if !ok {
+			return apiEvent, eventTypePayloadMismatch
+		}
+		apiEvent.ResWaitingEvent = &apitype.ResWaitingEvent{
+			Metadata: convertStepEventMetadata(p.Metadata),
+			Limit:    p.Limit,
+			Planning: p.Planning,
+		}
+
 	case engine.ResourceOutputsEvent:
 		p, ok := e.Payload().(engine.ResourceOutputsEventPayload)
 		if !ok {
@@ -210,13 +221,14 @@ func convertStepEventStateMetadata(md *engine.StepEventStateMetadata) *apitype.S
 		Type: string(md.Type),
 		URN:  string(md.URN),
 
-		Custom:     md.Custom,
-		Delete:     md.Delete,
-		ID:         string(md.ID),
-		Parent:     string(md.Parent),
-		Protect:    md.Protect,
-		Inputs:     inputs,
-		Outputs:    outputs,
-		InitErrors: md.InitErrors,
+		Custom:         md.Custom,
+		Delete:         md.Delete,
+		ID:             string(md.ID),
+		Parent:         string(md.Parent),
+		Protect:        md.Protect,
+		RetainOnDelete: md.RetainOnDelete,
+		Inputs:         inputs,
+		Outputs:        outputs,
+		InitErrors:     md.InitErrors,
 	}
 }
@@ -215,6 +215,9 @@ func getEventUrnAndMetadata(event engine.Event) (resource.URN, *engine.StepEvent
 	case engine.ResourcePreEvent:
 		payload := event.Payload().(engine.ResourcePreEventPayload)
 		return payload.Metadata.URN, &payload.Metadata
+	case engine.ResourceWaitingEvent:
+		payload := event.Payload().(engine.ResourceWaitingEventPayload)
+		return payload.Metadata.URN, &payload.Metadata
 	case engine.ResourceOutputsEvent:
 		payload := event.Payload().(engine.ResourceOutputsEventPayload)
 		return payload.Metadata.URN, &payload.Metadata
@@ -1099,6 +1102,11 @@ func (display *ProgressDisplay) processNormalEvent(event engine.Event) {
 	if event.Type == engine.ResourcePreEvent {
 		step := event.Payload().(engine.ResourcePreEventPayload).Metadata
 		row.SetStep(step)
+		row.SetWaiting(false)
+	} else if event.Type == engine.ResourceWaitingEvent {
+		step := event.Payload().(engine.ResourceWaitingEventPayload).Metadata
+		row.SetStep(step)
+		row.SetWaiting(true)
 	} else if event.Type == engine.ResourceOutputsEvent {
 		isRefresh := display.getStepOp(row.Step()) == deploy.OpRefresh
 		step := event.Payload().(engine.ResourceOutputsEventPayload).Metadata
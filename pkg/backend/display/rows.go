@@ -55,6 +55,7 @@ type ResourceRow interface {
 	IsDone() bool
 
 	SetFailed()
+	SetWaiting(waiting bool)
 
 	DiagInfo() *DiagInfo
 	PolicyPayloads() []engine.PolicyViolationEventPayload
@@ -124,6 +125,9 @@ type resourceRowData struct {
 	// If we failed this operation for any reason.
 	failed bool
 
+	// If this operation is queued behind a parallelism limit.
+	waiting bool
+
 	diagInfo       *DiagInfo
 	policyPayloads []engine.PolicyViolationEventPayload
 
@@ -176,6 +180,10 @@ func (data *resourceRowData) SetFailed() {
 	data.failed = true
 }
 
+func (data *resourceRowData) SetWaiting(waiting bool) {
+	data.waiting = waiting
+}
+
 func (data *resourceRowData) DiagInfo() *DiagInfo {
 	return data.diagInfo
 }
@@ -305,6 +313,8 @@ func (data *resourceRowData) ColorizedColumns() []string {
 	if data.IsDone() {
 		failed := data.failed || diagInfo.ErrorCount > 0
 		columns[statusColumn] = data.display.getStepDoneDescription(step, failed)
+	} else if data.waiting {
+		columns[statusColumn] = colors.SpecUnimportant + "waiting" + colors.Reset
 	} else {
 		columns[statusColumn] = data.display.getStepInProgressDescription(step)
 	}
@@ -347,6 +357,9 @@ func (data *resourceRowData) getInfoColumn() string {
 	if colors.Never.Colorize(changes) != "" {
 		appendDiagMessage("[" + changes + "]")
 	}
+	if isRetainedDelete(data.step) {
+		appendDiagMessage("[retain]")
+	}
 
 	diagInfo := data.diagInfo
 	if data.display.done {
@@ -434,6 +447,8 @@ func getDiffInfo(step engine.StepEventMetadata, action apitype.UpdateKind) strin
 			resource.NewStringProperty(step.Old.Provider), resource.NewStringProperty(step.New.Provider))
 		recordMetadataDiff("protect",
 			resource.NewBoolProperty(step.Old.Protect), resource.NewBoolProperty(step.New.Protect))
+		recordMetadataDiff("retainOnDelete",
+			resource.NewBoolProperty(step.Old.RetainOnDelete), resource.NewBoolProperty(step.New.RetainOnDelete))
 
 		if diff != nil {
 			writeString(changesBuf, "diff: ")
@@ -68,6 +68,12 @@ func ShowWatchEvents(op string, action apitype.UpdateKind, events <-chan engine.
 				PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
 					"%s %s\n", p.Metadata.Op, p.Metadata.URN.Type())
 			}
+		case engine.ResourceWaitingEvent:
+			p := e.Payload().(engine.ResourceWaitingEventPayload)
+			if shouldShow(p.Metadata, opts) {
+				PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
+					"waiting %s %s (%s parallelism limit)\n", p.Metadata.Op, p.Metadata.URN.Type(), p.Limit)
+			}
 		case engine.ResourceOutputsEvent:
 			p := e.Payload().(engine.ResourceOutputsEventPayload)
 			if shouldShow(p.Metadata, opts) {
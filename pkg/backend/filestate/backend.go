@@ -23,6 +23,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -62,6 +63,15 @@ import (
 type Backend interface {
 	backend.Backend
 	local() // at the moment, no local specific info, so just use a marker function.
+
+	// StackLocks returns the locks currently held on the given stack by other processes.
+	StackLocks(ctx context.Context, stackRef backend.StackReference) ([]StackLock, error)
+	// CancelCurrentUpdate removes every lock held on the given stack. The processes that held the locks are not
+	// stopped, so this should only be used once they have died.
+	CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error
+
+	// Upgrade moves the stacks in a backend that uses the legacy layout into the layout that scopes stacks by project.
+	Upgrade(ctx context.Context) error
 }
 
 type localBackend struct {
@@ -77,20 +87,42 @@ type localBackend struct {
 	mutex  sync.Mutex
 
 	lockID string
+	// leases tracks the renewal of the lease on each lock held by this backend.
+	leases      map[string]*lockLease
+	leasesMutex sync.Mutex
+
+	// meta describes how the state in the bucket is laid out.
+	meta *pulumiMeta
+	// currentProject is the name of the project in the current workspace, if any. Unqualified stack names refer to
+	// stacks in this project.
+	currentProject tokens.PackageName
 }
 
 type localBackendReference struct {
 	name tokens.QName
+	// project is the project that the stack belongs to. It is empty for stacks stored in the legacy layout, which are
+	// not scoped by project.
+	project tokens.PackageName
+	// currentProject is the project of the current workspace, which is used to decide whether the reference needs to
+	// be qualified by its project when displayed.
+	currentProject tokens.PackageName
 }
 
 func (r localBackendReference) String() string {
-	return string(r.name)
+	if r.project == "" || r.project == r.currentProject {
+		return string(r.name)
+	}
+	return fmt.Sprintf("%s/%s", r.project, r.name)
 }
 
 func (r localBackendReference) Name() tokens.QName {
 	return r.name
 }
 
+func (r localBackendReference) Project() (tokens.PackageName, bool) {
+	return r.project, r.project != ""
+}
+
 func IsFileStateBackendURL(urlstr string) bool {
 	u, err := url.Parse(urlstr)
 	if err != nil {
@@ -151,12 +183,27 @@ func New(d diag.Sink, originalURL string) (Backend, error) {
 		return nil, err
 	}
 
+	wbucket := &wrappedBucket{bucket: bucket}
+	meta, err := readPulumiMeta(context.TODO(), wbucket)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unqualified stack names refer to stacks in the current project, if there is one.
+	var currentProject tokens.PackageName
+	if proj, err := workspace.DetectProject(); err == nil && proj != nil {
+		currentProject = proj.Name
+	}
+
 	return &localBackend{
-		d:           d,
-		originalURL: originalURL,
-		url:         u,
-		bucket:      &wrappedBucket{bucket: bucket},
-		lockID:      lockID.String(),
+		d:              d,
+		originalURL:    originalURL,
+		url:            u,
+		bucket:         wbucket,
+		lockID:         lockID.String(),
+		leases:         make(map[string]*lockLease),
+		meta:           meta,
+		currentProject: currentProject,
 	}, nil
 }
 
@@ -251,13 +298,71 @@ func (b *localBackend) SupportsOrganizations() bool {
 	return false
 }
 
+// ParseStackReference parses a stack name into a reference. When the backend's state is scoped by project, the name
+// may be qualified with the stack's project, as in `project/stack`; unqualified names refer to stacks in the current
+// project.
 func (b *localBackend) ParseStackReference(stackRefName string) (backend.StackReference, error) {
-	return localBackendReference{name: tokens.QName(stackRefName)}, nil
+	return b.parseStackReference(stackRefName)
+}
+
+func (b *localBackend) parseStackReference(stackRefName string) (*localBackendReference, error) {
+	if b.meta.Version == legacyLayoutVersion {
+		ref := &localBackendReference{name: tokens.QName(stackRefName)}
+
+		// If an interrupted upgrade has already moved the stack into the current project's directory, refer to it
+		// there instead.
+		if stackRefName != "" && b.currentProject != "" && !b.hasCheckpoint(ref) {
+			moved := &localBackendReference{name: ref.name, project: b.currentProject, currentProject: b.currentProject}
+			if b.hasCheckpoint(moved) {
+				return moved, nil
+			}
+		}
+		return ref, nil
+	}
+
+	project, name := b.currentProject, stackRefName
+	if idx := strings.Index(stackRefName, "/"); idx != -1 {
+		project, name = tokens.PackageName(stackRefName[:idx]), stackRefName[idx+1:]
+		if !tokens.IsPackageName(string(project)) {
+			return nil, errors.Errorf("%q is not a valid project name", project)
+		}
+	}
+	if project == "" {
+		return nil, errors.Errorf(
+			"no current project found; qualify the stack name with its project, e.g. 'my-project/%s'", name)
+	}
+
+	return &localBackendReference{
+		name:           tokens.QName(name),
+		project:        project,
+		currentProject: b.currentProject,
+	}, nil
+}
+
+// getReference converts a generic stack reference into one for this backend, re-parsing it if necessary.
+func (b *localBackend) getReference(ref backend.StackReference) (*localBackendReference, error) {
+	switch ref := ref.(type) {
+	case *localBackendReference:
+		return ref, nil
+	case localBackendReference:
+		return &ref, nil
+	default:
+		return b.parseStackReference(ref.String())
+	}
 }
 
 // ValidateStackName verifies the stack name is valid for the local backend. We use the same rules as the
-// httpstate backend.
+// httpstate backend. When the backend's state is scoped by project, the name may be qualified with its project.
 func (b *localBackend) ValidateStackName(stackName string) error {
+	if b.meta.Version != legacyLayoutVersion {
+		if idx := strings.Index(stackName, "/"); idx != -1 {
+			if project := stackName[:idx]; !tokens.IsPackageName(project) {
+				return errors.Errorf("%q is not a valid project name", project)
+			}
+			stackName = stackName[idx+1:]
+		}
+	}
+
 	if strings.Contains(stackName, "/") {
 		return errors.New("stack names may not contain slashes")
 	}
@@ -278,7 +383,7 @@ func (b *localBackend) DoesProjectExist(ctx context.Context, projectName string)
 func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackReference,
 	opts interface{}) (backend.Stack, error) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stackRef)
 		if err != nil {
 			return nil, err
@@ -288,13 +393,17 @@ func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackRe
 
 	contract.Requiref(opts == nil, "opts", "local stacks do not support any options")
 
-	stackName := stackRef.Name()
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+	stackName := ref.Name()
 	if stackName == "" {
 		return nil, errors.New("invalid empty stack name")
 	}
 
-	if _, _, err := b.getStack(stackName); err == nil {
-		return nil, &backend.StackAlreadyExistsError{StackName: string(stackName)}
+	if _, _, err := b.getStack(ref); err == nil {
+		return nil, &backend.StackAlreadyExistsError{StackName: ref.String()}
 	}
 
 	tags, err := backend.GetEnvironmentTagsForCurrentStack()
@@ -305,44 +414,64 @@ func (b *localBackend) CreateStack(ctx context.Context, stackRef backend.StackRe
 		return nil, errors.Wrap(err, "validating stack properties")
 	}
 
-	file, err := b.saveStack(stackName, nil, nil)
+	file, err := b.saveStack(ref, nil, nil)
 	if err != nil {
 		return nil, err
 	}
+	if err = b.saveStackTags(ref, tags); err != nil {
+		return nil, err
+	}
 
-	stack := newStack(stackRef, file, nil, b)
+	stack := newStack(ref, file, nil, b)
 	fmt.Printf("Created stack '%s'\n", stack.Ref())
 
 	return stack, nil
 }
 
 func (b *localBackend) GetStack(ctx context.Context, stackRef backend.StackReference) (backend.Stack, error) {
-	stackName := stackRef.Name()
-	snapshot, path, err := b.getStack(stackName)
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, path, err := b.getStack(ref)
 	switch {
 	case gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound:
 		return nil, nil
 	case err != nil:
 		return nil, err
 	default:
-		return newStack(stackRef, path, snapshot, b), nil
+		return newStack(ref, path, snapshot, b), nil
 	}
 }
 
 func (b *localBackend) ListStacks(
-	ctx context.Context, _ backend.ListStacksFilter) ([]backend.StackSummary, error) {
-	stacks, err := b.getLocalStacks()
+	ctx context.Context, filter backend.ListStacksFilter) ([]backend.StackSummary, error) {
+	stacks, err := b.getLocalStacks(filter.Project)
 	if err != nil {
 		return nil, err
 	}
 
-	// Note that the provided stack filter is not honored, since fields like
-	// organizations and tags aren't persisted in the local backend.
+	// Note that the organization filter is not honored, since organizations aren't persisted in the local backend.
 	var results []backend.StackSummary
-	for _, stackName := range stacks {
-		stack, err := b.GetStack(ctx, localBackendReference{name: stackName})
+	for _, ref := range stacks {
+		if filter.TagName != nil {
+			tags, err := b.getStackTags(ref)
+			if err != nil {
+				return nil, err
+			}
+			value, has := tags[*filter.TagName]
+			if !has || (filter.TagValue != nil && value != *filter.TagValue) {
+				continue
+			}
+		}
+
+		stack, err := b.GetStack(ctx, ref)
 		if err != nil {
-			return nil, err
+			// Stacks that can't be decrypted are listed without any details of their contents.
+			if !isDecryptionError(err) {
+				return nil, err
+			}
+			stack = newStack(ref, b.stackPath(ref), nil, b)
 		}
 		localStack, ok := stack.(*localStack)
 		contract.Assertf(ok, "localBackend GetStack returned non-localStack")
@@ -354,7 +483,7 @@ func (b *localBackend) ListStacks(
 
 func (b *localBackend) RemoveStack(ctx context.Context, stack backend.Stack, force bool) (bool, error) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stack.Ref())
 		if err != nil {
 			return false, err
@@ -362,8 +491,11 @@ func (b *localBackend) RemoveStack(ctx context.Context, stack backend.Stack, for
 		defer b.Unlock(ctx, stack.Ref())
 	}
 
-	stackName := stack.Ref().Name()
-	snapshot, _, err := b.getStack(stackName)
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return false, err
+	}
+	snapshot, _, err := b.getStack(ref)
 	if err != nil {
 		return false, err
 	}
@@ -373,13 +505,13 @@ func (b *localBackend) RemoveStack(ctx context.Context, stack backend.Stack, for
 		return true, errors.New("refusing to remove stack because it still contains resources")
 	}
 
-	return false, b.removeStack(stackName)
+	return false, b.removeStack(ref)
 }
 
 func (b *localBackend) RenameStack(ctx context.Context, stack backend.Stack,
 	newName tokens.QName) (backend.StackReference, error) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stack.Ref())
 		if err != nil {
 			return nil, err
@@ -388,45 +520,60 @@ func (b *localBackend) RenameStack(ctx context.Context, stack backend.Stack,
 	}
 
 	// Get the current state from the stack to be renamed.
-	stackName := stack.Ref().Name()
-	snap, _, err := b.getStack(stackName)
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return nil, err
+	}
+	snap, _, err := b.getStack(ref)
 	if err != nil {
 		return nil, err
 	}
 
-	// Ensure the new stack name is valid.
-	newRef, err := b.ParseStackReference(string(newName))
+	// Ensure the new stack name is valid. If the backend is scoped by project, the new name may also move the stack
+	// to a different project.
+	newRef, err := b.parseStackReference(string(newName))
 	if err != nil {
 		return nil, err
 	}
 
 	// Ensure the destination stack does not already exist.
-	hasExisting, err := b.bucket.Exists(ctx, b.stackPath(newName))
+	hasExisting, err := b.bucket.Exists(ctx, b.stackPath(newRef))
 	if err != nil {
 		return nil, err
 	}
 	if hasExisting {
-		return nil, errors.Errorf("a stack named %s already exists", newName)
+		return nil, errors.Errorf("a stack named %s already exists", newRef)
 	}
 
 	// If we have a snapshot, we need to rename the URNs inside it to use the new stack name.
 	if snap != nil {
-		if err = edit.RenameStack(snap, newName, ""); err != nil {
+		var newProject tokens.PackageName
+		if newRef.project != ref.project {
+			newProject = newRef.project
+		}
+		if err = edit.RenameStack(snap, newRef.Name(), newProject); err != nil {
 			return nil, err
 		}
 	}
 
 	// Now save the snapshot with a new name (we pass nil to re-use the existing secrets manager from the snapshot).
-	if _, err = b.saveStack(newName, snap, nil); err != nil {
+	if _, err = b.saveStack(newRef, snap, nil); err != nil {
 		return nil, err
 	}
 
-	// To remove the old stack, just make a backup of the file and don't write out anything new.
-	file := b.stackPath(stackName)
+	// To remove the old stack, just make a backup of the file and don't write out anything new. Any changes in the
+	// old stack's journal were included in the snapshot that was just saved.
+	file := b.stackPath(ref)
 	backupTarget(b.bucket, file)
+	if err = b.removeJournal(ref); err != nil {
+		return nil, err
+	}
 
-	// And rename the histoy folder as well.
-	if err = b.renameHistory(stackName, newName); err != nil {
+	// And rename the histoy folder and tags as well.
+	if err = b.renameHistory(ref, newRef); err != nil {
+		return nil, err
+	}
+	if err = b.renameStackTags(ref, newRef); err != nil {
 		return nil, err
 	}
 	return newRef, err
@@ -455,12 +602,12 @@ func (b *localBackend) PackPolicies(
 }
 
 func (b *localBackend) Preview(ctx context.Context, stack backend.Stack,
-	op backend.UpdateOperation) (engine.ResourceChanges, result.Result) {
+	op backend.UpdateOperation) (*deploy.Plan, engine.ResourceChanges, result.Result) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stack.Ref())
 		if err != nil {
-			return nil, result.FromError(err)
+			return nil, nil, result.FromError(err)
 		}
 		defer b.Unlock(ctx, stack.Ref())
 	}
@@ -476,7 +623,7 @@ func (b *localBackend) Preview(ctx context.Context, stack backend.Stack,
 func (b *localBackend) Update(ctx context.Context, stack backend.Stack,
 	op backend.UpdateOperation) (engine.ResourceChanges, result.Result) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stack.Ref())
 		if err != nil {
 			return nil, result.FromError(err)
@@ -490,7 +637,7 @@ func (b *localBackend) Update(ctx context.Context, stack backend.Stack,
 func (b *localBackend) Import(ctx context.Context, stack backend.Stack,
 	op backend.UpdateOperation, imports []deploy.Import) (engine.ResourceChanges, result.Result) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stack.Ref())
 		if err != nil {
 			return nil, result.FromError(err)
@@ -505,7 +652,7 @@ func (b *localBackend) Import(ctx context.Context, stack backend.Stack,
 func (b *localBackend) Refresh(ctx context.Context, stack backend.Stack,
 	op backend.UpdateOperation) (engine.ResourceChanges, result.Result) {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stack.Ref())
 		if err != nil {
 			return nil, result.FromError(err)
@@ -542,9 +689,12 @@ func (b *localBackend) Watch(ctx context.Context, stack backend.Stack,
 func (b *localBackend) apply(
 	ctx context.Context, kind apitype.UpdateKind, stack backend.Stack,
 	op backend.UpdateOperation, opts backend.ApplierOptions,
-	events chan<- engine.Event) (engine.ResourceChanges, result.Result) {
+	events chan<- engine.Event) (*deploy.Plan, engine.ResourceChanges, result.Result) {
 
-	stackRef := stack.Ref()
+	stackRef, err := b.getReference(stack.Ref())
+	if err != nil {
+		return nil, nil, result.FromError(err)
+	}
 	stackName := stackRef.Name()
 	actionLabel := backend.ActionLabel(kind, opts.DryRun)
 
@@ -554,10 +704,22 @@ func (b *localBackend) apply(
 			colors.SpecHeadline+"%s (%s):"+colors.Reset+"\n"), actionLabel, stackRef)
 	}
 
+	// Pick up any changes to the stack's environment tags, e.g. a new project description, as the service does when
+	// an update starts.
+	if !opts.DryRun {
+		tags, err := backend.GetMergedStackTags(ctx, stack)
+		if err != nil {
+			return nil, nil, result.FromError(errors.Wrap(err, "getting stack tags"))
+		}
+		if err = b.saveStackTags(stackRef, tags); err != nil {
+			return nil, nil, result.FromError(err)
+		}
+	}
+
 	// Start the update.
-	update, err := b.newUpdate(stackName, op)
+	update, err := b.newUpdate(stackRef, op)
 	if err != nil {
-		return nil, result.FromError(err)
+		return nil, nil, result.FromError(err)
 	}
 
 	// Spawn a display loop to show events on the CLI.
@@ -587,7 +749,7 @@ func (b *localBackend) apply(
 	}()
 
 	// Create the management machinery.
-	persister := b.newSnapshotPersister(stackName, op.SecretsManager)
+	persister := b.newSnapshotPersister(stackRef, op.SecretsManager)
 	manager := backend.NewSnapshotManager(persister, update.GetTarget().Snapshot)
 	engineCtx := &engine.Context{
 		Cancel:          scope.Context(),
@@ -598,19 +760,20 @@ func (b *localBackend) apply(
 
 	// Perform the update
 	start := time.Now().Unix()
+	var plan *deploy.Plan
 	var changes engine.ResourceChanges
 	var updateRes result.Result
 	switch kind {
 	case apitype.PreviewUpdate:
-		changes, updateRes = engine.Update(update, engineCtx, op.Opts.Engine, true)
+		plan, changes, updateRes = engine.Update(update, engineCtx, op.Opts.Engine, true)
 	case apitype.UpdateUpdate:
-		changes, updateRes = engine.Update(update, engineCtx, op.Opts.Engine, opts.DryRun)
+		plan, changes, updateRes = engine.Update(update, engineCtx, op.Opts.Engine, opts.DryRun)
 	case apitype.ResourceImportUpdate:
-		changes, updateRes = engine.Import(update, engineCtx, op.Opts.Engine, op.Imports, opts.DryRun)
+		plan, changes, updateRes = engine.Import(update, engineCtx, op.Opts.Engine, op.Imports, opts.DryRun)
 	case apitype.RefreshUpdate:
-		changes, updateRes = engine.Refresh(update, engineCtx, op.Opts.Engine, opts.DryRun)
+		plan, changes, updateRes = engine.Refresh(update, engineCtx, op.Opts.Engine, opts.DryRun)
 	case apitype.DestroyUpdate:
-		changes, updateRes = engine.Destroy(update, engineCtx, op.Opts.Engine, opts.DryRun)
+		plan, changes, updateRes = engine.Destroy(update, engineCtx, op.Opts.Engine, opts.DryRun)
 	default:
 		contract.Failf("Unrecognized update kind: %s", kind)
 	}
@@ -626,6 +789,12 @@ func (b *localBackend) apply(
 	<-eventsDone
 	close(displayEvents)
 
+	// If the lock on the stack was lost while the update was running, another process may have updated the stack in
+	// the meantime, so the update fails even if it had no further changes to save.
+	if err := b.leaseError(stackRef); err != nil && updateRes == nil {
+		updateRes = result.FromError(err)
+	}
+
 	// Save update results.
 	backendUpdateResult := backend.SucceededResult
 	if updateRes != nil {
@@ -648,22 +817,22 @@ func (b *localBackend) apply(
 	var saveErr error
 	var backupErr error
 	if !opts.DryRun {
-		saveErr = b.addToHistory(stackName, info)
-		backupErr = b.backupStack(stackName)
+		saveErr = b.addToHistory(stackRef, info)
+		backupErr = b.backupStack(stackRef)
 	}
 
 	if updateRes != nil {
 		// We swallow saveErr and backupErr as they are less important than the updateErr.
-		return changes, updateRes
+		return plan, changes, updateRes
 	}
 
 	if saveErr != nil {
 		// We swallow backupErr as it is less important than the saveErr.
-		return changes, result.FromError(errors.Wrap(saveErr, "saving update info"))
+		return plan, changes, result.FromError(errors.Wrap(saveErr, "saving update info"))
 	}
 
 	if backupErr != nil {
-		return changes, result.FromError(errors.Wrap(backupErr, "saving backup"))
+		return plan, changes, result.FromError(errors.Wrap(backupErr, "saving backup"))
 	}
 
 	// Make sure to print a link to the stack's checkpoint before exiting.
@@ -673,10 +842,10 @@ func (b *localBackend) apply(
 		var link string
 		if strings.HasPrefix(b.url, FilePathPrefix) {
 			u, _ := url.Parse(b.url)
-			u.Path = filepath.ToSlash(path.Join(u.Path, b.stackPath(stackName)))
+			u.Path = filepath.ToSlash(path.Join(u.Path, b.stackPath(stackRef)))
 			link = u.String()
 		} else {
-			link, err = b.bucket.SignedURL(context.TODO(), b.stackPath(stackName), nil)
+			link, err = b.bucket.SignedURL(context.TODO(), b.stackPath(stackRef), nil)
 			if err != nil {
 				// set link to be empty to when there is an error to hide use of Permalinks
 				link = ""
@@ -699,7 +868,7 @@ func (b *localBackend) apply(
 		}
 	}
 
-	return changes, nil
+	return plan, changes, nil
 }
 
 // query executes a query program against the resource outputs of a locally hosted stack.
@@ -714,8 +883,11 @@ func (b *localBackend) GetHistory(
 	stackRef backend.StackReference,
 	pageSize int,
 	page int) ([]backend.UpdateInfo, error) {
-	stackName := stackRef.Name()
-	updates, err := b.getHistory(stackName, pageSize, page)
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+	updates, err := b.getHistory(ref, pageSize, page)
 	if err != nil {
 		return nil, err
 	}
@@ -725,8 +897,11 @@ func (b *localBackend) GetHistory(
 func (b *localBackend) GetLogs(ctx context.Context, stack backend.Stack, cfg backend.StackConfiguration,
 	query operations.LogQuery) ([]operations.LogEntry, error) {
 
-	stackName := stack.Ref().Name()
-	target, err := b.getTarget(stackName, cfg.Config, cfg.Decrypter)
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return nil, err
+	}
+	target, err := b.getTarget(ref, cfg.Config, cfg.Decrypter)
 	if err != nil {
 		return nil, err
 	}
@@ -760,8 +935,11 @@ func GetLogsForTarget(target *deploy.Target, query operations.LogQuery) ([]opera
 func (b *localBackend) ExportDeployment(ctx context.Context,
 	stk backend.Stack) (*apitype.UntypedDeployment, error) {
 
-	stackName := stk.Ref().Name()
-	snap, _, err := b.getStack(stackName)
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return nil, err
+	}
+	snap, _, err := b.getStack(ref)
 	if err != nil {
 		return nil, err
 	}
@@ -786,10 +964,41 @@ func (b *localBackend) ExportDeployment(ctx context.Context,
 	}, nil
 }
 
+func (b *localBackend) ExportDeploymentForVersion(ctx context.Context, stk backend.Stack,
+	version string) (*apitype.UntypedDeployment, error) {
+
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return nil, err
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, errors.Errorf("%q is not a valid stack version; versions are numbered from 1", version)
+	}
+	chk, err := b.getHistoryCheckpoint(ref, v)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := chk.Latest
+	if latest == nil {
+		latest = &apitype.DeploymentV3{}
+	}
+	data, err := json.Marshal(latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(data),
+	}, nil
+}
+
 func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 	deployment *apitype.UntypedDeployment) error {
 
-	if cmdutil.IsTruthy(os.Getenv(PulumiFilestateLockingEnvVar)) {
+	if lockingEnabled() {
 		err := b.Lock(ctx, stk.Ref())
 		if err != nil {
 			return err
@@ -797,8 +1006,11 @@ func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 		defer b.Unlock(ctx, stk.Ref())
 	}
 
-	stackName := stk.Ref().Name()
-	_, _, err := b.getStack(stackName)
+	ref, err := b.getReference(stk.Ref())
+	if err != nil {
+		return err
+	}
+	_, _, err = b.getStack(ref)
 	if err != nil {
 		return err
 	}
@@ -808,7 +1020,7 @@ func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 		return err
 	}
 
-	_, err = b.saveStack(stackName, snap, snap.SecretsManager)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
 	return err
 }
 
@@ -828,13 +1040,147 @@ func (b *localBackend) CurrentUser() (string, error) {
 	return user.Username, nil
 }
 
-func (b *localBackend) getLocalStacks() ([]tokens.QName, error) {
-	var stacks []tokens.QName
+func (b *localBackend) Upgrade(ctx context.Context) error {
+	if b.meta.Version >= projectLayoutVersion {
+		return nil
+	}
+
+	stacks, err := b.getLocalStacks(nil)
+	if err != nil {
+		return err
+	}
+
+	// Each stack's checkpoint is moved after the rest of its files, so that if the upgrade is interrupted, a stack
+	// whose checkpoint is still in the legacy layout has files left to move, and running the upgrade again resumes
+	// where it left off. Stacks that have already been moved are listed with their projects, and are skipped.
+	var skipped []string
+	for _, oldRef := range stacks {
+		if oldRef.project != "" {
+			continue
+		}
+
+		chk, err := b.getCheckpoint(oldRef)
+		if err != nil {
+			return errors.Wrapf(err, "reading checkpoint for stack %s", oldRef)
+		}
+
+		// Legacy stacks don't record their project, so recover it from the URNs of the stack's resources. Empty stacks
+		// are assumed to belong to the current project.
+		project := b.currentProject
+		if chk.Latest != nil && len(chk.Latest.Resources) > 0 {
+			project = chk.Latest.Resources[0].URN.Project()
+		}
+		if project == "" {
+			b.d.Warningf(diag.Message("", "skipping stack %s: unable to determine its project"), oldRef)
+			skipped = append(skipped, oldRef.String())
+			continue
+		}
+
+		newRef := &localBackendReference{name: oldRef.name, project: project, currentProject: b.currentProject}
+		if err := b.renameDirectory(b.historyDirectory(oldRef), b.historyDirectory(newRef)); err != nil {
+			return errors.Wrapf(err, "moving history for stack %s", oldRef)
+		}
+		if err := b.renameDirectory(b.journalDirectory(oldRef), b.journalDirectory(newRef)); err != nil {
+			return errors.Wrapf(err, "moving journal for stack %s", oldRef)
+		}
+		if err := b.renameDirectory(b.backupDirectory(oldRef), b.backupDirectory(newRef)); err != nil {
+			return errors.Wrapf(err, "moving backups for stack %s", oldRef)
+		}
+		if err := b.renameStackTags(oldRef, newRef); err != nil {
+			return errors.Wrapf(err, "moving tags for stack %s", oldRef)
+		}
+
+		oldPath, newPath := b.stackPath(oldRef), b.plainStackPath(newRef)
+		if strings.HasSuffix(oldPath, gzipExt) {
+			newPath += gzipExt
+		}
+		if err := renameObject(b.bucket, oldPath, newPath); err != nil {
+			return errors.Wrapf(err, "moving stack %s", oldRef)
+		}
+	}
+
+	// The backend keeps using the legacy layout until every stack has been moved, since stacks that remain in the
+	// legacy layout would not be found in the new one.
+	if len(skipped) > 0 {
+		return errors.Errorf("could not upgrade %d stack(s) whose project could not be determined: %s. Run "+
+			"`pulumi state upgrade` again from each stack's project directory to finish upgrading", len(skipped),
+			strings.Join(skipped, ", "))
+	}
+
+	meta := &pulumiMeta{Version: projectLayoutVersion}
+	if err := writePulumiMeta(ctx, b.bucket, meta); err != nil {
+		return err
+	}
+	b.meta = meta
+	return nil
+}
+
+// renameDirectory moves every file in the source directory into the destination directory.
+func (b *localBackend) renameDirectory(source, dest string) error {
+	files, err := listBucket(b.bucket, source)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir {
+			continue
+		}
+		if err := renameObject(b.bucket, file.Key, path.Join(dest, objectName(file))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getLocalStacks returns references to all of the stacks in the backend, optionally restricted to the given project.
+// Stacks stored in the legacy layout do not record their project, so the project filter is ignored for them. A backend
+// that still uses the legacy layout may also have stacks that an interrupted upgrade has already moved into their
+// projects' directories; these are listed along with the legacy stacks.
+func (b *localBackend) getLocalStacks(project *string) ([]*localBackendReference, error) {
+	var stacks []*localBackendReference
+	if b.meta.Version == legacyLayoutVersion {
+		legacyStacks, err := b.getLocalStacksIn(b.stacksDirectory(), "")
+		if err != nil {
+			return nil, err
+		}
+		stacks = legacyStacks
+	}
+
+	if project != nil {
+		projectStacks, err := b.getLocalStacksIn(path.Join(b.stacksDirectory(), *project), tokens.PackageName(*project))
+		if err != nil {
+			return nil, err
+		}
+		return append(stacks, projectStacks...), nil
+	}
+
+	// Stacks are grouped into a directory per project, so list each project's directory in turn.
+	dirs, err := listBucket(b.bucket, b.stacksDirectory())
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing stacks")
+	}
+
+	for _, dir := range dirs {
+		if !dir.IsDir {
+			continue
+		}
 
-	// Read the stack directory.
-	path := b.stackPath("")
+		project := path.Base(dir.Key)
+		projectStacks, err := b.getLocalStacksIn(path.Join(b.stacksDirectory(), project), tokens.PackageName(project))
+		if err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, projectStacks...)
+	}
 
-	files, err := listBucket(b.bucket, path)
+	return stacks, nil
+}
+
+// getLocalStacksIn returns references to all of the stacks whose checkpoints are stored in the given directory.
+func (b *localBackend) getLocalStacksIn(dir string, project tokens.PackageName) ([]*localBackendReference, error) {
+	var stacks []*localBackendReference
+
+	files, err := listBucket(b.bucket, dir)
 	if err != nil {
 		return nil, errors.Wrap(err, "error listing stacks")
 	}
@@ -845,22 +1191,34 @@ func (b *localBackend) getLocalStacks() ([]tokens.QName, error) {
 			continue
 		}
 
-		// Skip files without valid extensions (e.g., *.bak files).
-		stackfn := objectName(file)
+		// Skip files without valid extensions (e.g., *.bak files). Compressed checkpoints are named after the
+		// uncompressed file, with an additional .gz extension.
+		stackfn := strings.TrimSuffix(objectName(file), gzipExt)
 		ext := filepath.Ext(stackfn)
 		if _, has := encoding.Marshalers[ext]; !has {
 			continue
 		}
 
 		// Read in this stack's information.
-		name := tokens.QName(stackfn[:len(stackfn)-len(ext)])
-		_, _, err := b.getStack(name)
+		ref := &localBackendReference{
+			name:           tokens.QName(stackfn[:len(stackfn)-len(ext)]),
+			project:        project,
+			currentProject: b.currentProject,
+		}
+		_, _, err := b.getStack(ref)
 		if err != nil {
-			logging.V(5).Infof("error reading stack: %v (%v) skipping", name, err)
+			// A stack that can't be decrypted, e.g. because its passphrase isn't set, is still listed, so that it
+			// doesn't appear to have gone missing.
+			if isDecryptionError(err) {
+				b.d.Warningf(diag.Message("", "could not read stack %s: %v"), ref, err)
+				stacks = append(stacks, ref)
+				continue
+			}
+			logging.V(5).Infof("error reading stack: %v (%v) skipping", ref, err)
 			continue // failure reading the stack information.
 		}
 
-		stacks = append(stacks, name)
+		stacks = append(stacks, ref)
 	}
 
 	return stacks, nil
@@ -870,14 +1228,23 @@ func (b *localBackend) getLocalStacks() ([]tokens.QName, error) {
 func (b *localBackend) GetStackTags(ctx context.Context,
 	stack backend.Stack) (map[apitype.StackTagName]string, error) {
 
-	// The local backend does not currently persist tags.
-	return nil, errors.New("stack tags not supported in --local mode")
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return nil, err
+	}
+	return b.getStackTags(ref)
 }
 
 // UpdateStackTags updates the stacks's tags, replacing all existing tags.
 func (b *localBackend) UpdateStackTags(ctx context.Context,
 	stack backend.Stack, tags map[apitype.StackTagName]string) error {
 
-	// The local backend does not currently persist tags.
-	return errors.New("stack tags not supported in --local mode")
+	ref, err := b.getReference(stack.Ref())
+	if err != nil {
+		return err
+	}
+	if err := validation.ValidateStackTags(tags); err != nil {
+		return errors.Wrap(err, "validating stack tags")
+	}
+	return b.saveStackTags(ref, tags)
 }
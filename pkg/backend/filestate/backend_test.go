@@ -1,6 +1,11 @@
 package filestate
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -8,9 +13,17 @@ import (
 	"github.com/stretchr/testify/assert"
 	user "github.com/tweekmonster/luser"
 
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/operations"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
 func TestMassageBlobPath(t *testing.T) {
@@ -80,3 +93,258 @@ func TestGetLogsForTargetWithNoSnapshot(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, res)
 }
+
+func newTestBackend(t *testing.T, dir string) *localBackend {
+	sink := diag.DefaultSink(ioutil.Discard, ioutil.Discard, diag.FormatOptions{Color: colors.Never})
+	b, err := New(sink, FilePathPrefix+dir)
+	assert.NoError(t, err)
+	return b.(*localBackend)
+}
+
+func TestProjectLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBackend(t, dir)
+	assert.Equal(t, projectLayoutVersion, b.meta.Version)
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, "meta.json"))
+
+	// Unqualified stack names can't be resolved without a current project.
+	b.currentProject = ""
+	_, err = b.ParseStackReference("dev")
+	assert.Error(t, err)
+
+	// Stacks in different projects may share a name.
+	ctx := context.Background()
+	for _, name := range []string{"proj-a/dev", "proj-b/dev"} {
+		ref, err := b.ParseStackReference(name)
+		assert.NoError(t, err)
+		assert.Equal(t, name, ref.String())
+		_, err = b.CreateStack(ctx, ref, nil)
+		assert.NoError(t, err)
+	}
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "proj-a", "dev.json"))
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "proj-b", "dev.json"))
+
+	stacks, err := b.ListStacks(ctx, backend.ListStacksFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, stacks, 2)
+
+	project := "proj-a"
+	stacks, err = b.ListStacks(ctx, backend.ListStacksFilter{Project: &project})
+	assert.NoError(t, err)
+	if assert.Len(t, stacks, 1) {
+		assert.Equal(t, "proj-a/dev", stacks[0].Name().String())
+	}
+
+	// Stacks in the current project may be referred to without qualification.
+	b.currentProject = "proj-b"
+	ref, err := b.ParseStackReference("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", ref.String())
+	stack, err := b.GetStack(ctx, ref)
+	assert.NoError(t, err)
+	assert.NotNil(t, stack)
+}
+
+func TestUpgradeLegacyLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Write a stack in the legacy layout.
+	assert.NoError(t, os.Setenv(PulumiFilestateLegacyLayoutEnvVar, "true"))
+	b := newTestBackend(t, dir)
+	assert.NoError(t, os.Unsetenv(PulumiFilestateLegacyLayoutEnvVar))
+	assert.Equal(t, legacyLayoutVersion, b.meta.Version)
+
+	ref, err := b.parseStackReference("dev")
+	assert.NoError(t, err)
+	urn := resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev")
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), []*resource.State{
+		{URN: urn, Type: resource.RootStackType},
+	}, nil)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: "update"}))
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "dev.json"))
+
+	// Re-opening the backend keeps using the legacy layout, since it already contains stacks.
+	b = newTestBackend(t, dir)
+	assert.Equal(t, legacyLayoutVersion, b.meta.Version)
+
+	assert.NoError(t, b.Upgrade(context.Background()))
+	assert.Equal(t, projectLayoutVersion, b.meta.Version)
+	assert.NoFileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "dev.json"))
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "proj", "dev.json"))
+
+	// The upgrade persists, and the stack and its history are found in their new locations.
+	b = newTestBackend(t, dir)
+	assert.Equal(t, projectLayoutVersion, b.meta.Version)
+	newRef, err := b.ParseStackReference("proj/dev")
+	assert.NoError(t, err)
+	stack, err := b.GetStack(context.Background(), newRef)
+	assert.NoError(t, err)
+	assert.NotNil(t, stack)
+	history, err := b.GetHistory(context.Background(), newRef, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestUpgradeLegacyLayoutSkippedStack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Write two stacks in the legacy layout. The project of the empty stack cannot be determined.
+	assert.NoError(t, os.Setenv(PulumiFilestateLegacyLayoutEnvVar, "true"))
+	b := newTestBackend(t, dir)
+	assert.NoError(t, os.Unsetenv(PulumiFilestateLegacyLayoutEnvVar))
+	b.currentProject = ""
+
+	ref, err := b.parseStackReference("dev")
+	assert.NoError(t, err)
+	urn := resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev")
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), []*resource.State{
+		{URN: urn, Type: resource.RootStackType},
+	}, nil)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	emptyRef, err := b.parseStackReference("empty")
+	assert.NoError(t, err)
+	_, err = b.saveStack(emptyRef, nil, nil)
+	assert.NoError(t, err)
+
+	// The upgrade moves the stack that it can, but keeps using the legacy layout.
+	assert.Error(t, b.Upgrade(context.Background()))
+	assert.Equal(t, legacyLayoutVersion, b.meta.Version)
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "proj", "dev.json"))
+	b = newTestBackend(t, dir)
+	assert.Equal(t, legacyLayoutVersion, b.meta.Version)
+
+	// The moved stack is still listed, and can be found by its name from within its project.
+	b.currentProject = ""
+	stacks, err := b.getLocalStacks(nil)
+	assert.NoError(t, err)
+	var names []string
+	for _, s := range stacks {
+		names = append(names, s.String())
+	}
+	assert.ElementsMatch(t, []string{"empty", "proj/dev"}, names)
+
+	b.currentProject = "proj"
+	ref, err = b.parseStackReference("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, tokens.PackageName("proj"), ref.project)
+	stack, err := b.GetStack(context.Background(), ref)
+	assert.NoError(t, err)
+	assert.NotNil(t, stack)
+
+	// Running the upgrade again from within a project finishes it.
+	assert.NoError(t, b.Upgrade(context.Background()))
+	assert.Equal(t, projectLayoutVersion, b.meta.Version)
+	assert.FileExists(t, filepath.Join(dir, workspace.BookkeepingDir, workspace.StackDir, "proj", "empty.json"))
+}
+
+func TestStackTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBackend(t, dir)
+	ctx := context.Background()
+
+	var stacks []backend.Stack
+	for _, name := range []string{"proj/dev", "proj/prod"} {
+		ref, err := b.ParseStackReference(name)
+		assert.NoError(t, err)
+		stack, err := b.CreateStack(ctx, ref, nil)
+		assert.NoError(t, err)
+		stacks = append(stacks, stack)
+	}
+
+	assert.NoError(t, b.UpdateStackTags(ctx, stacks[0], map[apitype.StackTagName]string{"env": "dev", "team": "a"}))
+	assert.NoError(t, b.UpdateStackTags(ctx, stacks[1], map[apitype.StackTagName]string{"env": "prod"}))
+	assert.Error(t, b.UpdateStackTags(ctx, stacks[1], map[apitype.StackTagName]string{"not a tag": "value"}))
+
+	tags, err := b.GetStackTags(ctx, stacks[0])
+	assert.NoError(t, err)
+	assert.Equal(t, map[apitype.StackTagName]string{"env": "dev", "team": "a"}, tags)
+
+	listNames := func(tagName string, tagValue *string) []string {
+		summaries, err := b.ListStacks(ctx, backend.ListStacksFilter{TagName: &tagName, TagValue: tagValue})
+		assert.NoError(t, err)
+		var names []string
+		for _, summary := range summaries {
+			names = append(names, summary.Name().String())
+		}
+		return names
+	}
+	prod := "prod"
+	assert.Equal(t, []string{"proj/dev", "proj/prod"}, listNames("env", nil))
+	assert.Equal(t, []string{"proj/prod"}, listNames("env", &prod))
+	assert.Equal(t, []string{"proj/dev"}, listNames("team", nil))
+
+	// Tags follow the stack when it is renamed, and are removed along with it.
+	newRef, err := stacks[0].Rename(ctx, "proj/staging")
+	assert.NoError(t, err)
+	renamed, err := b.GetStack(ctx, newRef)
+	assert.NoError(t, err)
+	tags, err = b.GetStackTags(ctx, renamed)
+	assert.NoError(t, err)
+	assert.Equal(t, map[apitype.StackTagName]string{"env": "dev", "team": "a"}, tags)
+
+	_, err = b.RemoveStack(ctx, renamed, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"proj/prod"}, listNames("env", nil))
+}
+
+func TestExportDeploymentForVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+	stk, err := b.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	// Record two updates, each of which leaves a different set of resources behind.
+	resources := []*resource.State{{
+		URN:  resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev"),
+		Type: resource.RootStackType,
+	}}
+	for i := 0; i < 2; i++ {
+		snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), resources, nil)
+		_, err = b.saveStack(ref, snap, snap.SecretsManager)
+		assert.NoError(t, err)
+		assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+
+		resources = append(resources, &resource.State{
+			URN:    resource.NewURN("dev", "proj", "", "pkg:index:typ", tokens.QName(fmt.Sprintf("res-%d", i))),
+			Type:   "pkg:index:typ",
+			Parent: resources[0].URN,
+		})
+	}
+
+	history, err := b.GetHistory(ctx, ref, 0, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, 2, history[0].Version)
+		assert.Equal(t, 1, history[1].Version)
+	}
+
+	deployment, err := b.ExportDeploymentForVersion(ctx, stk, "1")
+	assert.NoError(t, err)
+	var v3 apitype.DeploymentV3
+	assert.NoError(t, json.Unmarshal(deployment.Deployment, &v3))
+	assert.Len(t, v3.Resources, 1)
+
+	_, err = b.ExportDeploymentForVersion(ctx, stk, "3")
+	assert.Error(t, err)
+	_, err = b.ExportDeploymentForVersion(ctx, stk, "latest")
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// PulumiFilestateGzipEnvVar is an env var that, when truthy, makes the filestate backend compress the checkpoints and
+// history that it writes with gzip, and save the checkpoint for each update in a stack's history as content-addressed
+// objects shared across updates. Compressed files and such histories are always read, regardless of this setting.
+const PulumiFilestateGzipEnvVar = "PULUMI_SELF_MANAGED_STATE_GZIP"
+
+// gzipExt is the extension appended to the names of compressed files.
+const gzipExt = ".gz"
+
+// gzipEnabled returns true if newly written files should be compressed.
+func gzipEnabled() bool {
+	return cmdutil.IsTruthy(os.Getenv(PulumiFilestateGzipEnvVar))
+}
+
+// isGzipped returns true if the given data starts with the gzip magic number.
+func isGzipped(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// compress compresses the given data with gzip.
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// maybeCompress compresses the given data and appends gzipExt to the given file name if compression is enabled.
+func maybeCompress(file string, data []byte) (string, []byte, error) {
+	if !gzipEnabled() {
+		return file, data, nil
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "compressing %s", file)
+	}
+	return file + gzipExt, compressed, nil
+}
+
+// maybeDecompress decompresses the given data if it was compressed with gzip, and otherwise returns it unchanged.
+func maybeDecompress(data []byte) ([]byte, error) {
+	if !isGzipped(data) {
+		return data, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(r)
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,238 @@
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+func TestMaybeDecompress(t *testing.T) {
+	data := []byte(`{"version":3}`)
+
+	plain, err := maybeDecompress(data)
+	assert.NoError(t, err)
+	assert.Equal(t, data, plain)
+
+	compressed, err := compress(data)
+	assert.NoError(t, err)
+	assert.True(t, isGzipped(compressed))
+	plain, err = maybeDecompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, data, plain)
+}
+
+func TestGzipCheckpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+	stk, err := b.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	resources := []*resource.State{{
+		URN:  resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev"),
+		Type: resource.RootStackType,
+	}}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), resources, nil)
+
+	// With compression enabled, the checkpoint is written to a .gz file and read back transparently.
+	plainPath := b.plainStackPath(ref)
+	os.Setenv(PulumiFilestateGzipEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateGzipEnvVar)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	assert.Equal(t, plainPath+gzipExt, b.stackPath(ref))
+	assert.NoFileExists(t, filepath.Join(dir, plainPath))
+	assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+
+	loaded, err := b.GetStack(ctx, ref)
+	assert.NoError(t, err)
+	loadedSnap, err := loaded.Snapshot(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, loadedSnap.Resources, 1)
+
+	stacks, err := b.ListStacks(ctx, backend.ListStacksFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, stacks, 1)
+
+	// Turning compression off again replaces the compressed checkpoint with a plain one.
+	os.Unsetenv(PulumiFilestateGzipEnvVar)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	assert.Equal(t, plainPath, b.stackPath(ref))
+	assert.NoFileExists(t, filepath.Join(dir, plainPath+gzipExt))
+	assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+
+	// Both the compressed and the plain history entries can be read.
+	history, err := b.GetHistory(ctx, ref, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	for _, version := range []string{"1", "2"} {
+		deployment, err := b.ExportDeploymentForVersion(ctx, stk, version)
+		assert.NoError(t, err)
+		var v3 apitype.DeploymentV3
+		assert.NoError(t, json.Unmarshal(deployment.Deployment, &v3))
+		assert.Len(t, v3.Resources, 1)
+	}
+}
+
+func TestHistoryObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+	stk, err := b.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	resources := []*resource.State{{
+		URN:  resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev"),
+		Type: resource.RootStackType,
+	}}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), resources, nil)
+
+	// Without compression, each update's checkpoint is saved as a copy that older versions of the CLI can read.
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	assert.NoDirExists(t, filepath.Join(dir, b.objectsDirectory()))
+
+	// With compression, updates that leave a resource unchanged share the object that stores its state.
+	os.Setenv(PulumiFilestateGzipEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateGzipEnvVar)
+	for i := 0; i < 3; i++ {
+		_, err = b.saveStack(ref, snap, snap.SecretsManager)
+		assert.NoError(t, err)
+		assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	}
+	objects, err := listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	// Updates recorded by older versions of the CLI, which saved a copy of the whole checkpoint, can still be read.
+	historyDir := filepath.Join(dir, b.historyDirectory(ref))
+	files, err := ioutil.ReadDir(historyDir)
+	assert.NoError(t, err)
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".checkpoint.manifest.json.gz") {
+			prefix := strings.TrimSuffix(file.Name(), ".checkpoint.manifest.json.gz")
+			chk, err := b.readHistoryCheckpointManifest(filepath.Join(b.historyDirectory(ref), file.Name()))
+			assert.NoError(t, err)
+			bytes, err := json.Marshal(chk)
+			assert.NoError(t, err)
+			versioned, err := json.Marshal(apitype.VersionedCheckpoint{
+				Version:    apitype.DeploymentSchemaVersionCurrent,
+				Checkpoint: bytes,
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(historyDir, prefix+".checkpoint.json"), versioned, 0600))
+			assert.NoError(t, os.Remove(filepath.Join(historyDir, file.Name())))
+			break
+		}
+	}
+
+	for _, version := range []string{"1", "2", "3", "4"} {
+		deployment, err := b.ExportDeploymentForVersion(ctx, stk, version)
+		assert.NoError(t, err)
+		var v3 apitype.DeploymentV3
+		assert.NoError(t, json.Unmarshal(deployment.Deployment, &v3))
+		assert.Len(t, v3.Resources, 1)
+	}
+}
+
+func TestCollectObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(PulumiFilestateGzipEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateGzipEnvVar)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+
+	// Each stack has a resource of its own, and both share an identical resource.
+	var refs []*localBackendReference
+	for _, name := range []string{"dev", "prod"} {
+		ref, err := b.parseStackReference("proj/" + name)
+		assert.NoError(t, err)
+		_, err = b.CreateStack(ctx, ref, nil)
+		assert.NoError(t, err)
+
+		resources := []*resource.State{
+			{URN: resource.NewURN("shared", "proj", "", "pkg:m:typ", "shared"), Type: "pkg:m:typ"},
+			{URN: resource.NewURN(tokens.QName(name), "proj", "", "pkg:m:typ", tokens.QName(name)), Type: "pkg:m:typ"},
+		}
+		snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), resources, nil)
+		_, err = b.saveStack(ref, snap, snap.SecretsManager)
+		assert.NoError(t, err)
+		assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+		refs = append(refs, ref)
+	}
+
+	objects, err := listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	assert.Len(t, objects, 3)
+
+	// Objects that were written recently are kept even if they are no longer referenced, as an update that is still
+	// in progress may be about to reference them.
+	_, err = b.RemoveStack(ctx, &localStack{ref: refs[0]}, true)
+	assert.NoError(t, err)
+	objects, err = listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	assert.Len(t, objects, 3)
+
+	// Once they are old enough, only the objects referenced by the remaining stack's history are kept.
+	old := time.Now().Add(-2 * objectGracePeriod)
+	for _, obj := range objects {
+		assert.NoError(t, os.Chtimes(filepath.Join(dir, obj.Key), old, old))
+	}
+	assert.NoError(t, b.collectObjects())
+	objects, err = listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+
+	chk, err := b.getHistoryCheckpoint(refs[1], 1)
+	assert.NoError(t, err)
+	assert.Len(t, chk.Latest.Resources, 2)
+
+	// Saving an old object that is still needed rewrites it, so that it cannot be collected before the manifest that
+	// references it has been saved.
+	assert.NoError(t, b.addToHistory(refs[1], backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	objects, err = listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	for _, obj := range objects {
+		assert.True(t, obj.ModTime.After(old.Add(objectGracePeriod)))
+	}
+
+	// Removing the last stack removes its objects along with it.
+	for _, obj := range objects {
+		assert.NoError(t, os.Chtimes(filepath.Join(dir, obj.Key), old, old))
+	}
+	_, err = b.RemoveStack(ctx, &localStack{ref: refs[1]}, true)
+	assert.NoError(t, err)
+	objects, err = listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+}
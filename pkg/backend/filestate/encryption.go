@@ -0,0 +1,184 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+// PulumiFilestateEncryptionEnvVar is an env var that, when truthy, makes the filestate backend encrypt the entire
+// contents of the checkpoints, history and journals that it writes with the stack's secrets manager, rather than only
+// the values that are marked as secret. Encrypted files are always read, regardless of this setting, provided that
+// the secrets manager that encrypted them is available (e.g. that PULUMI_CONFIG_PASSPHRASE is set). While it is set,
+// state is never written in plaintext: saving the state of a stack that has no secrets manager fails instead.
+//
+// A checkpoint that was written before encryption was enabled is encrypted when it is moved aside to its `.bak` file.
+// Copies that were made before then, in the stack's history and backups directories, are left as they are.
+const PulumiFilestateEncryptionEnvVar = "PULUMI_SELF_MANAGED_STATE_ENCRYPTION"
+
+// encryptionEnabled returns true if newly written files should be encrypted.
+func encryptionEnabled() bool {
+	return cmdutil.IsTruthy(os.Getenv(PulumiFilestateEncryptionEnvVar))
+}
+
+// encryptedFile is the format of a file whose entire contents have been encrypted.
+type encryptedFile struct {
+	// Encrypted describes the secrets manager that encrypted the contents, so that they can be decrypted without any
+	// other knowledge of the stack that they belong to. This is the same information that an unencrypted checkpoint
+	// records in plaintext.
+	Encrypted apitype.SecretsProvidersV1 `json:"encrypted"`
+	// Ciphertext is the encrypted contents. The plaintext is base64-encoded before it is encrypted, as the contents
+	// need not be text (e.g. if they are compressed).
+	Ciphertext string `json:"ciphertext"`
+}
+
+// encryptedFilePrefix is the prefix of every encrypted file, by which they are recognized.
+var encryptedFilePrefix = []byte(`{"encrypted":`)
+
+// isEncrypted returns true if the given data is the contents of an encrypted file.
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedFilePrefix)
+}
+
+// encrypt encrypts the given data with the given secrets manager.
+func encrypt(sm secrets.Manager, data []byte) ([]byte, error) {
+	enc, err := sm.Encrypter()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := enc.EncryptValue(base64.StdEncoding.EncodeToString(data))
+	if err != nil {
+		return nil, err
+	}
+
+	file := encryptedFile{Encrypted: apitype.SecretsProvidersV1{Type: sm.Type()}, Ciphertext: ciphertext}
+	if state := sm.State(); state != nil {
+		if file.Encrypted.State, err = json.Marshal(state); err != nil {
+			return nil, errors.Wrap(err, "marshalling secrets manager state")
+		}
+	}
+	return json.Marshal(file)
+}
+
+// errNoEncryptionSecretsManager is returned when encryption is enabled but there is no secrets manager with which to
+// encrypt a file. Rather than writing the file in plaintext, which would defeat the point of enabling encryption, the
+// write fails.
+var errNoEncryptionSecretsManager = errors.Errorf(
+	"%s is set, but the stack has no secrets provider to encrypt its state with; "+
+		"configure one with `pulumi stack change-secrets-provider`", PulumiFilestateEncryptionEnvVar)
+
+// maybeEncrypt encrypts the given data with the given secrets manager if encryption is enabled. If it is, but there is
+// no secrets manager, errNoEncryptionSecretsManager is returned.
+func maybeEncrypt(sm secrets.Manager, data []byte) ([]byte, error) {
+	if !encryptionEnabled() {
+		return data, nil
+	}
+	if sm == nil {
+		return nil, errNoEncryptionSecretsManager
+	}
+	encrypted, err := encrypt(sm, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting state")
+	}
+	return encrypted, nil
+}
+
+// decryptionError is returned when a file is encrypted but cannot be decrypted, e.g. because its secrets manager is not
+// available.
+type decryptionError struct {
+	file string
+	err  error
+}
+
+func (e *decryptionError) Error() string {
+	return fmt.Sprintf("decrypting %s: %v", e.file, e.err)
+}
+
+// isDecryptionError returns true if the given error was caused by a file that could not be decrypted.
+func isDecryptionError(err error) bool {
+	_, ok := errors.Cause(err).(*decryptionError)
+	return ok
+}
+
+// maybeDecrypt decrypts the given data if it was encrypted, and otherwise returns it unchanged.
+func maybeDecrypt(data []byte) ([]byte, error) {
+	if !isEncrypted(data) {
+		return data, nil
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	sm, err := stack.DefaultSecretsProvider.OfType(file.Encrypted.Type, file.Encrypted.State)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := sm.Decrypter()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := dec.DecryptValue(file.Ciphertext)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting state")
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// encodeFile prepares the given contents of the given file to be written, compressing them and then encrypting them
+// with the given secrets manager as requested. The name of the file is updated to match.
+func encodeFile(sm secrets.Manager, file string, data []byte) (string, []byte, error) {
+	file, data, err := maybeCompress(file, data)
+	if err != nil {
+		return "", nil, err
+	}
+	if data, err = maybeEncrypt(sm, data); err != nil {
+		return "", nil, errors.Wrapf(err, "encrypting %s", file)
+	}
+	return file, data, nil
+}
+
+// decodeFile returns the contents of a file that was written by encodeFile, decrypting and then decompressing them
+// as necessary.
+func decodeFile(file string, data []byte) ([]byte, error) {
+	data, err := maybeDecrypt(data)
+	if err != nil {
+		return nil, &decryptionError{file: file, err: err}
+	}
+	if data, err = maybeDecompress(data); err != nil {
+		return nil, errors.Wrapf(err, "decompressing %s", file)
+	}
+	return data, nil
+}
+
+// encryptionSecretsManager returns the secrets manager with which files belonging to the stack with the given
+// checkpoint should be encrypted, or nil if encryption is disabled.
+func encryptionSecretsManager(chk *apitype.CheckpointV3) (secrets.Manager, error) {
+	if !encryptionEnabled() || chk.Latest == nil || chk.Latest.SecretsProviders == nil {
+		return nil, nil
+	}
+	return stack.DefaultSecretsProvider.OfType(chk.Latest.SecretsProviders.Type, chk.Latest.SecretsProviders.State)
+}
@@ -0,0 +1,198 @@
+package filestate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestEncodeFile(t *testing.T) {
+	data := []byte(`{"version":3}`)
+	sm := b64.NewBase64SecretsManager()
+
+	// Without encryption enabled, the contents are written as-is.
+	file, encoded, err := encodeFile(sm, "stack.json", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "stack.json", file)
+	assert.Equal(t, data, encoded)
+
+	os.Setenv(PulumiFilestateEncryptionEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateEncryptionEnvVar)
+	os.Setenv(PulumiFilestateGzipEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateGzipEnvVar)
+
+	file, encoded, err = encodeFile(sm, "stack.json", data)
+	assert.NoError(t, err)
+	assert.Equal(t, "stack.json"+gzipExt, file)
+	assert.True(t, isEncrypted(encoded))
+	decoded, err := decodeFile(file, encoded)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	// Nothing is written in plaintext for want of a secrets manager.
+	_, _, err = encodeFile(nil, "stack.json", data)
+	assert.Equal(t, errNoEncryptionSecretsManager, errors.Cause(err))
+}
+
+func TestEncryptedCheckpointWithoutSecretsManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(PulumiFilestateEncryptionEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateEncryptionEnvVar)
+
+	// A new stack has no state to encrypt, so it can be created without a secrets manager.
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	// A deployment can't be saved without one, rather than being saved in plaintext.
+	urn := resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev")
+	resources := []*resource.State{{URN: urn, Type: resource.RootStackType}}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, nil, resources, nil)
+	_, err = b.saveStack(ref, snap, nil)
+	assert.Equal(t, errNoEncryptionSecretsManager, errors.Cause(err))
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, b.stackPath(ref)))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), string(urn))
+}
+
+func TestEncryptedCheckpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	urn := resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev")
+	resources := []*resource.State{{URN: urn, Type: resource.RootStackType}}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), resources, nil)
+
+	os.Setenv(PulumiFilestateEncryptionEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateEncryptionEnvVar)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	assert.NoError(t, b.addToHistory(ref, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+
+	// Neither the checkpoint nor its history reveal the stack's resources, and no objects are shared with other
+	// stacks.
+	files, err := listBucket(b.bucket, b.StateDir())
+	assert.NoError(t, err)
+	for _, file := range files {
+		if file.IsDir || filepath.Ext(file.Key) == ".attrs" || file.Key == metaPath() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Key))
+		assert.NoError(t, err)
+		assert.True(t, isEncrypted(data), file.Key)
+		assert.NotContains(t, string(data), string(urn), file.Key)
+	}
+	objects, err := listBucket(b.bucket, b.objectsDirectory())
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+
+	// Both are read back transparently.
+	loaded, err := b.GetStack(ctx, ref)
+	assert.NoError(t, err)
+	loadedSnap, err := loaded.Snapshot(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, loadedSnap.Resources, 1) {
+		assert.Equal(t, urn, loadedSnap.Resources[0].URN)
+	}
+
+	history, err := b.GetHistory(ctx, ref, 10, 1)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	chk, err := b.getHistoryCheckpoint(ref, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, chk.Latest.Resources, 1) {
+		assert.Equal(t, urn, chk.Latest.Resources[0].URN)
+	}
+
+	// Turning encryption off leaves existing files readable, and writes new ones in plaintext.
+	os.Unsetenv(PulumiFilestateEncryptionEnvVar)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(dir, b.stackPath(ref)))
+	assert.NoError(t, err)
+	assert.False(t, isEncrypted(data))
+	assert.Contains(t, string(data), string(urn))
+}
+
+func TestEncryptedBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+
+	urn := resource.NewURN("dev", "proj", "", resource.RootStackType, "proj-dev")
+	resources := []*resource.State{{URN: urn, Type: resource.RootStackType}}
+	snap := deploy.NewSnapshot(deploy.Manifest{}, b64.NewBase64SecretsManager(), resources, nil)
+	_, err = b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+
+	// Once encryption is enabled, the plaintext checkpoint is encrypted as it is backed up.
+	os.Setenv(PulumiFilestateEncryptionEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateEncryptionEnvVar)
+	file, err := b.saveStack(ref, snap, snap.SecretsManager)
+	assert.NoError(t, err)
+	data, err := ioutil.ReadFile(filepath.Join(dir, file+".bak"))
+	assert.NoError(t, err)
+	assert.True(t, isEncrypted(data))
+	assert.NotContains(t, string(data), string(urn))
+	decoded, err := decodeFile(file, data)
+	assert.NoError(t, err)
+	assert.Contains(t, string(decoded), string(urn))
+}
+
+func TestUndecryptableStacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+
+	// Write a checkpoint that was encrypted by a secrets manager that isn't available.
+	data := []byte(`{"encrypted":{"type":"unavailable"},"ciphertext":"secret"}`)
+	assert.NoError(t, b.bucket.WriteAll(ctx, b.plainStackPath(ref), data, nil))
+
+	_, err = b.GetStack(ctx, ref)
+	assert.True(t, isDecryptionError(err))
+
+	// The stack is still listed, without any details of its contents.
+	project := "proj"
+	summaries, err := b.ListStacks(ctx, backend.ListStacksFilter{Project: &project})
+	assert.NoError(t, err)
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, "proj/dev", summaries[0].Name().String())
+		assert.Nil(t, summaries[0].ResourceCount())
+		assert.Nil(t, summaries[0].LastUpdate())
+	}
+}
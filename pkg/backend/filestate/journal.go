@@ -0,0 +1,169 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// PulumiFilestateJournalingEnvVar is an env var that, when truthy, makes updates to stacks in the filestate backend
+// journal the changes made by each step, rather than rewriting the stack's whole checkpoint. The checkpoint is still
+// rewritten periodically, and when the update completes.
+const PulumiFilestateJournalingEnvVar = "PULUMI_SELF_MANAGED_STATE_JOURNALING"
+
+// journalingEnabled returns true if updates should journal their changes.
+func journalingEnabled() bool {
+	return cmdutil.IsTruthy(os.Getenv(PulumiFilestateJournalingEnvVar))
+}
+
+// localJournalingSnapshotPersister is a snapshot persister that, in addition to saving whole snapshots, appends
+// batches of changes to the stack's journal. Each batch is written as a separate object, so that appending to the
+// journal never rewrites what has already been written.
+type localJournalingSnapshotPersister struct {
+	localSnapshotPersister
+
+	seq int // the sequence number of the next batch
+}
+
+var _ backend.SnapshotJournaler = (*localJournalingSnapshotPersister)(nil)
+
+func (sp *localJournalingSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
+	if err := sp.localSnapshotPersister.Save(snapshot); err != nil {
+		return err
+	}
+
+	// The saved checkpoint includes every change recorded in the journal, which can now be discarded. Should this
+	// fail, the leftover batches are ignored by readers, as they refer to an older checkpoint.
+	if err := sp.backend.removeJournal(sp.ref); err != nil {
+		logging.V(5).Infof("failed to remove journal for stack %s: %v", sp.ref, err)
+	}
+	return nil
+}
+
+func (sp *localJournalingSnapshotPersister) Append(journal backend.Journal) error {
+	if err := sp.backend.leaseError(sp.ref); err != nil {
+		return err
+	}
+	enc, err := sp.sm.Encrypter()
+	if err != nil {
+		return err
+	}
+	sjournal, err := backend.SerializeJournal(journal, enc)
+	if err != nil {
+		return err
+	}
+	if err := sp.backend.appendJournal(sp.ref, sp.seq, sjournal, sp.sm); err != nil {
+		return err
+	}
+	sp.seq++
+	return nil
+}
+
+func (b *localBackend) journalDirectory(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return filepath.Join(b.StateDir(), workspace.JournalDir, ref.relativePath())
+}
+
+// appendJournal writes the given batch of changes to the stack's journal, with the given sequence number. If
+// encryption is enabled, the batch is encrypted with the given secrets manager.
+func (b *localBackend) appendJournal(ref *localBackendReference, seq int, journal apitype.JournalV1,
+	sm secrets.Manager) error {
+
+	byts, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(b.journalDirectory(ref), fmt.Sprintf("%020d.json", seq))
+	if file, byts, err = encodeFile(sm, file, byts); err != nil {
+		return err
+	}
+	if err = b.bucket.WriteAll(context.TODO(), file, byts, nil); err != nil {
+		return errors.Wrapf(err, "writing journal for stack %s", ref)
+	}
+	return nil
+}
+
+// getJournal reads every batch of changes in the stack's journal, in the order in which they were written.
+func (b *localBackend) getJournal(ref *localBackendReference) ([]apitype.JournalV1, error) {
+	files, err := listBucket(b.bucket, b.journalDirectory(ref))
+	if err != nil {
+		if gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "listing journal for stack %s", ref)
+	}
+
+	var journals []apitype.JournalV1
+	for _, file := range files {
+		if file.IsDir || filepath.Ext(strings.TrimSuffix(file.Key, gzipExt)) != ".json" {
+			continue
+		}
+		byts, err := b.bucket.ReadAll(context.TODO(), file.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading journal file %s", file.Key)
+		}
+		if byts, err = decodeFile(file.Key, byts); err != nil {
+			return nil, err
+		}
+		var journal apitype.JournalV1
+		if err = json.Unmarshal(byts, &journal); err != nil {
+			return nil, errors.Wrapf(err, "reading journal file %s", file.Key)
+		}
+		journals = append(journals, journal)
+	}
+	return journals, nil
+}
+
+// replayJournal applies any changes recorded in the stack's journal to the given checkpoint, which was loaded from
+// the stack's checkpoint file. This recovers the changes made by an update that was interrupted before it could save
+// the whole checkpoint.
+func (b *localBackend) replayJournal(ref *localBackendReference, chk *apitype.CheckpointV3) error {
+	if chk.Latest == nil {
+		return nil
+	}
+	journals, err := b.getJournal(ref)
+	if err != nil || len(journals) == 0 {
+		return err
+	}
+	replayed, err := stack.ReplayJournal(chk.Latest, journals)
+	if err != nil {
+		return errors.Wrapf(err, "replaying journal for stack %s", ref)
+	}
+	logging.V(5).Infof("replayed %d journal entries for stack %s", replayed, ref)
+	return nil
+}
+
+// removeJournal discards the stack's journal.
+func (b *localBackend) removeJournal(ref *localBackendReference) error {
+	return removeAllByPrefix(b.bucket, b.journalDirectory(ref))
+}
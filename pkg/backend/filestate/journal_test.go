@@ -0,0 +1,92 @@
+package filestate
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+type testRegisterResourceEvent struct {
+	deploy.SourceEvent
+}
+
+func (testRegisterResourceEvent) Goal() *resource.Goal               { return nil }
+func (testRegisterResourceEvent) Done(result *deploy.RegisterResult) {}
+
+func TestJournalRecovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(PulumiFilestateJournalingEnvVar, "true")
+	defer os.Unsetenv(PulumiFilestateJournalingEnvVar)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	newState := func(name string) *resource.State {
+		return &resource.State{
+			URN:     resource.NewURN("dev", "proj", "", "pkg:index:typ", tokens.QName(name)),
+			Type:    "pkg:index:typ",
+			Inputs:  resource.PropertyMap{},
+			Outputs: resource.PropertyMap{},
+		}
+	}
+
+	// Run part of an update, creating two resources and starting to create a third.
+	persister := b.newSnapshotPersister(ref, b64.NewBase64SecretsManager())
+	_, ok := persister.(backend.SnapshotJournaler)
+	assert.True(t, ok)
+	manager := backend.NewSnapshotManager(persister, deploy.NewSnapshot(deploy.Manifest{}, nil, nil, nil))
+	for _, name := range []string{"a", "b"} {
+		step := deploy.NewCreateStep(nil, testRegisterResourceEvent{}, newState(name))
+		mutation, err := manager.BeginMutation(step)
+		assert.NoError(t, err)
+		assert.NoError(t, mutation.End(step, true))
+	}
+	pending := deploy.NewCreateStep(nil, testRegisterResourceEvent{}, newState("c"))
+	_, err = manager.BeginMutation(pending)
+	assert.NoError(t, err)
+
+	journal, err := b.getJournal(ref)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, journal)
+
+	// Simulate a crash by reading the stack with a new backend, without closing the manager. The completed creates
+	// and the pending operation are recovered from the journal.
+	recovered := newTestBackend(t, dir)
+	stk, err := recovered.GetStack(ctx, ref)
+	assert.NoError(t, err)
+	snap, err := stk.Snapshot(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, snap.Resources, 2) {
+		assert.Equal(t, tokens.QName("a"), snap.Resources[0].URN.Name())
+		assert.Equal(t, tokens.QName("b"), snap.Resources[1].URN.Name())
+	}
+	if assert.Len(t, snap.PendingOperations, 1) {
+		assert.Equal(t, tokens.QName("c"), snap.PendingOperations[0].Resource.URN.Name())
+	}
+
+	// Once the update completes, the whole checkpoint is saved and the journal is discarded.
+	assert.NoError(t, manager.Close())
+	journal, err = b.getJournal(ref)
+	assert.NoError(t, err)
+	assert.Empty(t, journal)
+	chk, err := b.getCheckpoint(ref)
+	assert.NoError(t, err)
+	assert.Len(t, chk.Latest.Resources, 2)
+	assert.Len(t, chk.Latest.PendingOperations, 1)
+}
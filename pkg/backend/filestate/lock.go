@@ -21,26 +21,46 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
+	"gocloud.dev/gcerrors"
 
 	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
-	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
-	"github.com/pulumi/pulumi/sdk/v3/go/common/util/fsutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/retry"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
-// PulumiFilestateLockingEnvVar is an env var that must be truthy to enable locking when using a filestate backend.
+// PulumiFilestateLockingEnvVar is an env var that may be set to a falsy value (e.g. "0" or "false") to disable
+// locking when using a filestate backend. Locking is enabled by default.
 const PulumiFilestateLockingEnvVar = "PULUMI_SELF_MANAGED_STATE_LOCKING"
 
+// lockLeaseDuration is how long a lock is valid for without being renewed. The process holding a lock renews its
+// lease well before it runs out, so a lock whose lease has expired was left behind by a process that has died.
+var lockLeaseDuration = 5 * time.Minute
+
+// errLockLost is returned when renewing the lease on a lock finds that the lock has been removed, e.g. by `pulumi
+// cancel`, so that the stack may now be locked by another process.
+var errLockLost = errors.New("the lock has been removed")
+
+// lockingEnabled returns true unless locking has been explicitly disabled.
+func lockingEnabled() bool {
+	v := os.Getenv(PulumiFilestateLockingEnvVar)
+	return v == "" || cmdutil.IsTruthy(v)
+}
+
 type lockContent struct {
 	Pid       int       `json:"pid"`
 	Username  string    `json:"username"`
 	Hostname  string    `json:"hostname"`
 	Timestamp time.Time `json:"timestamp"`
+	// Expires is when the lock's lease runs out. It is zero for locks written by CLIs that predate leases.
+	Expires time.Time `json:"expires"`
 }
 
 func newLockContent() (*lockContent, error) {
@@ -52,53 +72,145 @@ func newLockContent() (*lockContent, error) {
 	if err != nil {
 		return nil, err
 	}
+	now := time.Now()
 	return &lockContent{
 		Pid:       os.Getpid(),
 		Username:  u.Username,
 		Hostname:  hostname,
-		Timestamp: time.Now(),
+		Timestamp: now,
+		Expires:   now.Add(lockLeaseDuration),
 	}, nil
 }
 
-// checkForLock looks for any existing locks for this stack, and returns a helpful diagnostic if there is one.
-func (b *localBackend) checkForLock(ctx context.Context, stackRef backend.StackReference) error {
-	allFiles, err := listBucket(b.bucket, stackLockDir(stackRef.Name()))
+// StackLock describes a lock held on a stack by a running operation.
+type StackLock struct {
+	// URL is the location of the lock file.
+	URL string
+	// Pid is the process ID of the process that holds the lock.
+	Pid int
+	// Username is the name of the user that holds the lock.
+	Username string
+	// Hostname is the name of the machine on which the lock is held.
+	Hostname string
+	// Timestamp is when the lock was acquired.
+	Timestamp time.Time
+	// Expires is when the lock's lease runs out, unless it is renewed. It is zero for locks that have no lease.
+	Expires time.Time
+}
+
+// Stale returns true if the lock's lease has run out, meaning that the process that held it is no longer running.
+func (l StackLock) Stale() bool {
+	return !l.Expires.IsZero() && l.Expires.Before(time.Now())
+}
+
+func (l StackLock) String() string {
+	s := fmt.Sprintf("%v: created by %v@%v (pid %v) at %v",
+		l.URL, l.Username, l.Hostname, l.Pid, l.Timestamp.Format(time.RFC3339))
+	if l.Stale() {
+		s += fmt.Sprintf(", expired at %v", l.Expires.Format(time.RFC3339))
+	}
+	return s
+}
+
+// getLocks returns the locks held on the given stack by other backends, along with their keys in the bucket.
+func (b *localBackend) getLocks(ctx context.Context, ref *localBackendReference) ([]StackLock, []string, error) {
+	allFiles, err := listBucket(b.bucket, stackLockDir(ref))
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	var lockKeys []string
+	var locks []StackLock
+	var keys []string
 	for _, file := range allFiles {
-		if file.IsDir {
+		// Skip anything that isn't a lock, such as the temporary files some buckets write while a lock's lease is
+		// being renewed.
+		if file.IsDir || file.Key == b.lockPath(ref) || path.Ext(file.Key) != ".json" {
 			continue
 		}
-		if file.Key != b.lockPath(stackRef.Name()) {
-			lockKeys = append(lockKeys, file.Key)
+
+		l, err := b.readLock(ctx, file.Key)
+		if err != nil {
+			if gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound {
+				continue // the lock was released while we were listing locks.
+			}
+			return nil, nil, err
 		}
+
+		locks = append(locks, StackLock{
+			URL:       b.url + "/" + file.Key,
+			Pid:       l.Pid,
+			Username:  l.Username,
+			Hostname:  l.Hostname,
+			Timestamp: l.Timestamp,
+			Expires:   l.Expires,
+		})
+		keys = append(keys, file.Key)
 	}
+	return locks, keys, nil
+}
 
-	if len(lockKeys) > 0 {
-		errorString := fmt.Sprintf("the stack is currently locked by %v lock(s). Either wait for the other "+
-			"process(es) to end or manually delete the lock file(s).", len(lockKeys))
+// readLock reads the lock at the given key. Reads are retried a few times, since a lock may be read while its lease is
+// being renewed, and not all buckets can read an object atomically while it is being overwritten.
+func (b *localBackend) readLock(ctx context.Context, key string) (*lockContent, error) {
+	delay := 10 * time.Millisecond
+	maxDelay := 100 * time.Millisecond
+	backoff := 2.0
 
-		for _, lock := range lockKeys {
-			content, err := b.bucket.ReadAll(ctx, lock)
-			if err != nil {
-				return err
+	_, l, err := retry.Until(ctx, retry.Acceptor{
+		Delay:    &delay,
+		MaxDelay: &maxDelay,
+		Backoff:  &backoff,
+		Accept: func(try int, nextRetryTime time.Duration) (bool, interface{}, error) {
+			content, err := b.bucket.ReadAll(ctx, key)
+			if err == nil {
+				l := &lockContent{}
+				if err = json.Unmarshal(content, l); err == nil {
+					return true, l, nil
+				}
 			}
-			l := &lockContent{}
-			err = json.Unmarshal(content, &l)
-			if err != nil {
-				return err
+			if try >= 5 || gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound {
+				return false, nil, err
 			}
+			return false, nil, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, ctx.Err()
+	}
+	return l.(*lockContent), nil
+}
 
-			errorString += fmt.Sprintf("\n  %v: created by %v@%v (pid %v) at %v",
-				b.url+"/"+lock,
-				l.Username,
-				l.Hostname,
-				l.Pid,
-				l.Timestamp.Format(time.RFC3339),
-			)
+// checkForLock looks for any existing locks for this stack, and returns a helpful diagnostic if there is one. Stale
+// locks, whose leases have expired, are removed.
+func (b *localBackend) checkForLock(ctx context.Context, ref *localBackendReference) error {
+	locks, keys, err := b.getLocks(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	var liveLocks []StackLock
+	for i, lock := range locks {
+		if !lock.Stale() {
+			liveLocks = append(liveLocks, lock)
+			continue
+		}
+
+		b.d.Warningf(diag.Message("", "removing stale lock %v"), lock)
+		if err := b.bucket.Delete(ctx, keys[i]); err != nil &&
+			gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound {
+			return errors.Wrapf(err, "removing stale lock %v", lock.URL)
+		}
+	}
+
+	if len(liveLocks) > 0 {
+		errorString := fmt.Sprintf("the stack is currently locked by %v lock(s). Either wait for the other "+
+			"process(es) to end or run `pulumi cancel` to remove the lock(s).", len(liveLocks))
+
+		for _, lock := range liveLocks {
+			errorString += fmt.Sprintf("\n  %v", lock)
 		}
 
 		return errors.New(errorString)
@@ -107,8 +219,11 @@ func (b *localBackend) checkForLock(ctx context.Context, stackRef backend.StackR
 }
 
 func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference) error {
-	//
-	err := b.checkForLock(ctx, stackRef)
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return err
+	}
+	err = b.checkForLock(ctx, ref)
 	if err != nil {
 		return err
 	}
@@ -120,38 +235,245 @@ func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference
 	if err != nil {
 		return err
 	}
-	err = b.bucket.WriteAll(ctx, b.lockPath(stackRef.Name()), content, nil)
+	err = b.bucket.WriteAll(ctx, b.lockPath(ref), content, nil)
 	if err != nil {
 		return err
 	}
-	err = b.checkForLock(ctx, stackRef)
+	err = b.checkForLock(ctx, ref)
 	if err != nil {
 		b.Unlock(ctx, stackRef)
 		return err
 	}
+	b.startLease(ref, lockContent)
 	return nil
 }
 
 func (b *localBackend) Unlock(ctx context.Context, stackRef backend.StackReference) {
-	err := b.bucket.Delete(ctx, b.lockPath(stackRef.Name()))
+	ref, err := b.getReference(stackRef)
+	contract.AssertNoErrorf(err, "unlocking a stack that could not have been locked")
+
+	b.stopLease(ref)
+	err = b.bucket.Delete(ctx, b.lockPath(ref))
 	if err != nil {
 		b.d.Errorf(
 			diag.Message("", "there was a problem deleting the lock at %v, manual clean up may be required: %v"),
-			path.Join(b.url, b.lockPath(stackRef.Name())),
+			path.Join(b.url, b.lockPath(ref)),
 			err)
 	}
 }
 
+// startLease starts renewing the lease on a lock held by this backend, until the lock is released.
+func (b *localBackend) startLease(ref *localBackendReference, content *lockContent) {
+	key, lease := b.lockPath(ref), lockLeaseDuration
+	l := &lockLease{done: make(chan struct{}), stopped: make(chan struct{})}
+
+	b.leasesMutex.Lock()
+	if previous, has := b.leases[key]; has {
+		previous.stop()
+	}
+	b.leases[key] = l
+	b.leasesMutex.Unlock()
+
+	go func() {
+		defer close(l.stopped)
+
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-ticker.C:
+				if err := b.keepLease(l, ref, content, lease); err != nil {
+					b.d.Errorf(diag.Message("", "the lock on stack %v could not be renewed: %v"), ref, err)
+					l.lose(err)
+					return
+				}
+			}
+		}
+	}()
+}
+
+// keepLease renews the lease on a lock held by this backend. If the lease cannot be renewed, it is retried with
+// backoff for as long as the current lease lasts. An error is returned if the lock has been removed, or if the lease
+// expires before it can be renewed; either way, the stack may now be locked by another process.
+func (b *localBackend) keepLease(l *lockLease, ref *localBackendReference, content *lockContent,
+	lease time.Duration) error {
+
+	delay, maxDelay := lease/100, lease/10
+	for {
+		err := b.renewLease(ref, content, lease)
+		if err == nil || err == errLockLost {
+			return err
+		}
+		if time.Now().Add(delay).After(content.Expires) {
+			return errors.Wrap(err, "the lock's lease expired before it could be renewed")
+		}
+
+		b.d.Warningf(diag.Message("", "the lock on stack %v could not be renewed, retrying in %v: %v"),
+			ref, delay, err)
+		select {
+		case <-l.done:
+			return nil
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// renewLease extends the lease on the lock held by this backend on the given stack. The lock is not recreated if it
+// has been removed, e.g. by `pulumi cancel`, since another process may now hold a lock on the stack. Buckets don't
+// offer a portable way to write an object only if it still exists, so the lock may be removed between checking that
+// it exists and writing it. To narrow this window, the lock is checked for again once it has been written.
+//
+// Other locks on the stack are ignored if they were taken after ours: Lock writes a lock before checking for others,
+// so these belong to processes that will back off as soon as they see ours. Only a live lock that is older than ours
+// shows that the stack is locked by another process, in which case ours is removed.
+func (b *localBackend) renewLease(ref *localBackendReference, content *lockContent, lease time.Duration) error {
+	ctx, key := context.TODO(), b.lockPath(ref)
+	exists, err := b.bucket.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errLockLost
+	}
+
+	renewed := *content
+	renewed.Expires = time.Now().Add(lease)
+	bytes, err := json.Marshal(renewed)
+	if err != nil {
+		return err
+	}
+	if err = b.bucket.WriteAll(ctx, key, bytes, nil); err != nil {
+		return err
+	}
+
+	if exists, err = b.bucket.Exists(ctx, key); err != nil {
+		return err
+	}
+	if !exists {
+		return errLockLost
+	}
+
+	locks, _, err := b.getLocks(ctx, ref)
+	if err != nil {
+		return err
+	}
+	for _, lock := range locks {
+		if !lock.Stale() && lock.Timestamp.Before(content.Timestamp) {
+			if err := b.bucket.Delete(ctx, key); err != nil && gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound {
+				return errors.Wrapf(err, "removing lock after finding %v", lock)
+			}
+			return errLockLost
+		}
+	}
+
+	content.Expires = renewed.Expires
+	return nil
+}
+
+// leaseError returns an error if this backend holds a lock on the given stack whose lease has been lost.
+func (b *localBackend) leaseError(ref *localBackendReference) error {
+	b.leasesMutex.Lock()
+	l, has := b.leases[b.lockPath(ref)]
+	b.leasesMutex.Unlock()
+	if !has {
+		return nil
+	}
+	if err := l.err(); err != nil {
+		return errors.Wrapf(err, "lost the lock on stack %v", ref)
+	}
+	return nil
+}
+
+// stopLease stops renewing the lease on a lock held by this backend.
+func (b *localBackend) stopLease(ref *localBackendReference) {
+	key := b.lockPath(ref)
+
+	b.leasesMutex.Lock()
+	defer b.leasesMutex.Unlock()
+	if l, has := b.leases[key]; has {
+		l.stop()
+		delete(b.leases, key)
+	}
+}
+
+// lockLease tracks the goroutine that renews the lease on a lock held by this backend.
+type lockLease struct {
+	done    chan struct{} // closed to stop renewing the lease.
+	stopped chan struct{} // closed once the lease will no longer be renewed.
+
+	lostMutex sync.Mutex // lock protecting lost.
+	lost      error      // why the lease was lost, if it was.
+}
+
+// lose records that the lease was lost for the given reason.
+func (l *lockLease) lose(err error) {
+	l.lostMutex.Lock()
+	defer l.lostMutex.Unlock()
+	l.lost = err
+}
+
+// err returns why the lease was lost, or nil if it is still held.
+func (l *lockLease) err() error {
+	l.lostMutex.Lock()
+	defer l.lostMutex.Unlock()
+	return l.lost
+}
+
+// stop stops renewing the lease, waiting for any renewal in progress to finish so that it can't recreate the lock
+// after it has been released.
+func (l *lockLease) stop() {
+	close(l.done)
+	<-l.stopped
+}
+
+// StackLocks returns the locks currently held on the given stack by other processes.
+func (b *localBackend) StackLocks(ctx context.Context, stackRef backend.StackReference) ([]StackLock, error) {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return nil, err
+	}
+	locks, _, err := b.getLocks(ctx, ref)
+	return locks, err
+}
+
+// CancelCurrentUpdate removes every lock held on the given stack, so that it is ready for further updates.
+func (b *localBackend) CancelCurrentUpdate(ctx context.Context, stackRef backend.StackReference) error {
+	ref, err := b.getReference(stackRef)
+	if err != nil {
+		return err
+	}
+	_, keys, err := b.getLocks(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.Errorf("stack %v is not locked", stackRef)
+	}
+
+	for _, key := range keys {
+		if err := b.bucket.Delete(ctx, key); err != nil && gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound {
+			return errors.Wrapf(err, "removing lock %v", key)
+		}
+	}
+	return nil
+}
+
 func lockDir() string {
 	return path.Join(workspace.BookkeepingDir, workspace.LockDir)
 }
 
-func stackLockDir(stack tokens.QName) string {
-	contract.Require(stack != "", "stack")
-	return path.Join(lockDir(), fsutil.QnamePath(stack))
+func stackLockDir(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return path.Join(lockDir(), filepath.ToSlash(ref.relativePath()))
 }
 
-func (b *localBackend) lockPath(stack tokens.QName) string {
-	contract.Require(stack != "", "stack")
-	return path.Join(stackLockDir(stack), b.lockID+".json")
+func (b *localBackend) lockPath(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return path.Join(stackLockDir(ref), b.lockID+".json")
 }
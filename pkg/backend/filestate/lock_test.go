@@ -0,0 +1,237 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gocloud.dev/blob"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+)
+
+func TestLockLease(t *testing.T) {
+	defer func(d time.Duration) { lockLeaseDuration = d }(lockLeaseDuration)
+	lockLeaseDuration = 60 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	holder, other := newTestBackend(t, dir), newTestBackend(t, dir)
+	ref, err := holder.ParseStackReference("proj/dev")
+	assert.NoError(t, err)
+	_, err = holder.CreateStack(ctx, ref, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, holder.Lock(ctx, ref))
+	assert.Error(t, other.Lock(ctx, ref))
+
+	// The holder renews its lease, so the lock does not go stale while it is held.
+	time.Sleep(3 * lockLeaseDuration)
+	locks, err := other.StackLocks(ctx, ref)
+	assert.NoError(t, err)
+	if assert.Len(t, locks, 1) {
+		assert.False(t, locks[0].Stale())
+	}
+	assert.Error(t, other.Lock(ctx, ref))
+
+	holder.Unlock(ctx, ref)
+	assert.NoError(t, other.Lock(ctx, ref))
+	other.Unlock(ctx, ref)
+}
+
+func TestStaleLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	b := newTestBackend(t, dir)
+	ref, err := b.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+
+	// Leave behind a lock whose lease has expired, as a crashed process would.
+	content, err := newLockContent()
+	assert.NoError(t, err)
+	content.Expires = time.Now().Add(-time.Minute)
+	bytes, err := json.Marshal(content)
+	assert.NoError(t, err)
+	staleKey := path.Join(stackLockDir(ref), "crashed.json")
+	assert.NoError(t, b.bucket.WriteAll(ctx, staleKey, bytes, nil))
+
+	locks, err := b.StackLocks(ctx, ref)
+	assert.NoError(t, err)
+	if assert.Len(t, locks, 1) {
+		assert.True(t, locks[0].Stale())
+	}
+
+	assert.NoError(t, b.Lock(ctx, ref))
+	b.Unlock(ctx, ref)
+
+	exists, err := b.bucket.Exists(ctx, staleKey)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCancelCurrentUpdate(t *testing.T) {
+	defer func(d time.Duration) { lockLeaseDuration = d }(lockLeaseDuration)
+	lockLeaseDuration = 60 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	holder, other := newTestBackend(t, dir), newTestBackend(t, dir)
+	ref, err := holder.ParseStackReference("proj/dev")
+	assert.NoError(t, err)
+
+	assert.Error(t, other.CancelCurrentUpdate(ctx, ref))
+
+	assert.NoError(t, holder.Lock(ctx, ref))
+	assert.NoError(t, other.CancelCurrentUpdate(ctx, ref))
+
+	// The holder must not recreate the lock when it next renews its lease.
+	time.Sleep(3 * lockLeaseDuration)
+	locks, err := other.StackLocks(ctx, ref)
+	assert.NoError(t, err)
+	assert.Empty(t, locks)
+
+	// The holder has lost its lock, so it can no longer save the stack.
+	localRef, err := holder.getReference(ref)
+	assert.NoError(t, err)
+	assert.Error(t, holder.leaseError(localRef))
+	assert.Error(t, holder.newSnapshotPersister(localRef, nil).Save(&deploy.Snapshot{}))
+
+	assert.NoError(t, other.Lock(ctx, ref))
+	other.Unlock(ctx, ref)
+	holder.Unlock(ctx, ref)
+}
+
+// failingBucket is a bucket whose writes fail while fail is set.
+type failingBucket struct {
+	Bucket
+
+	fail int32
+}
+
+func (b *failingBucket) WriteAll(ctx context.Context, key string, p []byte, opts *blob.WriterOptions) error {
+	if atomic.LoadInt32(&b.fail) != 0 {
+		return errors.New("write failed")
+	}
+	return b.Bucket.WriteAll(ctx, key, p, opts)
+}
+
+func TestLockLeaseRetry(t *testing.T) {
+	defer func(d time.Duration) { lockLeaseDuration = d }(lockLeaseDuration)
+	lockLeaseDuration = 300 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	holder := newTestBackend(t, dir)
+	bucket := &failingBucket{Bucket: holder.bucket}
+	holder.bucket = bucket
+	ref, err := holder.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+
+	assert.NoError(t, holder.Lock(ctx, ref))
+
+	// The lease survives renewals that fail for less time than it lasts.
+	atomic.StoreInt32(&bucket.fail, 1)
+	time.Sleep(lockLeaseDuration / 2)
+	atomic.StoreInt32(&bucket.fail, 0)
+	time.Sleep(lockLeaseDuration)
+	assert.NoError(t, holder.leaseError(ref))
+
+	// But once the lease expires without being renewed, the lock is lost.
+	atomic.StoreInt32(&bucket.fail, 1)
+	time.Sleep(3 * lockLeaseDuration)
+	assert.Error(t, holder.leaseError(ref))
+
+	atomic.StoreInt32(&bucket.fail, 0)
+	holder.Unlock(ctx, ref)
+}
+
+// Tests that renewing a lease only gives up the lock if it has been removed, or if another process locked the stack
+// first, and not because of processes that are trying to lock the stack in the meantime.
+func TestLockLeaseContended(t *testing.T) {
+	defer func(d time.Duration) { lockLeaseDuration = d }(lockLeaseDuration)
+	lockLeaseDuration = time.Hour
+
+	dir, err := ioutil.TempDir("", "filestate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	holder, other := newTestBackend(t, dir), newTestBackend(t, dir)
+	ref, err := holder.parseStackReference("proj/dev")
+	assert.NoError(t, err)
+
+	writeLock := func(b *localBackend, content *lockContent) {
+		bytes, err := json.Marshal(content)
+		assert.NoError(t, err)
+		assert.NoError(t, b.bucket.WriteAll(ctx, b.lockPath(ref), bytes, nil))
+	}
+	holderExists := func() bool {
+		exists, err := holder.bucket.Exists(ctx, holder.lockPath(ref))
+		assert.NoError(t, err)
+		return exists
+	}
+
+	content, err := newLockContent()
+	assert.NoError(t, err)
+	writeLock(holder, content)
+
+	// Another process that is trying to lock the stack writes its lock before noticing ours, and will then back off.
+	// Its lock is newer than ours, so the lease is renewed regardless.
+	contender, err := newLockContent()
+	assert.NoError(t, err)
+	contender.Timestamp = content.Timestamp.Add(time.Second)
+	writeLock(other, contender)
+	assert.NoError(t, holder.renewLease(ref, content, lockLeaseDuration))
+	assert.True(t, holderExists())
+
+	// An ordinary attempt to lock the stack is refused, and leaves the lock in place.
+	assert.Error(t, other.Lock(ctx, ref))
+	assert.NoError(t, holder.renewLease(ref, content, lockLeaseDuration))
+	assert.True(t, holderExists())
+
+	// A live lock that is older than ours means that another process holds the stack, so ours is given up.
+	older, err := newLockContent()
+	assert.NoError(t, err)
+	older.Timestamp = content.Timestamp.Add(-time.Second)
+	writeLock(other, older)
+	assert.Equal(t, errLockLost, holder.renewLease(ref, content, lockLeaseDuration))
+	assert.False(t, holderExists())
+	assert.NoError(t, other.bucket.Delete(ctx, other.lockPath(ref)))
+
+	// A lock that has been removed, e.g. by `pulumi cancel`, is not recreated.
+	assert.Equal(t, errLockLost, holder.renewLease(ref, content, lockLeaseDuration))
+	assert.False(t, holderExists())
+}
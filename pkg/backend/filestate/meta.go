@@ -0,0 +1,122 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// PulumiFilestateLegacyLayoutEnvVar is an env var that, when truthy, makes newly initialized self-managed backends use
+// the legacy layout, in which stacks are not scoped by project.
+const PulumiFilestateLegacyLayoutEnvVar = "PULUMI_SELF_MANAGED_STATE_LEGACY_LAYOUT"
+
+const (
+	// legacyLayoutVersion is the original layout, in which every stack is stored at .pulumi/stacks/<stack>.json and
+	// stack names must therefore be unique across all projects sharing a backend.
+	legacyLayoutVersion = 0
+	// projectLayoutVersion stores every stack at .pulumi/stacks/<project>/<stack>.json, and likewise scopes history,
+	// backups and locks by project.
+	projectLayoutVersion = 1
+)
+
+// pulumiMeta is the metadata stored at the root of a self-managed backend, describing how its state is laid out.
+type pulumiMeta struct {
+	// Version is the layout version of the backend's state.
+	Version int `json:"version"`
+}
+
+func metaPath() string {
+	return path.Join(workspace.BookkeepingDir, "meta.json")
+}
+
+// readPulumiMeta reads the metadata for the state stored in the given bucket. If the bucket has no metadata yet, it
+// is initialized: buckets that already contain stacks keep using the legacy layout (until they are upgraded), and
+// empty buckets use the project layout unless PULUMI_SELF_MANAGED_STATE_LEGACY_LAYOUT is set.
+func readPulumiMeta(ctx context.Context, bucket Bucket) (*pulumiMeta, error) {
+	bytes, err := bucket.ReadAll(ctx, metaPath())
+	switch {
+	case err == nil:
+		var meta pulumiMeta
+		if err := json.Unmarshal(bytes, &meta); err != nil {
+			return nil, errors.Wrapf(err, "corrupt state metadata in %s", metaPath())
+		}
+		if meta.Version > projectLayoutVersion {
+			return nil, errors.Errorf(
+				"the state layout version %d is newer than this version of the CLI supports; please upgrade the CLI",
+				meta.Version)
+		}
+		return &meta, nil
+	case gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound:
+		return nil, errors.Wrapf(err, "reading state metadata from %s", metaPath())
+	}
+
+	hasLegacyStacks, err := hasLegacyStacks(bucket)
+	if err != nil {
+		return nil, err
+	}
+	if hasLegacyStacks || cmdutil.IsTruthy(os.Getenv(PulumiFilestateLegacyLayoutEnvVar)) {
+		return &pulumiMeta{Version: legacyLayoutVersion}, nil
+	}
+
+	meta := &pulumiMeta{Version: projectLayoutVersion}
+	if err := writePulumiMeta(ctx, bucket, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// writePulumiMeta writes the given metadata to the root of the bucket.
+func writePulumiMeta(ctx context.Context, bucket Bucket, meta *pulumiMeta) error {
+	bytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := bucket.WriteAll(ctx, metaPath(), bytes, nil); err != nil {
+		return errors.Wrapf(err, "writing state metadata to %s", metaPath())
+	}
+	return nil
+}
+
+// hasLegacyStacks returns true if the bucket contains any stacks stored in the legacy layout.
+func hasLegacyStacks(bucket Bucket) (bool, error) {
+	files, err := listBucket(bucket, path.Join(workspace.BookkeepingDir, workspace.StackDir))
+	if err != nil {
+		if gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "error listing stacks")
+	}
+	for _, file := range files {
+		if file.IsDir {
+			continue
+		}
+		if _, has := encoding.Marshalers[filepath.Ext(strings.TrimSuffix(objectName(file), gzipExt))]; has {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -15,15 +15,15 @@
 package filestate
 
 import (
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/secrets"
-	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 )
 
 // localSnapshotManager is a simple SnapshotManager implementation that persists snapshots
 // to disk on the local machine.
 type localSnapshotPersister struct {
-	name    tokens.QName
+	ref     *localBackendReference
 	backend *localBackend
 	sm      secrets.Manager
 }
@@ -33,11 +33,23 @@ func (sp *localSnapshotPersister) SecretsManager() secrets.Manager {
 }
 
 func (sp *localSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
-	_, err := sp.backend.saveStack(sp.name, snapshot, sp.sm)
+	// If the lock on the stack has been lost, another process may now be updating it.
+	if err := sp.backend.leaseError(sp.ref); err != nil {
+		return err
+	}
+	_, err := sp.backend.saveStack(sp.ref, snapshot, sp.sm)
 	return err
 
 }
 
-func (b *localBackend) newSnapshotPersister(stackName tokens.QName, sm secrets.Manager) *localSnapshotPersister {
-	return &localSnapshotPersister{name: stackName, backend: b, sm: sm}
+// newSnapshotPersister returns a persister for the given stack. If journaling is enabled, the persister is also a
+// backend.SnapshotJournaler.
+func (b *localBackend) newSnapshotPersister(ref *localBackendReference,
+	sm secrets.Manager) backend.SnapshotPersister {
+
+	persister := localSnapshotPersister{ref: ref, backend: b, sm: sm}
+	if journalingEnabled() {
+		return &localJournalingSnapshotPersister{localSnapshotPersister: persister}
+	}
+	return &persister
 }
@@ -16,8 +16,11 @@ package filestate
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -93,11 +96,11 @@ func (b *localBackend) newQuery(ctx context.Context,
 	return &localQuery{root: op.Root, proj: op.Proj}, nil
 }
 
-func (b *localBackend) newUpdate(stackName tokens.QName, op backend.UpdateOperation) (*update, error) {
-	contract.Require(stackName != "", "stackName")
+func (b *localBackend) newUpdate(ref *localBackendReference, op backend.UpdateOperation) (*update, error) {
+	contract.Require(ref != nil, "ref")
 
 	// Construct the deployment target.
-	target, err := b.getTarget(stackName, op.StackConfiguration.Config, op.StackConfiguration.Decrypter)
+	target, err := b.getTarget(ref, op.StackConfiguration.Config, op.StackConfiguration.Decrypter)
 	if err != nil {
 		return nil, err
 	}
@@ -111,27 +114,28 @@ func (b *localBackend) newUpdate(stackName tokens.QName, op backend.UpdateOperat
 	}, nil
 }
 
-func (b *localBackend) getTarget(stackName tokens.QName, cfg config.Map, dec config.Decrypter) (*deploy.Target, error) {
-	snapshot, _, err := b.getStack(stackName)
+func (b *localBackend) getTarget(ref *localBackendReference,
+	cfg config.Map, dec config.Decrypter) (*deploy.Target, error) {
+	snapshot, _, err := b.getStack(ref)
 	if err != nil {
 		return nil, err
 	}
 	return &deploy.Target{
-		Name:      stackName,
+		Name:      ref.Name(),
 		Config:    cfg,
 		Decrypter: dec,
 		Snapshot:  snapshot,
 	}, nil
 }
 
-func (b *localBackend) getStack(name tokens.QName) (*deploy.Snapshot, string, error) {
-	if name == "" {
+func (b *localBackend) getStack(ref *localBackendReference) (*deploy.Snapshot, string, error) {
+	if ref.Name() == "" {
 		return nil, "", errors.New("invalid empty stack name")
 	}
 
-	file := b.stackPath(name)
+	file := b.stackPath(ref)
 
-	chk, err := b.getCheckpoint(name)
+	chk, err := b.getCheckpoint(ref)
 	if err != nil {
 		return nil, file, errors.Wrap(err, "failed to load checkpoint")
 	}
@@ -154,19 +158,30 @@ func (b *localBackend) getStack(name tokens.QName) (*deploy.Snapshot, string, er
 }
 
 // GetCheckpoint loads a checkpoint file for the given stack in this project, from the current project workspace.
-func (b *localBackend) getCheckpoint(stackName tokens.QName) (*apitype.CheckpointV3, error) {
-	chkpath := b.stackPath(stackName)
+func (b *localBackend) getCheckpoint(ref *localBackendReference) (*apitype.CheckpointV3, error) {
+	chkpath := b.stackPath(ref)
 	bytes, err := b.bucket.ReadAll(context.TODO(), chkpath)
 	if err != nil {
 		return nil, err
 	}
+	if bytes, err = decodeFile(chkpath, bytes); err != nil {
+		return nil, err
+	}
 
-	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.replayJournal(ref, chk); err != nil {
+		return nil, err
+	}
+	return chk, nil
 }
 
-func (b *localBackend) saveStack(name tokens.QName, snap *deploy.Snapshot, sm secrets.Manager) (string, error) {
+func (b *localBackend) saveStack(ref *localBackendReference,
+	snap *deploy.Snapshot, sm secrets.Manager) (string, error) {
 	// Make a serializable stack and then use the encoder to encode it.
-	file := b.stackPath(name)
+	file := b.plainStackPath(ref)
 	m, ext := encoding.Detect(file)
 	if m == nil {
 		return "", errors.Errorf("resource serialization failed; illegal markup extension: '%v'", ext)
@@ -174,7 +189,7 @@ func (b *localBackend) saveStack(name tokens.QName, snap *deploy.Snapshot, sm se
 	if filepath.Ext(file) == "" {
 		file = file + ext
 	}
-	chk, err := stack.SerializeCheckpoint(name, snap, sm, false /* showSecrets */)
+	chk, err := stack.SerializeCheckpoint(ref.Name(), snap, sm, false /* showSecrets */)
 	if err != nil {
 		return "", errors.Wrap(err, "serializaing checkpoint")
 	}
@@ -183,8 +198,27 @@ func (b *localBackend) saveStack(name tokens.QName, snap *deploy.Snapshot, sm se
 		return "", errors.Wrap(err, "An IO error occurred while marshalling the checkpoint")
 	}
 
+	// Compress and encrypt the checkpoint if requested. The checkpoint may previously have been saved with or
+	// without compression, in which case that file is moved out of the way once the new one has been written. A
+	// stack that has never been deployed has no state to encrypt, nor yet a secrets manager to encrypt it with.
+	otherFile := file + gzipExt
+	if snap == nil {
+		file, byts, err = maybeCompress(file, byts)
+	} else {
+		if sm == nil {
+			sm = snap.SecretsManager
+		}
+		file, byts, err = encodeFile(sm, file, byts)
+	}
+	if err != nil {
+		return "", err
+	}
+	if file == otherFile {
+		otherFile = strings.TrimSuffix(file, gzipExt)
+	}
+
 	// Back up the existing file if it already exists.
-	bck := backupTarget(b.bucket, file)
+	bck := b.backupCheckpoint(file, sm)
 
 	// And now write out the new snapshot file, overwriting that location.
 	if err = b.bucket.WriteAll(context.TODO(), file, byts, nil); err != nil {
@@ -220,7 +254,11 @@ func (b *localBackend) saveStack(name tokens.QName, snap *deploy.Snapshot, sm se
 		}
 	}
 
-	logging.V(7).Infof("Saved stack %s checkpoint to: %s (backup=%s)", name, file, bck)
+	if hasOther, err := b.bucket.Exists(context.TODO(), otherFile); err == nil && hasOther {
+		b.backupCheckpoint(otherFile, sm)
+	}
+
+	logging.V(7).Infof("Saved stack %s checkpoint to: %s (backup=%s)", ref, file, bck)
 
 	// And if we are retaining historical checkpoint information, write it out again
 	if cmdutil.IsTruthy(os.Getenv("PULUMI_RETAIN_CHECKPOINTS")) {
@@ -244,15 +282,37 @@ func (b *localBackend) saveStack(name tokens.QName, snap *deploy.Snapshot, sm se
 }
 
 // removeStack removes information about a stack from the current workspace.
-func (b *localBackend) removeStack(name tokens.QName) error {
-	contract.Require(name != "", "name")
+func (b *localBackend) removeStack(ref *localBackendReference) error {
+	contract.Require(ref.Name() != "", "name")
 
 	// Just make a backup of the file and don't write out anything new.
-	file := b.stackPath(name)
-	backupTarget(b.bucket, file)
+	var sm secrets.Manager
+	if chk, err := b.getCheckpoint(ref); err == nil {
+		sm, _ = encryptionSecretsManager(chk)
+	}
+	file := b.stackPath(ref)
+	b.backupCheckpoint(file, sm)
+
+	// Tags are only meaningful for an existing stack, so there is no need to back them up.
+	if err := b.bucket.Delete(context.TODO(), b.tagsPath(ref)); err != nil &&
+		gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound {
+		return errors.Wrapf(err, "removing tags for stack %s", ref)
+	}
+
+	if err := b.removeJournal(ref); err != nil {
+		return err
+	}
+
+	historyDir := b.historyDirectory(ref)
+	if err := removeAllByPrefix(b.bucket, historyDir); err != nil {
+		return err
+	}
 
-	historyDir := b.historyDirectory(name)
-	return removeAllByPrefix(b.bucket, historyDir)
+	// The stack's history may have been the last to reference some objects.
+	if err := b.collectObjects(); err != nil {
+		return errors.Wrapf(err, "removing unreferenced history objects for stack %s", ref)
+	}
+	return nil
 }
 
 // backupTarget makes a backup of an existing file, in preparation for writing a new one.  Instead of a copy, it
@@ -266,9 +326,38 @@ func backupTarget(bucket Bucket, file string) string {
 	return bck
 }
 
+// backupCheckpoint makes a backup of an existing checkpoint file, in preparation for writing a new one. If encryption is
+// enabled, a checkpoint that was written before it was enabled is encrypted with the given secrets manager as it is
+// backed up, so that the backup doesn't leave the stack's state behind in plaintext.
+func (b *localBackend) backupCheckpoint(file string, sm secrets.Manager) string {
+	if !encryptionEnabled() || sm == nil {
+		return backupTarget(b.bucket, file)
+	}
+
+	ctx := context.TODO()
+	data, err := b.bucket.ReadAll(ctx, file)
+	if err != nil || isEncrypted(data) {
+		return backupTarget(b.bucket, file)
+	}
+
+	bck := file + ".bak"
+	encrypted, err := encrypt(sm, data)
+	if err == nil {
+		err = b.bucket.WriteAll(ctx, bck, encrypted, nil)
+	}
+	if err != nil {
+		logging.V(5).Infof("error encrypting backup of %s: %v; keeping it unencrypted", file, err)
+		return backupTarget(b.bucket, file)
+	}
+	if err = b.bucket.Delete(ctx, file); err != nil {
+		logging.V(5).Infof("error deleting %s after backing it up: %v", file, err)
+	}
+	return bck
+}
+
 // backupStack copies the current Checkpoint file to ~/.pulumi/backups.
-func (b *localBackend) backupStack(name tokens.QName) error {
-	contract.Require(name != "", "name")
+func (b *localBackend) backupStack(ref *localBackendReference) error {
+	contract.Require(ref.Name() != "", "name")
 
 	// Exit early if backups are disabled.
 	if cmdutil.IsTruthy(os.Getenv(DisableCheckpointBackupsEnvVar)) {
@@ -276,48 +365,85 @@ func (b *localBackend) backupStack(name tokens.QName) error {
 	}
 
 	// Read the current checkpoint file. (Assuming it aleady exists.)
-	stackPath := b.stackPath(name)
+	stackPath := b.stackPath(ref)
 	byts, err := b.bucket.ReadAll(context.TODO(), stackPath)
 	if err != nil {
 		return err
 	}
 
 	// Get the backup directory.
-	backupDir := b.backupDirectory(name)
+	backupDir := b.backupDirectory(ref)
 
 	// Write out the new backup checkpoint file.
 	stackFile := filepath.Base(stackPath)
+	compressed := strings.HasSuffix(stackFile, gzipExt)
+	stackFile = strings.TrimSuffix(stackFile, gzipExt)
 	ext := filepath.Ext(stackFile)
 	base := strings.TrimSuffix(stackFile, ext)
 	backupFile := fmt.Sprintf("%s.%v%s", base, time.Now().UnixNano(), ext)
+	if compressed {
+		backupFile += gzipExt
+	}
 	return b.bucket.WriteAll(context.TODO(), filepath.Join(backupDir, backupFile), byts, nil)
 }
 
-func (b *localBackend) stackPath(stack tokens.QName) string {
-	path := filepath.Join(b.StateDir(), workspace.StackDir)
-	if stack != "" {
-		path = filepath.Join(path, fsutil.QnamePath(stack)+".json")
-	}
+// stacksDirectory returns the directory in which stacks are stored.
+func (b *localBackend) stacksDirectory() string {
+	return filepath.Join(b.StateDir(), workspace.StackDir)
+}
 
+// stackPath returns the path to the given stack's checkpoint file. If the checkpoint has been saved compressed, the
+// path to the compressed file is returned.
+func (b *localBackend) stackPath(ref *localBackendReference) string {
+	path := b.plainStackPath(ref)
+	if compressed, err := b.bucket.Exists(context.TODO(), path+gzipExt); err == nil && compressed {
+		return path + gzipExt
+	}
 	return path
 }
 
-func (b *localBackend) historyDirectory(stack tokens.QName) string {
-	contract.Require(stack != "", "stack")
-	return filepath.Join(b.StateDir(), workspace.HistoryDir, fsutil.QnamePath(stack))
+// hasCheckpoint returns true if the given stack's checkpoint file exists.
+func (b *localBackend) hasCheckpoint(ref *localBackendReference) bool {
+	exists, err := b.bucket.Exists(context.TODO(), b.stackPath(ref))
+	return err == nil && exists
+}
+
+// plainStackPath returns the path to the given stack's uncompressed checkpoint file, whether or not it exists.
+func (b *localBackend) plainStackPath(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return filepath.Join(b.stacksDirectory(), ref.relativePath()+".json")
+}
+
+func (b *localBackend) historyDirectory(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return filepath.Join(b.StateDir(), workspace.HistoryDir, ref.relativePath())
 }
 
-func (b *localBackend) backupDirectory(stack tokens.QName) string {
-	contract.Require(stack != "", "stack")
-	return filepath.Join(b.StateDir(), workspace.BackupDir, fsutil.QnamePath(stack))
+func (b *localBackend) backupDirectory(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return filepath.Join(b.StateDir(), workspace.BackupDir, ref.relativePath())
+}
+
+func (b *localBackend) tagsPath(ref *localBackendReference) string {
+	contract.Require(ref.Name() != "", "stack")
+	return filepath.Join(b.StateDir(), workspace.TagDir, ref.relativePath()+".json")
+}
+
+// relativePath returns the path at which a stack's files are stored, relative to the directories for each kind of
+// file. Stacks are grouped into a directory per project unless the backend uses the legacy layout.
+func (r *localBackendReference) relativePath() string {
+	if r.project == "" {
+		return fsutil.QnamePath(r.name)
+	}
+	return filepath.Join(fsutil.QnamePath(tokens.QName(r.project)), fsutil.QnamePath(r.name))
 }
 
 // getHistory returns locally stored update history. The first element of the result will be
 // the most recent update record.
-func (b *localBackend) getHistory(name tokens.QName, pageSize int, page int) ([]backend.UpdateInfo, error) {
-	contract.Require(name != "", "name")
+func (b *localBackend) getHistory(ref *localBackendReference, pageSize int, page int) ([]backend.UpdateInfo, error) {
+	contract.Require(ref.Name() != "", "name")
 
-	dir := b.historyDirectory(name)
+	dir := b.historyDirectory(ref)
 	// TODO: we could consider optimizing the list operation using `page` and `pageSize`.
 	// Unfortunately, this is mildly invasive given the gocloud List API.
 	allFiles, err := listBucket(b.bucket, dir)
@@ -339,7 +465,7 @@ func (b *localBackend) getHistory(name tokens.QName, pageSize int, page int) ([]
 		filepath := file.Key
 
 		// ignore checkpoints
-		if !strings.HasSuffix(filepath, ".history.json") {
+		if !isHistoryFile(filepath) {
 			continue
 		}
 
@@ -370,23 +496,301 @@ func (b *localBackend) getHistory(name tokens.QName, pageSize int, page int) ([]
 		if err != nil {
 			return nil, errors.Wrapf(err, "reading history file %s", filepath)
 		}
+		if b, err = decodeFile(filepath, b); err != nil {
+			return nil, err
+		}
 		err = json.Unmarshal(b, &update)
 		if err != nil {
 			return nil, errors.Wrapf(err, "reading history file %s", filepath)
 		}
 
+		// Updates are numbered from 1, in the order in which they happened.
+		update.Version = len(historyEntries) - i
+
 		updates = append(updates, update)
 	}
 
 	return updates, nil
 }
 
-func (b *localBackend) renameHistory(oldName tokens.QName, newName tokens.QName) error {
-	contract.Require(oldName != "", "oldName")
-	contract.Require(newName != "", "newName")
+// getHistoryCheckpoint returns the checkpoint that was saved at the end of the given version of a stack's history.
+func (b *localBackend) getHistoryCheckpoint(ref *localBackendReference, version int) (*apitype.CheckpointV3, error) {
+	contract.Require(ref.Name() != "", "name")
+
+	allFiles, err := listBucket(b.bucket, b.historyDirectory(ref))
+	if err != nil && gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound {
+		return nil, err
+	}
+
+	// listBucket returns the files sorted by name, so older updates come before newer ones.
+	var historyEntries []string
+	keys := make(map[string]bool)
+	for _, file := range allFiles {
+		keys[file.Key] = true
+		if isHistoryFile(file.Key) {
+			historyEntries = append(historyEntries, file.Key)
+		}
+	}
+	if version < 1 || version > len(historyEntries) {
+		return nil, errors.Errorf("version %d of stack %s does not exist", version, ref)
+	}
+
+	// Each update's checkpoint is saved alongside its history file, either as a manifest of content-addressed objects
+	// or as a copy of the whole checkpoint.
+	prefix := strings.TrimSuffix(strings.TrimSuffix(historyEntries[version-1], gzipExt), ".history.json")
+	for _, manifest := range []string{prefix + ".checkpoint.manifest.json", prefix + ".checkpoint.manifest.json.gz"} {
+		if keys[manifest] {
+			return b.readHistoryCheckpointManifest(manifest)
+		}
+	}
+
+	chkpath := prefix + ".checkpoint.json"
+	if keys[chkpath+gzipExt] {
+		chkpath += gzipExt
+	}
+	bytes, err := b.bucket.ReadAll(context.TODO(), chkpath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading checkpoint for version %d of stack %s", version, ref)
+	}
+	if bytes, err = decodeFile(chkpath, bytes); err != nil {
+		return nil, err
+	}
+	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(bytes)
+}
+
+// isHistoryFile returns true if the given key refers to an update's history file, as opposed to its checkpoint.
+func isHistoryFile(key string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(key, gzipExt), ".history.json")
+}
+
+// isHistoryManifest returns true if the given key refers to the manifest of an update's checkpoint.
+func isHistoryManifest(key string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(key, gzipExt), ".checkpoint.manifest.json")
+}
+
+// historyCheckpointManifest is how the checkpoint at the end of an update is saved in a stack's history when compression
+// is enabled. Rather than copying the whole checkpoint, which would make the size of the history grow with the number of
+// resources times the number of updates, each resource's state is saved as a content-addressed object that is shared
+// by every update in which the resource's state is the same. Older versions of the CLI cannot read manifests, so they
+// are only written under the same opt-in as compressed files, which those versions cannot read either.
+type historyCheckpointManifest struct {
+	// Checkpoint is the versioned checkpoint, without its resources.
+	Checkpoint json.RawMessage `json:"checkpoint"`
+	// Resources holds the name of the object that stores each of the checkpoint's resources, in order.
+	Resources []string `json:"resources"`
+}
+
+// objectsDirectory returns the directory in which content-addressed objects are stored. Objects may be shared by the
+// history of any number of stacks, so they are not removed along with a stack's history; instead, collectObjects
+// removes the objects that are no longer referenced by any history.
+func (b *localBackend) objectsDirectory() string {
+	return filepath.Join(b.StateDir(), workspace.ObjectDir)
+}
+
+// objectGracePeriod is how long an unreferenced object is kept before it may be collected. An update that needs an
+// object that already exists rewrites it if it is older than half this period, so that it cannot be collected in the
+// time between the update finding the object and saving the manifest that references it.
+const objectGracePeriod = time.Hour
+
+// saveHistoryCheckpoint saves the given checkpoint as a manifest at the given path, writing an object for each
+// resource state that hasn't been saved before. If the manifest is encrypted with the given secrets manager, the
+// resource states are instead kept in the manifest itself: objects are shared between stacks that need not share a
+// secrets manager, and their names would reveal the hashes of the states that they hold.
+func (b *localBackend) saveHistoryCheckpoint(chk *apitype.CheckpointV3, manifestPath string,
+	sm secrets.Manager) error {
+
+	var manifest historyCheckpointManifest
+	if chk.Latest != nil && sm == nil {
+		for _, res := range chk.Latest.Resources {
+			name, err := b.saveObject(res)
+			if err != nil {
+				return err
+			}
+			manifest.Resources = append(manifest.Resources, name)
+		}
+
+		latest := *chk.Latest
+		latest.Resources = nil
+		chk.Latest = &latest
+	}
+
+	data, err := json.Marshal(chk)
+	if err != nil {
+		return err
+	}
+	if manifest.Checkpoint, err = json.Marshal(apitype.VersionedCheckpoint{
+		Version:    apitype.DeploymentSchemaVersionCurrent,
+		Checkpoint: data,
+	}); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	manifestPath, bytes, err = encodeFile(sm, manifestPath, bytes)
+	if err != nil {
+		return err
+	}
+	return b.bucket.WriteAll(context.TODO(), manifestPath, bytes, nil)
+}
+
+// saveObject saves the given resource state as a content-addressed object, unless an identical object already exists,
+// and returns the object's name.
+func (b *localBackend) saveObject(res apitype.ResourceV3) (string, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	name, data, err := maybeCompress(hex.EncodeToString(sum[:])+".json", data)
+	if err != nil {
+		return "", err
+	}
+
+	key := filepath.Join(b.objectsDirectory(), name)
+	modTime, exists, err := b.objectModTime(key)
+	if err != nil {
+		return "", err
+	}
+	if !exists || time.Since(modTime) > objectGracePeriod/2 {
+		if err = b.bucket.WriteAll(context.TODO(), key, data, nil); err != nil {
+			return "", errors.Wrapf(err, "saving object %s", name)
+		}
+	}
+	return name, nil
+}
+
+// objectModTime returns the time at which the file at the given key was last written, and false if it doesn't exist.
+func (b *localBackend) objectModTime(key string) (time.Time, bool, error) {
+	iter := b.bucket.List(&blob.ListOptions{Prefix: key})
+	for {
+		file, err := iter.Next(context.TODO())
+		if err == io.EOF {
+			return time.Time{}, false, nil
+		}
+		if err != nil {
+			return time.Time{}, false, errors.Wrapf(err, "checking for %s", key)
+		}
+		// The prefix also matches other files whose names start with the key, such as compressed variants.
+		if file.Key == filepath.ToSlash(key) {
+			return file.ModTime, true, nil
+		}
+	}
+}
+
+// collectObjects removes the content-addressed objects that are no longer referenced by the history of any stack, once
+// they are older than objectGracePeriod.
+func (b *localBackend) collectObjects() error {
+	objects, err := listBucket(b.bucket, b.objectsDirectory())
+	if err != nil {
+		if gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound {
+			return nil
+		}
+		return err
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+
+	// Mark every object that is referenced by a manifest...
+	referenced := make(map[string]bool)
+	iter := b.bucket.List(&blob.ListOptions{Prefix: filepath.Join(b.StateDir(), workspace.HistoryDir) + "/"})
+	for {
+		file, err := iter.Next(context.TODO())
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not list bucket")
+		}
+		if file.IsDir || !isHistoryManifest(file.Key) {
+			continue
+		}
 
-	oldHistory := b.historyDirectory(oldName)
-	newHistory := b.historyDirectory(newName)
+		bytes, err := b.bucket.ReadAll(context.TODO(), file.Key)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", file.Key)
+		}
+		if bytes, err = decodeFile(file.Key, bytes); err != nil {
+			// Encrypted manifests keep their resource states in the manifest itself, so they reference no objects.
+			if isDecryptionError(err) {
+				continue
+			}
+			return err
+		}
+		var manifest historyCheckpointManifest
+		if err = json.Unmarshal(bytes, &manifest); err != nil {
+			return errors.Wrapf(err, "reading %s", file.Key)
+		}
+		for _, name := range manifest.Resources {
+			referenced[name] = true
+		}
+	}
+
+	// ...and sweep the rest.
+	cutoff := time.Now().Add(-objectGracePeriod)
+	for _, obj := range objects {
+		if obj.IsDir || referenced[objectName(obj)] || obj.ModTime.After(cutoff) {
+			continue
+		}
+		if err := b.bucket.Delete(context.TODO(), obj.Key); err != nil &&
+			gcerrors.Code(errors.Cause(err)) != gcerrors.NotFound {
+			return errors.Wrapf(err, "removing object %s", objectName(obj))
+		}
+	}
+	return nil
+}
+
+// readHistoryCheckpointManifest reads the checkpoint saved as a manifest at the given path.
+func (b *localBackend) readHistoryCheckpointManifest(manifestPath string) (*apitype.CheckpointV3, error) {
+	bytes, err := b.bucket.ReadAll(context.TODO(), manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	if bytes, err = decodeFile(manifestPath, bytes); err != nil {
+		return nil, err
+	}
+	var manifest historyCheckpointManifest
+	if err = json.Unmarshal(bytes, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "reading %s", manifestPath)
+	}
+
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(manifest.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Resources) == 0 {
+		return chk, nil
+	}
+	if chk.Latest == nil {
+		chk.Latest = &apitype.DeploymentV3{}
+	}
+	for _, name := range manifest.Resources {
+		key := filepath.Join(b.objectsDirectory(), name)
+		data, err := b.bucket.ReadAll(context.TODO(), key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading object %s", name)
+		}
+		if data, err = maybeDecompress(data); err != nil {
+			return nil, errors.Wrapf(err, "decompressing object %s", name)
+		}
+		var res apitype.ResourceV3
+		if err = json.Unmarshal(data, &res); err != nil {
+			return nil, errors.Wrapf(err, "reading object %s", name)
+		}
+		chk.Latest.Resources = append(chk.Latest.Resources, res)
+	}
+	return chk, nil
+}
+
+func (b *localBackend) renameHistory(oldRef, newRef *localBackendReference) error {
+	contract.Require(oldRef.Name() != "", "oldName")
+	contract.Require(newRef.Name() != "", "newName")
+
+	oldHistory := b.historyDirectory(oldRef)
+	newHistory := b.historyDirectory(newRef)
 
 	allFiles, err := listBucket(b.bucket, oldHistory)
 	if err != nil {
@@ -403,7 +807,7 @@ func (b *localBackend) renameHistory(oldName tokens.QName, newName tokens.QName)
 
 		// The filename format is <stack-name>-<timestamp>.[checkpoint|history].json, we need to change
 		// the stack name part but retain the other parts.
-		newFileName := string(newName) + fileName[strings.LastIndex(fileName, "-"):]
+		newFileName := string(newRef.Name()) + fileName[strings.LastIndex(fileName, "-"):]
 		newBlob := path.Join(newHistory, newFileName)
 
 		if err := b.bucket.Copy(context.TODO(), newBlob, oldBlob, nil); err != nil {
@@ -418,13 +822,23 @@ func (b *localBackend) renameHistory(oldName tokens.QName, newName tokens.QName)
 }
 
 // addToHistory saves the UpdateInfo and makes a copy of the current Checkpoint file.
-func (b *localBackend) addToHistory(name tokens.QName, update backend.UpdateInfo) error {
-	contract.Require(name != "", "name")
+func (b *localBackend) addToHistory(ref *localBackendReference, update backend.UpdateInfo) error {
+	contract.Require(ref.Name() != "", "name")
 
-	dir := b.historyDirectory(name)
+	dir := b.historyDirectory(ref)
 
 	// Prefix for the update and checkpoint files.
-	pathPrefix := path.Join(dir, fmt.Sprintf("%s-%d", name, time.Now().UnixNano()))
+	pathPrefix := path.Join(dir, fmt.Sprintf("%s-%d", ref.Name(), time.Now().UnixNano()))
+
+	// Read the checkpoint (assuming it already exists), whose secrets manager encrypts the history if requested.
+	chk, err := b.getCheckpoint(ref)
+	if err != nil {
+		return err
+	}
+	sm, err := encryptionSecretsManager(chk)
+	if err != nil {
+		return errors.Wrapf(err, "creating secrets manager for stack %s", ref)
+	}
 
 	// Save the history file.
 	byts, err := json.MarshalIndent(&update, "", "    ")
@@ -432,12 +846,60 @@ func (b *localBackend) addToHistory(name tokens.QName, update backend.UpdateInfo
 		return err
 	}
 
-	historyFile := fmt.Sprintf("%s.history.json", pathPrefix)
+	historyFile, byts, err := encodeFile(sm, fmt.Sprintf("%s.history.json", pathPrefix), byts)
+	if err != nil {
+		return err
+	}
 	if err = b.bucket.WriteAll(context.TODO(), historyFile, byts, nil); err != nil {
 		return err
 	}
 
-	// Make a copy of the checkpoint file. (Assuming it already exists.)
-	checkpointFile := fmt.Sprintf("%s.checkpoint.json", pathPrefix)
-	return b.bucket.Copy(context.TODO(), checkpointFile, b.stackPath(name), nil)
+	// Save the checkpoint. Unless compression is enabled, this is a copy of the stack's checkpoint file, which older
+	// versions of the CLI can read.
+	if !gzipEnabled() {
+		file, checkpointFile := b.stackPath(ref), fmt.Sprintf("%s.checkpoint.json", pathPrefix)
+		if strings.HasSuffix(file, gzipExt) {
+			checkpointFile += gzipExt
+		}
+		return b.bucket.Copy(context.TODO(), checkpointFile, file, nil)
+	}
+	return b.saveHistoryCheckpoint(chk, fmt.Sprintf("%s.checkpoint.manifest.json", pathPrefix), sm)
+}
+
+// getStackTags returns the tags persisted for the given stack, or nil if it has none.
+func (b *localBackend) getStackTags(ref *localBackendReference) (map[apitype.StackTagName]string, error) {
+	bytes, err := b.bucket.ReadAll(context.TODO(), b.tagsPath(ref))
+	if err != nil {
+		if gcerrors.Code(errors.Cause(err)) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "reading tags for stack %s", ref)
+	}
+
+	var tags map[apitype.StackTagName]string
+	if err := json.Unmarshal(bytes, &tags); err != nil {
+		return nil, errors.Wrapf(err, "corrupt tags for stack %s", ref)
+	}
+	return tags, nil
+}
+
+// saveStackTags replaces the tags persisted for the given stack.
+func (b *localBackend) saveStackTags(ref *localBackendReference, tags map[apitype.StackTagName]string) error {
+	bytes, err := json.MarshalIndent(tags, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := b.bucket.WriteAll(context.TODO(), b.tagsPath(ref), bytes, nil); err != nil {
+		return errors.Wrapf(err, "saving tags for stack %s", ref)
+	}
+	return nil
+}
+
+// renameStackTags moves the tags persisted for a stack, if any, to the location for its new name.
+func (b *localBackend) renameStackTags(oldRef, newRef *localBackendReference) error {
+	hasTags, err := b.bucket.Exists(context.TODO(), b.tagsPath(oldRef))
+	if err != nil || !hasTags {
+		return err
+	}
+	return renameObject(b.bucket, b.tagsPath(oldRef), b.tagsPath(newRef))
 }
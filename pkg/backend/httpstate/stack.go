@@ -69,6 +69,10 @@ func (c cloudBackendReference) Name() tokens.QName {
 	return c.name
 }
 
+func (c cloudBackendReference) Project() (tokens.PackageName, bool) {
+	return tokens.PackageName(c.project), c.project != ""
+}
+
 // cloudStack is a cloud stack descriptor.
 type cloudStack struct {
 	// ref is the stack's unique name.
@@ -140,7 +144,9 @@ func (s *cloudStack) Rename(ctx context.Context, newName tokens.QName) (backend.
 	return backend.RenameStack(ctx, s, newName)
 }
 
-func (s *cloudStack) Preview(ctx context.Context, op backend.UpdateOperation) (engine.ResourceChanges, result.Result) {
+func (s *cloudStack) Preview(ctx context.Context,
+	op backend.UpdateOperation) (*deploy.Plan, engine.ResourceChanges, result.Result) {
+
 	return backend.PreviewStack(ctx, s, op)
 }
 
@@ -0,0 +1,193 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// DefaultJournalCompactionInterval is the number of journal entries after which a journaling SnapshotManager saves
+// the whole snapshot, discarding the journal.
+const DefaultJournalCompactionInterval = 1000
+
+// SnapshotJournaler is implemented by snapshot persisters that support journaling. Rather than saving the whole
+// snapshot after every step, a SnapshotManager that uses a journaler appends a record of the changes made by each
+// step to a journal, and only saves the whole snapshot periodically. Saving the snapshot must discard the journal.
+//
+// Readers of the persisted state recover the changes that were made since the snapshot was last saved by replaying
+// the journal on top of it (see stack.ReplayJournal).
+type SnapshotJournaler interface {
+	SnapshotPersister
+
+	// Append records the given batch of changes in the journal. Returns an error if the persistence failed.
+	Append(journal Journal) error
+}
+
+// Journal is a batch of changes made to a snapshot since it was last saved.
+type Journal struct {
+	Base         time.Time      // the time recorded in the manifest of the snapshot to which the entries apply.
+	NewResources int            // the number of resources at the start of that snapshot that were produced by the plan.
+	Entries      []JournalEntry // the changes that were made, in order.
+}
+
+// JournalEntry is a single change to a snapshot. See apitype.JournalEntryV1 for the meaning of each field.
+type JournalEntry struct {
+	Kind      apitype.JournalEntryKind
+	Slot      int
+	Operation int
+	State     *resource.State
+	Type      resource.OperationType
+}
+
+// SerializeJournal serializes the given journal, using the given encrypter to encrypt any secrets in its resources.
+func SerializeJournal(journal Journal, enc config.Encrypter) (apitype.JournalV1, error) {
+	result := apitype.JournalV1{
+		Base:         journal.Base,
+		NewResources: journal.NewResources,
+		Entries:      make([]apitype.JournalEntryV1, 0, len(journal.Entries)),
+	}
+	for _, entry := range journal.Entries {
+		sentry := apitype.JournalEntryV1{
+			Kind:      entry.Kind,
+			Slot:      entry.Slot,
+			Operation: entry.Operation,
+			Type:      apitype.OperationType(entry.Type),
+		}
+		if entry.State != nil {
+			state, err := stack.SerializeResource(entry.State, enc, false /* showSecrets */)
+			if err != nil {
+				return apitype.JournalV1{}, err
+			}
+			sentry.State = &state
+		}
+		result.Entries = append(result.Entries, sentry)
+	}
+	return result, nil
+}
+
+// snapshotJournal tracks the changes that a SnapshotManager has made since it last saved the whole snapshot.
+type snapshotJournal struct {
+	base          time.Time                 // the manifest time of the last saved snapshot
+	newResources  int                       // the number of resources in the last saved snapshot produced by the plan
+	baseResources int                       // the number of resources in the last saved snapshot
+	slots         map[*resource.State]int   // the slot of each resource state known to the journal
+	nextSlot      int                       // the slot of the next new resource state
+	operations    map[*resource.State][]int // the indices of the outstanding operations on each resource state
+	nextOperation int                       // the index of the next pending operation
+	entries       []JournalEntry            // changes that have not yet been appended to the journal
+	appended      int                       // the number of entries appended since the snapshot was last saved
+	compact       bool                      // true if the next write must save the whole snapshot
+}
+
+func newSnapshotJournal() *snapshotJournal {
+	// Nothing has been saved yet, so the first write must save the whole snapshot.
+	return &snapshotJournal{
+		slots:      make(map[*resource.State]int),
+		operations: make(map[*resource.State][]int),
+		compact:    true,
+	}
+}
+
+// reset starts a new journal on top of the given snapshot, which has just been saved.
+func (j *snapshotJournal) reset(snap *deploy.Snapshot, newResources int) {
+	j.base = snap.Manifest.Time
+	j.newResources = newResources
+	j.baseResources = len(snap.Resources)
+	j.slots = make(map[*resource.State]int)
+	for i, res := range snap.Resources {
+		j.slots[res] = i
+	}
+	j.nextSlot = len(snap.Resources)
+	j.operations = make(map[*resource.State][]int)
+	for i, op := range snap.PendingOperations {
+		j.operations[op.Resource] = append(j.operations[op.Resource], i)
+	}
+	j.nextOperation = len(snap.PendingOperations)
+	j.entries = nil
+	j.appended = 0
+	j.compact = false
+}
+
+// recordNew records that the given state was produced by the plan.
+func (j *snapshotJournal) recordNew(state *resource.State) {
+	// Resources with aliases cause references to their old URNs to be rewritten throughout the snapshot when it is
+	// saved (see Snapshot.NormalizeURNReferences), which the journal cannot express.
+	if len(state.Aliases) > 0 {
+		logging.V(9).Infof("SnapshotJournal: compacting because %v has aliases", state.URN)
+		j.compact = true
+	}
+
+	j.slots[state] = j.nextSlot
+	j.nextSlot++
+	j.entries = append(j.entries, JournalEntry{Kind: apitype.JournalEntryNew, State: state})
+}
+
+// recordDone records that the given state from the base snapshot was removed. States produced by the plan are never
+// removed from the snapshot (see SnapshotManager.snap), so they are ignored, as are states unknown to the journal.
+func (j *snapshotJournal) recordDone(state *resource.State) {
+	if slot, has := j.slots[state]; has && slot >= j.newResources && slot < j.baseResources {
+		j.entries = append(j.entries, JournalEntry{Kind: apitype.JournalEntryDone, Slot: slot})
+	}
+}
+
+// recordUpdate records that the given state was modified in-place.
+func (j *snapshotJournal) recordUpdate(state *resource.State) {
+	if slot, has := j.slots[state]; has {
+		j.entries = append(j.entries, JournalEntry{Kind: apitype.JournalEntryUpdate, Slot: slot, State: state})
+	}
+}
+
+// recordOperationPending records that an operation was started on the given state.
+func (j *snapshotJournal) recordOperationPending(state *resource.State, op resource.OperationType) {
+	j.operations[state] = append(j.operations[state], j.nextOperation)
+	j.nextOperation++
+	j.entries = append(j.entries, JournalEntry{Kind: apitype.JournalEntryBegin, State: state, Type: op})
+}
+
+// recordOperationComplete records that the outstanding operations on the given state have completed.
+func (j *snapshotJournal) recordOperationComplete(state *resource.State) {
+	for _, index := range j.operations[state] {
+		j.entries = append(j.entries, JournalEntry{Kind: apitype.JournalEntryEnd, Operation: index})
+	}
+	delete(j.operations, state)
+}
+
+// mustCompact returns true if the next write must save the whole snapshot rather than append to the journal.
+func (j *snapshotJournal) mustCompact(interval int) bool {
+	return j.compact || j.appended+len(j.entries) >= interval
+}
+
+// dirty returns true if any changes have been made since the snapshot was last saved.
+func (j *snapshotJournal) dirty() bool {
+	return j.appended > 0 || len(j.entries) > 0
+}
+
+// pending returns the batch of changes that have not yet been appended to the journal.
+func (j *snapshotJournal) pending() Journal {
+	return Journal{Base: j.base, NewResources: j.newResources, Entries: j.entries}
+}
+
+// markAppended records that the pending changes have been appended to the journal.
+func (j *snapshotJournal) markAppended() {
+	j.appended += len(j.entries)
+	j.entries = nil
+}
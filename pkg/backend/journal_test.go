@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// MockJournaler serializes the snapshots and journals that it is given as soon as it receives them, just as a real
+// persister would.
+type MockJournaler struct {
+	t        *testing.T
+	saves    int
+	snapshot *apitype.DeploymentV3
+	journals []apitype.JournalV1
+}
+
+func (m *MockJournaler) Save(snap *deploy.Snapshot) error {
+	deployment, err := stack.SerializeDeployment(snap, m.SecretsManager(), false /* showSecrets */)
+	if err != nil {
+		return err
+	}
+	m.saves++
+	m.snapshot, m.journals = deployment, nil
+	return nil
+}
+
+func (m *MockJournaler) Append(journal Journal) error {
+	enc, err := m.SecretsManager().Encrypter()
+	if err != nil {
+		return err
+	}
+	serialized, err := SerializeJournal(journal, enc)
+	if err != nil {
+		return err
+	}
+	m.journals = append(m.journals, serialized)
+	return nil
+}
+
+func (m *MockJournaler) SecretsManager() secrets.Manager {
+	return b64.NewBase64SecretsManager()
+}
+
+// Recover returns the resources and pending operations that a reader of the persisted state would see.
+func (m *MockJournaler) Recover() ([]string, []string) {
+	deployment := *m.snapshot
+	_, err := stack.ReplayJournal(&deployment, m.journals)
+	assert.NoError(m.t, err)
+
+	snap, err := stack.DeserializeDeploymentV3(deployment, stack.DefaultSecretsProvider)
+	assert.NoError(m.t, err)
+	return describe(snap)
+}
+
+// describe summarizes the resources and pending operations in the given snapshot.
+func describe(snap *deploy.Snapshot) ([]string, []string) {
+	var resources, operations []string
+	for _, res := range snap.Resources {
+		resources = append(resources, fmt.Sprintf("%s (delete=%v, outputs=%v)", res.URN, res.Delete, res.Outputs))
+	}
+	for _, op := range snap.PendingOperations {
+		operations = append(operations, fmt.Sprintf("%s (%s)", op.Resource.URN, op.Type))
+	}
+	return resources, operations
+}
+
+func TestJournalReplay(t *testing.T) {
+	a := NewResource("a")
+	b := NewResource("b", a.URN)
+	c := NewResource("c", a.URN, b.URN)
+	d := NewResource("d", c.URN)
+	e := NewResource("e", c.URN)
+	snap := NewSnapshot([]*resource.State{a, b, c, d, e})
+
+	// Run a journaling manager alongside one that saves the whole snapshot after every step. After each step, the
+	// state recovered from the journal must match the snapshot saved by the other manager.
+	journaler := &MockJournaler{t: t}
+	journaling := NewSnapshotManager(journaler, snap)
+	full, sp := MockSetup(t, snap)
+
+	check := func() {
+		if len(sp.SavedSnapshots) == 0 {
+			assert.Nil(t, journaler.snapshot)
+			return
+		}
+		expectedResources, expectedOperations := describe(sp.LastSnap())
+		actualResources, actualOperations := journaler.Recover()
+		assert.Equal(t, expectedResources, actualResources)
+		assert.Equal(t, expectedOperations, actualOperations)
+	}
+	begin := func(step deploy.Step) []engine.SnapshotMutation {
+		var mutations []engine.SnapshotMutation
+		for _, manager := range []*SnapshotManager{journaling, full} {
+			mutation, err := manager.BeginMutation(step)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			mutations = append(mutations, mutation)
+		}
+		if step.Op() != deploy.OpSame {
+			check()
+		}
+		return mutations
+	}
+	end := func(step deploy.Step, mutations []engine.SnapshotMutation, successful bool) {
+		for _, mutation := range mutations {
+			assert.NoError(t, mutation.End(step, successful))
+		}
+		check()
+	}
+	applyStep := func(step deploy.Step, successful bool) {
+		end(step, begin(step), successful)
+	}
+
+	bPrime := NewResource(string(b.URN))
+	applyStep(deploy.NewSameStep(nil, MockRegisterResourceEvent{}, b, bPrime), true)
+
+	cPrime := NewResource(string(c.URN), bPrime.URN)
+	createReplacement := deploy.NewCreateReplacementStep(nil, MockRegisterResourceEvent{}, c, cPrime, nil, nil, nil, true)
+	replace := deploy.NewReplaceStep(nil, c, cPrime, nil, nil, nil, true)
+	mutations := begin(createReplacement)
+	// The engine marks the replaced resource for deletion in-place while the step is applied.
+	c.Delete = true
+	end(createReplacement, mutations, true)
+	applyStep(replace, true)
+
+	dPrime := NewResource(string(d.URN), cPrime.URN)
+	applyStep(deploy.NewUpdateStep(nil, MockRegisterResourceEvent{}, d, dPrime, nil, nil, nil, nil), true)
+
+	applyStep(deploy.NewCreateStep(nil, MockRegisterResourceEvent{}, NewResource("f", dPrime.URN)), false)
+	g := NewResource("g", dPrime.URN)
+	createG := deploy.NewCreateStep(nil, MockRegisterResourceEvent{}, g)
+	applyStep(createG, true)
+
+	// The engine registers outputs by updating the new state in-place.
+	g.Outputs["foo"] = resource.NewStringProperty("bar")
+	assert.NoError(t, journaling.RegisterResourceOutputs(createG))
+	assert.NoError(t, full.RegisterResourceOutputs(createG))
+	check()
+
+	applyStep(deploy.NewDeleteStep(nil, e), true)
+	applyStep(deploy.NewDeleteReplacementStep(nil, c, false), true)
+
+	// Only the first step saved the whole snapshot; every other change was journaled.
+	assert.Equal(t, 1, journaler.saves)
+	assert.NotEmpty(t, journaler.journals)
+
+	// Closing the manager saves the whole snapshot and discards the journal.
+	assert.NoError(t, journaling.Close())
+	assert.NoError(t, full.Close())
+	assert.Equal(t, 2, journaler.saves)
+	assert.Empty(t, journaler.journals)
+	check()
+}
+
+func TestJournalCompaction(t *testing.T) {
+	snap := NewSnapshot(nil)
+	journaler := &MockJournaler{t: t}
+	manager := NewSnapshotManager(journaler, snap)
+	manager.compactInterval = 4
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		step := deploy.NewCreateStep(nil, MockRegisterResourceEvent{}, NewResource(name))
+		mutation, err := manager.BeginMutation(step)
+		assert.NoError(t, err)
+		assert.NoError(t, mutation.End(step, true))
+	}
+
+	// Each create records three entries: one when it begins, and two when it ends. The first write saves the whole
+	// snapshot, and the snapshot is saved again whenever the journal would reach the interval.
+	assert.Equal(t, 4, journaler.saves)
+	resources, _ := journaler.Recover()
+	assert.Len(t, resources, 5)
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		assert.Equal(t, name+" (delete=false, outputs=map[])", resources[i])
+	}
+}
@@ -63,6 +63,9 @@ type SnapshotManager struct {
 	dones            map[*resource.State]bool // The set of resources that have been operated upon already by this plan
 	completeOps      map[*resource.State]bool // The set of resources that have completed their operation
 	doVerify         bool                     // If true, verify the snapshot before persisting it
+	journaler        SnapshotJournaler        // If non-nil, the journaler to which changes are appended between saves
+	journal          *snapshotJournal         // The changes made since the snapshot was last saved, if journaling
+	compactInterval  int                      // The number of journal entries after which the snapshot is saved
 	mutationRequests chan<- mutationRequest   // The queue of mutation requests, to be retired serially by the manager
 	cancel           chan bool                // A channel used to request cancellation of any new mutation requests.
 	done             <-chan error             // A channel that sends a single result when the manager has shut down.
@@ -119,7 +122,12 @@ func (sm *SnapshotManager) mutate(mutator func() bool) error {
 // Note that this is completely not thread-safe and defeats the purpose of having a `mutate` callback
 // entirely, but the hope is that this state of things will not be permament.
 func (sm *SnapshotManager) RegisterResourceOutputs(step deploy.Step) error {
-	return sm.mutate(func() bool { return true })
+	return sm.mutate(func() bool {
+		if new := step.New(); new != nil {
+			sm.markUpdated(new)
+		}
+		return true
+	})
 }
 
 // BeginMutation signals to the SnapshotManager that the engine intends to mutate the global snapshot
@@ -332,6 +340,9 @@ func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error
 			// that it is flushed from the state file.
 			if old := step.Old(); old != nil && old.PendingReplacement {
 				csm.manager.markDone(old)
+			} else if old != nil {
+				// The engine marks the old state of a create-before-delete replacement for deletion in-place.
+				csm.manager.markUpdated(old)
 			}
 		}
 		return true
@@ -394,6 +405,8 @@ func (dsm *deleteSnapshotMutation) End(step deploy.Step, successful bool) error
 			contract.Assert(!step.Old().Protect)
 			if !step.Old().PendingReplacement {
 				dsm.manager.markDone(step.Old())
+			} else {
+				dsm.manager.markUpdated(step.Old())
 			}
 		}
 		return true
@@ -455,6 +468,7 @@ func (rsm *refreshSnapshotMutation) End(step deploy.Step, successful bool) error
 		// some other component will rewrite the base snapshot in-memory, so there's no action the snapshot
 		// manager needs to take other than to remember that the base snapshot--and therefore the actual snapshot--may
 		// have changed.
+		rsm.manager.markRebuilt()
 		return false
 	})
 }
@@ -500,6 +514,10 @@ func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error
 		ism.manager.markOperationComplete(step.New())
 		if successful {
 			ism.manager.markNew(step.New())
+			if step.Op() == deploy.OpImportReplacement {
+				// The engine marks the state being replaced for deletion in-place.
+				ism.manager.markRebuilt()
+			}
 		}
 		return true
 	})
@@ -510,6 +528,9 @@ func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error
 func (sm *SnapshotManager) markDone(state *resource.State) {
 	contract.Assert(state != nil)
 	sm.dones[state] = true
+	if sm.journal != nil {
+		sm.journal.recordDone(state)
+	}
 	logging.V(9).Infof("Marked old state snapshot as done: %v", state.URN)
 }
 
@@ -519,13 +540,37 @@ func (sm *SnapshotManager) markDone(state *resource.State) {
 func (sm *SnapshotManager) markNew(state *resource.State) {
 	contract.Assert(state != nil)
 	sm.resources = append(sm.resources, state)
+	if sm.journal != nil {
+		sm.journal.recordNew(state)
+	}
 	logging.V(9).Infof("Appended new state snapshot to be written: %v", state.URN)
 }
 
+// markUpdated marks a resource that is already part of the snapshot as having been modified in-place by the engine.
+func (sm *SnapshotManager) markUpdated(state *resource.State) {
+	contract.Assert(state != nil)
+	if sm.journal != nil {
+		sm.journal.recordUpdate(state)
+	}
+	logging.V(9).Infof("SnapshotManager.markUpdated(%s)", state.URN)
+}
+
+// markRebuilt marks the snapshot as having been modified by the engine in a way that can only be persisted by saving
+// the whole snapshot, such as by rebuilding the base snapshot after a refresh.
+func (sm *SnapshotManager) markRebuilt() {
+	if sm.journal != nil {
+		sm.journal.compact = true
+	}
+	logging.V(9).Infof("SnapshotManager.markRebuilt()")
+}
+
 // markOperationPending marks a resource as undergoing an operation that will now be considered pending.
 func (sm *SnapshotManager) markOperationPending(state *resource.State, op resource.OperationType) {
 	contract.Assert(state != nil)
 	sm.operations = append(sm.operations, resource.NewOperation(state, op))
+	if sm.journal != nil {
+		sm.journal.recordOperationPending(state, op)
+	}
 	logging.V(9).Infof("SnapshotManager.markPendingOperation(%s, %s)", state.URN, string(op))
 }
 
@@ -533,6 +578,9 @@ func (sm *SnapshotManager) markOperationPending(state *resource.State, op resour
 func (sm *SnapshotManager) markOperationComplete(state *resource.State) {
 	contract.Assert(state != nil)
 	sm.completeOps[state] = true
+	if sm.journal != nil {
+		sm.journal.recordOperationComplete(state)
+	}
 	logging.V(9).Infof("SnapshotManager.markOperationComplete(%s)", state.URN)
 }
 
@@ -581,8 +629,12 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 		}
 	}
 
-	// Record any pending operations, if there are any outstanding that have not completed yet.
+	// Record any pending operations, if there are any outstanding that have not completed yet. These include any
+	// operations in the base snapshot that a refresh was asked to resolve but could not.
 	var operations []resource.Operation
+	if base := sm.baseSnapshot; base != nil {
+		operations = append(operations, base.PendingOperations...)
+	}
 	for _, op := range sm.operations {
 		if !sm.completeOps[op.Resource] {
 			operations = append(operations, op)
@@ -599,6 +651,22 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 	return deploy.NewSnapshot(manifest, sm.persister.SecretsManager(), resources, operations)
 }
 
+// persist persists the changes made to the snapshot. If the manager is journaling, the changes are appended to the
+// journal unless it is time to save the whole snapshot; otherwise, the whole snapshot is saved.
+func (sm *SnapshotManager) persist() error {
+	if sm.journal == nil || sm.journal.mustCompact(sm.compactInterval) {
+		return sm.saveSnapshot()
+	}
+	if len(sm.journal.entries) == 0 {
+		return nil
+	}
+	if err := sm.journaler.Append(sm.journal.pending()); err != nil {
+		return errors.Wrap(err, "failed to append to snapshot journal")
+	}
+	sm.journal.markAppended()
+	return nil
+}
+
 // saveSnapshot persists the current snapshot and optionally verifies it afterwards.
 func (sm *SnapshotManager) saveSnapshot() error {
 	snap := sm.snap()
@@ -608,6 +676,9 @@ func (sm *SnapshotManager) saveSnapshot() error {
 	if err := sm.persister.Save(snap); err != nil {
 		return errors.Wrap(err, "failed to save snapshot")
 	}
+	if sm.journal != nil {
+		sm.journal.reset(snap, len(sm.resources))
+	}
 	if sm.doVerify {
 		if err := snap.VerifyIntegrity(); err != nil {
 			return errors.Wrapf(err, "failed to verify snapshot")
@@ -619,6 +690,9 @@ func (sm *SnapshotManager) saveSnapshot() error {
 // NewSnapshotManager creates a new SnapshotManager for the given stack name, using the given persister
 // and base snapshot.
 //
+// If the persister is a SnapshotJournaler, the manager journals the changes made by each step rather than saving the
+// whole snapshot, which it only does periodically and when it is closed.
+//
 // It is *very important* that the baseSnap pointer refers to the same Snapshot
 // given to the engine! The engine will mutate this object and correctness of the
 // SnapshotManager depends on being able to observe this mutation. (This is not ideal...)
@@ -631,10 +705,15 @@ func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot)
 		dones:            make(map[*resource.State]bool),
 		completeOps:      make(map[*resource.State]bool),
 		doVerify:         true,
+		compactInterval:  DefaultJournalCompactionInterval,
 		mutationRequests: mutationRequests,
 		cancel:           cancel,
 		done:             done,
 	}
+	if journaler, ok := persister.(SnapshotJournaler); ok {
+		manager.journaler = journaler
+		manager.journal = newSnapshotJournal()
+	}
 
 	go func() {
 		// True if we have elided writes since the last actual write.
@@ -647,7 +726,7 @@ func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot)
 			case request := <-mutationRequests:
 				var err error
 				if request.mutator() {
-					err = manager.saveSnapshot()
+					err = manager.persist()
 					hasElidedWrites = false
 				} else {
 					hasElidedWrites = true
@@ -658,9 +737,10 @@ func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot)
 			}
 		}
 
-		// If we still have elided writes once the channel has closed, flush the snapshot.
+		// If we still have elided writes once the channel has closed, flush the snapshot. Likewise, if we have
+		// journaled any changes, save the whole snapshot so that the journal can be discarded.
 		var err error
-		if hasElidedWrites {
+		if hasElidedWrites || (manager.journal != nil && manager.journal.dirty()) {
 			logging.V(9).Infof("SnapshotManager: flushing elided writes...")
 			err = manager.saveSnapshot()
 		}
@@ -21,7 +21,9 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
 	"github.com/pulumi/pulumi/pkg/v3/backend/httpstate"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
@@ -37,6 +39,8 @@ func newCancelCmd() *cobra.Command {
 		Long: "Cancel a stack's currently running update, if any.\n" +
 			"\n" +
 			"This command cancels the update currently being applied to a stack if any exists.\n" +
+			"For self-managed backends, this removes the locks held on the stack instead, which is\n" +
+			"necessary if a process holding a lock crashed before its lease expired.\n" +
 			"Note that this operation is _very dangerous_, and may leave the stack in an\n" +
 			"inconsistent state if a resource operation was pending when the update was canceled.\n" +
 			"\n" +
@@ -61,10 +65,15 @@ func newCancelCmd() *cobra.Command {
 				return result.FromError(err)
 			}
 
+			// Self-managed backends can't stop an update running elsewhere, but can break the lock it holds.
+			if b, ok := s.Backend().(filestate.Backend); ok {
+				return cancelFilestateUpdate(b, s.Ref(), yes, opts)
+			}
+
 			// Ensure that we are targeting the Pulumi cloud.
 			backend, ok := s.Backend().(httpstate.Backend)
 			if !ok {
-				return result.Error("the `cancel` command is not supported for local stacks")
+				return result.Error("the `cancel` command is not supported for this backend")
 			}
 
 			// Ensure the user really wants to do this.
@@ -98,3 +107,39 @@ func newCancelCmd() *cobra.Command {
 
 	return cmd
 }
+
+// cancelFilestateUpdate removes the locks held on a self-managed stack, after showing who holds them.
+func cancelFilestateUpdate(b filestate.Backend, stackRef backend.StackReference, yes bool,
+	opts display.Options) result.Result {
+
+	locks, err := b.StackLocks(commandContext(), stackRef)
+	if err != nil {
+		return result.FromError(err)
+	}
+	stackName := string(stackRef.Name())
+	if len(locks) == 0 {
+		return result.Errorf("there is no update currently running for '%s'", stackName)
+	}
+
+	fmt.Printf("The stack '%s' is locked by:\n", stackName)
+	for _, lock := range locks {
+		fmt.Printf("  %v\n", lock)
+	}
+	fmt.Println()
+
+	// Ensure the user really wants to do this.
+	prompt := fmt.Sprintf("This will remove the locks on '%s'! Make sure that the processes holding them have "+
+		"exited, or they will continue to modify the stack.", stackName)
+	if cmdutil.Interactive() && (!yes && !confirmPrompt(prompt, stackName, opts)) {
+		fmt.Println("confirmation declined")
+		return result.Bail()
+	}
+
+	if err := b.CancelCurrentUpdate(commandContext(), stackRef); err != nil {
+		return result.FromError(err)
+	}
+
+	msg := fmt.Sprintf("%sThe locks on '%s' have been removed!%s", colors.SpecAttention, stackName, colors.Reset)
+	fmt.Println(opts.Color.Colorize(msg))
+	return nil
+}
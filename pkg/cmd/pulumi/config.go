@@ -453,6 +453,7 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 	var plaintext bool
 	var secret bool
 	var path bool
+	var jsonValue bool
 
 	setCmd := &cobra.Command{
 		Use:   "set <key> [value]",
@@ -466,7 +467,10 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 			"  - `pulumi config set --path parent.nested value` " +
 			"will set the value of `parent` to a map `nested: value`.\n" +
 			"  - `pulumi config set --path '[\"parent.name\"].[\"nested.name\"]' value` will set the value of \n" +
-			"    `parent.name` to a map `nested.name: value`.",
+			"    `parent.name` to a map `nested.name: value`.\n\n" +
+			"The `--json` flag can be used to set a structured value, such as a map, list, number or boolean:\n\n" +
+			"  - `pulumi config set --json parent '{\"nested\": [1, true]}'` " +
+			"will set the value of `parent` to a map `nested: [1, true]`.",
 		Args: cmdutil.RangeArgs(1, 2),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			opts := display.Options{
@@ -510,7 +514,15 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 
 			// Encrypt the config value if needed.
 			var v config.Value
-			if secret {
+			if jsonValue {
+				if secret {
+					return errors.New("structured values can't be secret; " +
+						"set each secret inside the value with --path and --secret instead")
+				}
+				if v, err = parseJSONConfigValue(value); err != nil {
+					return err
+				}
+			} else if secret {
 				c, cerr := getStackEncrypter(s)
 				if cerr != nil {
 					return cerr
@@ -555,13 +567,45 @@ func newConfigSetCmd(stack *string) *cobra.Command {
 	setCmd.PersistentFlags().BoolVar(
 		&secret, "secret", false,
 		"Encrypt the value instead of storing it in plaintext")
+	setCmd.PersistentFlags().BoolVar(
+		&jsonValue, "json", false,
+		"Parse the value as JSON, to set a map, list, number or boolean")
 
 	return setCmd
 }
 
+// parseJSONConfigValue parses a configuration value given as JSON. Strings are stored as plain values, and maps,
+// lists, numbers and booleans as structured values.
+func parseJSONConfigValue(value string) (config.Value, error) {
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+
+	var obj interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return config.Value{}, errors.Wrap(err, "config value is not valid JSON")
+	}
+	if dec.More() {
+		return config.Value{}, errors.New("config value is not valid JSON: unexpected data after the value")
+	}
+
+	switch obj := obj.(type) {
+	case nil:
+		return config.Value{}, errors.New("config value may not be null")
+	case string:
+		return config.NewValue(obj), nil
+	default:
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return config.Value{}, err
+		}
+		return config.NewObjectValue(string(b)), nil
+	}
+}
+
 func newConfigSetAllCmd(stack *string) *cobra.Command {
 	var plaintextArgs []string
 	var secretArgs []string
+	var jsonArgs []string
 	var path bool
 
 	setCmd := &cobra.Command{
@@ -577,7 +621,10 @@ func newConfigSetAllCmd(stack *string) *cobra.Command {
 			"  - `pulumi config set-all --path --plaintext parent.nested=value --plaintext parent.other=value2` \n" +
 			"    will set the value of `parent` to a map `{nested: value, other: value2}`.\n" +
 			"  - `pulumi config set-all --path --plaintext '[\"parent.name\"].[\"nested.name\"]'=value` will set the \n" +
-			"    value of `parent.name` to a map `nested.name: value`.",
+			"    value of `parent.name` to a map `nested.name: value`.\n\n" +
+			"The `--json` flag can be used in place of `--plaintext` to set a structured value given as JSON:\n\n" +
+			"  - `pulumi config set-all --json 'parent={\"nested\": [1, true]}'` \n" +
+			"    will set the value of `parent` to a map `nested: [1, true]`.",
 		Args: cmdutil.NoArgs,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			opts := display.Options{
@@ -608,6 +655,22 @@ func newConfigSetAllCmd(stack *string) *cobra.Command {
 				}
 			}
 
+			for _, jsonArg := range jsonArgs {
+				key, value, err := parseKeyValuePair(jsonArg)
+				if err != nil {
+					return err
+				}
+				v, err := parseJSONConfigValue(value)
+				if err != nil {
+					return errors.Wrapf(err, "invalid value for %q", key)
+				}
+
+				err = ps.Config.Set(key, v, path)
+				if err != nil {
+					return err
+				}
+			}
+
 			for _, sArg := range secretArgs {
 				key, value, err := parseKeyValuePair(sArg)
 				if err != nil {
@@ -642,6 +705,9 @@ func newConfigSetAllCmd(stack *string) *cobra.Command {
 	setCmd.PersistentFlags().StringArrayVar(
 		&secretArgs, "secret", []string{},
 		"Marks a value as secret to be encrypted")
+	setCmd.PersistentFlags().StringArrayVar(
+		&jsonArgs, "json", []string{},
+		"Marks a value as JSON, to set a map, list, number or boolean")
 
 	return setCmd
 }
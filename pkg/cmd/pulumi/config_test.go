@@ -41,3 +41,25 @@ func TestSecretDetection(t *testing.T) {
 	// The key name does not match the pattern, so even though this "looks like" a secret, we say it is not.
 	assert.False(t, looksLikeSecret(config.MustMakeKey("test", "okay"), "1415fc1f4eaeb5e096ee58c1480016638fff29bf"))
 }
+
+func TestParseJSONConfigValue(t *testing.T) {
+	tests := map[string]config.Value{
+		`"value"`:                   config.NewValue("value"),
+		`"true"`:                    config.NewValue("true"),
+		`true`:                      config.NewObjectValue("true"),
+		`12345678901234567890`:      config.NewObjectValue("12345678901234567890"),
+		`1.5`:                       config.NewObjectValue("1.5"),
+		`[1, "a", false]`:           config.NewObjectValue(`[1,"a",false]`),
+		`{"b": {"c": [1]}, "a": 2}`: config.NewObjectValue(`{"a":2,"b":{"c":[1]}}`),
+	}
+	for value, expected := range tests {
+		actual, err := parseJSONConfigValue(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, actual, value)
+	}
+
+	for _, value := range []string{``, `null`, `{"a":`, `1 2`, `value`} {
+		_, err := parseJSONConfigValue(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -41,6 +41,7 @@ func newDestroyCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var parallelLimits []string
 	var refresh bool
 	var showConfig bool
 	var showReplacementSteps bool
@@ -51,6 +52,9 @@ func newDestroyCmd() *cobra.Command {
 	var yes bool
 	var targets *[]string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
+	var continueOnError bool
 
 	var cmd = &cobra.Command{
 		Use:        "destroy",
@@ -123,6 +127,11 @@ func newDestroyCmd() *cobra.Command {
 				return result.FromError(err)
 			}
 
+			limits, err := parseParallelLimits(proj, parallelLimits)
+			if err != nil {
+				return result.FromError(err)
+			}
+
 			m, err := getUpdateMetadata(message, root, execKind, execAgent)
 			if err != nil {
 				return result.FromError(errors.Wrap(err, "gathering environment metadata"))
@@ -143,12 +152,21 @@ func newDestroyCmd() *cobra.Command {
 				targetUrns = append(targetUrns, resource.URN(t))
 			}
 
+			excludeURNs := []resource.URN{}
+			for _, e := range excludes {
+				excludeURNs = append(excludeURNs, resource.URN(e))
+			}
+
 			opts.Engine = engine.UpdateOptions{
 				Parallel:                  parallel,
+				ParallelLimits:            limits,
 				Debug:                     debug,
 				Refresh:                   refresh,
 				DestroyTargets:            targetUrns,
 				TargetDependents:          targetDependents,
+				ExcludeTargets:            excludeURNs,
+				ExcludeDependents:         excludeDependents,
+				ContinueOnError:           continueOnError,
 				UseLegacyDiff:             useLegacyDiff(),
 				DisableProviderPreview:    disableProviderPreview(),
 				DisableResourceReferences: disableResourceReferences(),
@@ -164,7 +182,7 @@ func newDestroyCmd() *cobra.Command {
 				Scopes:             cancellationScopes,
 			})
 
-			if res == nil && len(*targets) == 0 {
+			if res == nil && len(*targets) == 0 && len(excludes) == 0 {
 				fmt.Printf("The resources in the stack have been deleted, but the history and configuration "+
 					"associated with the stack are still maintained. \nIf you want to remove the stack "+
 					"completely, run 'pulumi stack rm %s'.\n", s.Ref())
@@ -191,10 +209,21 @@ func newDestroyCmd() *cobra.Command {
 	targets = cmd.PersistentFlags().StringArrayP(
 		"target", "t", []string{},
 		"Specify a single resource URN to destroy. All resources necessary to destroy this target will also be destroyed."+
-			" Multiple resources can be specified using: --target urn1 --target urn2")
+			" Multiple resources can be specified using: --target urn1 --target urn2."+urnPatternHelp)
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows destroying of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to leave untouched. All other resources will be destroyed."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+urnPatternHelp)
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Allows excluding resources that depend on a resource specified in the --exclude list")
+	cmd.PersistentFlags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue deleting resources that are not depended upon by a resource that failed to delete, rather than "+
+			"stopping at the first error")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().BoolVar(
@@ -203,6 +232,9 @@ func newDestroyCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().StringArrayVar(
+		&parallelLimits, "parallel-limit", []string{},
+		parallelLimitHelp)
 	cmd.PersistentFlags().BoolVarP(
 		&refresh, "refresh", "r", false,
 		"Refresh the state of the stack's resources before this update")
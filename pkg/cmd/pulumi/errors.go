@@ -56,10 +56,14 @@ waiting for changes to these resources to complete. You should confirm whether o
 operations listed completed successfully by checking the state of the appropriate provider.
 For example, if you are using AWS, you can confirm using the AWS Console.
 
-Once you have confirmed the status of the interrupted operations, you can repair your stack
-using 'pulumi stack export' to export your stack to a file. For each operation that succeeded,
-remove that operation from the "pending_operations" section of the file. Once this is complete,
-use 'pulumi stack import' to import the repaired stack.
+To resolve these operations automatically, run 'pulumi refresh --clear-pending-operations'.
+This refreshes the resources whose update or deletion was interrupted, and adopts each resource
+whose creation was interrupted if its provider can find it.
+
+Alternatively, once you have confirmed the status of the interrupted operations, you can repair
+your stack using 'pulumi stack export' to export your stack to a file. For each operation that
+succeeded, remove that operation from the "pending_operations" section of the file. Once this is
+complete, use 'pulumi stack import' to import the repaired stack.
 
 refusing to proceed`)
 	contract.IgnoreError(writer.Flush())
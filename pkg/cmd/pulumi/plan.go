@@ -0,0 +1,60 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// readPlan reads a deployment plan saved by `pulumi preview --save-plan`.
+func readPlan(path string) (*deploy.Plan, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading plan")
+	}
+
+	var plan apitype.DeploymentPlanV1
+	if err = json.Unmarshal(b, &plan); err != nil {
+		return nil, errors.Wrap(err, "parsing plan")
+	}
+
+	result, err := stack.DeserializePlan(plan, stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading plan")
+	}
+	return result, nil
+}
+
+// writePlan saves a deployment plan to the given path, encrypting its secret values with the given secrets manager.
+func writePlan(path string, plan *deploy.Plan, sm secrets.Manager) error {
+	serialized, err := stack.SerializePlan(plan, sm, false /*showSecrets*/)
+	if err != nil {
+		return errors.Wrap(err, "serializing plan")
+	}
+
+	b, err := json.MarshalIndent(serialized, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "serializing plan")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, b, 0600), "writing plan")
+}
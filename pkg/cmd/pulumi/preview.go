@@ -36,6 +36,7 @@ func newPreviewCmd() *cobra.Command {
 	var configArray []string
 	var configPath bool
 	var client string
+	var planFilePath string
 
 	// Flags for engine.UpdateOptions.
 	var jsonDisplay bool
@@ -44,6 +45,7 @@ func newPreviewCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var parallelLimits []string
 	var refresh bool
 	var showConfig bool
 	var showReplacementSteps bool
@@ -55,6 +57,8 @@ func newPreviewCmd() *cobra.Command {
 	var replaces []string
 	var targetReplaces []string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
 
 	var cmd = &cobra.Command{
 		Use:        "preview",
@@ -130,6 +134,11 @@ func newPreviewCmd() *cobra.Command {
 				return result.FromError(err)
 			}
 
+			limits, err := parseParallelLimits(proj, parallelLimits)
+			if err != nil {
+				return result.FromError(err)
+			}
+
 			m, err := getUpdateMetadata(message, root, execKind, execAgent)
 			if err != nil {
 				return result.FromError(errors.Wrap(err, "gathering environment metadata"))
@@ -160,10 +169,16 @@ func newPreviewCmd() *cobra.Command {
 				replaceURNs = append(replaceURNs, resource.URN(tr))
 			}
 
+			excludeURNs := []resource.URN{}
+			for _, e := range excludes {
+				excludeURNs = append(excludeURNs, resource.URN(e))
+			}
+
 			opts := backend.UpdateOptions{
 				Engine: engine.UpdateOptions{
 					LocalPolicyPacks:          engine.MakeLocalPolicyPacks(policyPackPaths, policyPackConfigPaths),
 					Parallel:                  parallel,
+					ParallelLimits:            limits,
 					Debug:                     debug,
 					Refresh:                   refresh,
 					ReplaceTargets:            replaceURNs,
@@ -172,11 +187,13 @@ func newPreviewCmd() *cobra.Command {
 					DisableResourceReferences: disableResourceReferences(),
 					UpdateTargets:             targetURNs,
 					TargetDependents:          targetDependents,
+					ExcludeTargets:            excludeURNs,
+					ExcludeDependents:         excludeDependents,
 				},
 				Display: displayOpts,
 			}
 
-			changes, res := s.Preview(commandContext(), backend.UpdateOperation{
+			plan, changes, res := s.Preview(commandContext(), backend.UpdateOperation{
 				Proj:               proj,
 				Root:               root,
 				M:                  m,
@@ -191,6 +208,8 @@ func newPreviewCmd() *cobra.Command {
 				return PrintEngineResult(res)
 			case expectNop && changes != nil && changes.HasChanges():
 				return result.FromError(errors.New("error: no changes were expected but changes were proposed"))
+			case planFilePath != "":
+				return result.WrapIfNonNil(writePlan(planFilePath, plan, sm))
 			default:
 				return nil
 			}
@@ -223,14 +242,19 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVarP(
 		&message, "message", "m", "",
 		"Optional message to associate with the preview operation")
+	cmd.PersistentFlags().StringVar(
+		&planFilePath, "save-plan", "",
+		"Save the operations proposed by the preview to a plan file. Pass the file to `pulumi up --plan` to "+
+			"ensure that the update performs only these operations")
 
 	cmd.PersistentFlags().StringArrayVarP(
 		&targets, "target", "t", []string{},
 		"Specify a single resource URN to update. Other resources will not be updated."+
-			" Multiple resources can be specified using --target urn1 --target urn2")
+			" Multiple resources can be specified using --target urn1 --target urn2."+urnPatternHelp)
 	cmd.PersistentFlags().StringArrayVar(
 		&replaces, "replace", []string{},
-		"Specify resources to replace. Multiple resources can be specified using --replace urn1 --replace urn2")
+		"Specify resources to replace. Multiple resources can be specified using --replace urn1 --replace urn2."+
+			urnPatternHelp)
 	cmd.PersistentFlags().StringArrayVar(
 		&targetReplaces, "target-replace", []string{},
 		"Specify a single resource URN to replace. Other resources will not be updated."+
@@ -238,6 +262,13 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows updating of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to leave untouched. All other resources will be updated."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+urnPatternHelp)
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Allows excluding resources that depend on a resource specified in the --exclude list")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().StringSliceVar(
@@ -255,6 +286,9 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().StringArrayVar(
+		&parallelLimits, "parallel-limit", []string{},
+		parallelLimitHelp)
 	cmd.PersistentFlags().BoolVarP(
 		&refresh, "refresh", "r", false,
 		"Refresh the state of the stack's resources before this update")
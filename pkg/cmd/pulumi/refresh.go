@@ -40,6 +40,7 @@ func newRefreshCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var parallelLimits []string
 	var showConfig bool
 	var showReplacementSteps bool
 	var showSames bool
@@ -48,6 +49,9 @@ func newRefreshCmd() *cobra.Command {
 	var suppressPermaLink string
 	var yes bool
 	var targets *[]string
+	var excludes []string
+	var excludeDependents bool
+	var clearPendingOperations bool
 
 	var cmd = &cobra.Command{
 		Use:   "refresh",
@@ -59,6 +63,12 @@ func newRefreshCmd() *cobra.Command {
 			"the program text isn't updated accordingly, subsequent updates may still appear to be out of\n" +
 			"synch with respect to the cloud provider's source of truth.\n" +
 			"\n" +
+			"If an earlier update was interrupted, the stack may have operations that are still pending.\n" +
+			"Pass `--clear-pending-operations` to resolve them: resources whose update or deletion was\n" +
+			"interrupted are refreshed, and resources whose creation was interrupted are read from their\n" +
+			"provider and adopted into the stack if they exist. Operations that cannot be resolved remain\n" +
+			"pending.\n" +
+			"\n" +
 			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
 			"`--cwd` flag to use a different directory.",
 		Args: cmdutil.NoArgs,
@@ -120,6 +130,11 @@ func newRefreshCmd() *cobra.Command {
 				return result.FromError(err)
 			}
 
+			limits, err := parseParallelLimits(proj, parallelLimits)
+			if err != nil {
+				return result.FromError(err)
+			}
+
 			m, err := getUpdateMetadata(message, root, execKind, execAgent)
 			if err != nil {
 				return result.FromError(errors.Wrap(err, "gathering environment metadata"))
@@ -140,13 +155,22 @@ func newRefreshCmd() *cobra.Command {
 				targetUrns = append(targetUrns, resource.URN(t))
 			}
 
+			excludeURNs := []resource.URN{}
+			for _, e := range excludes {
+				excludeURNs = append(excludeURNs, resource.URN(e))
+			}
+
 			opts.Engine = engine.UpdateOptions{
 				Parallel:                  parallel,
+				ParallelLimits:            limits,
 				Debug:                     debug,
 				UseLegacyDiff:             useLegacyDiff(),
 				DisableProviderPreview:    disableProviderPreview(),
 				DisableResourceReferences: disableResourceReferences(),
 				RefreshTargets:            targetUrns,
+				ExcludeTargets:            excludeURNs,
+				ExcludeDependents:         excludeDependents,
+				ClearPendingOperations:    clearPendingOperations,
 			}
 
 			changes, res := s.Refresh(commandContext(), backend.UpdateOperation{
@@ -191,7 +215,18 @@ func newRefreshCmd() *cobra.Command {
 
 	targets = cmd.PersistentFlags().StringArrayP(
 		"target", "t", []string{},
-		"Specify a single resource URN to refresh. Multiple resource can be specified using: --target urn1 --target urn2")
+		"Specify a single resource URN to refresh. Multiple resource can be specified using: --target urn1 --target urn2."+
+			urnPatternHelp)
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to leave untouched. All other resources will be refreshed."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+urnPatternHelp)
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Allows excluding resources that depend on a resource specified in the --exclude list")
+	cmd.PersistentFlags().BoolVar(
+		&clearPendingOperations, "clear-pending-operations", false,
+		"Resolve any operations left pending by an interrupted update, rather than refusing to proceed")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().BoolVar(
@@ -200,6 +235,9 @@ func newRefreshCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().StringArrayVar(
+		&parallelLimits, "parallel-limit", []string{},
+		parallelLimitHelp)
 	cmd.PersistentFlags().BoolVar(
 		&showReplacementSteps, "show-replacement-steps", false,
 		"Show detailed resource replacement creates and deletes instead of a single step")
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 
@@ -61,23 +62,11 @@ func newStackExportCmd() *cobra.Command {
 			// the backend/stack implements the ability the export previous checkpoints.
 			if version == "" {
 				deployment, err = s.ExportDeployment(ctx)
-				if err != nil {
-					return err
-				}
 			} else {
-				// Check that the stack and its backend supports the ability to do this.
-				be := s.Backend()
-				specificExpBE, ok := be.(backend.SpecificDeploymentExporter)
-				if !ok {
-					return errors.Errorf(
-						"the current backend (%s) does not provide the ability to export previous deployments",
-						be.Name())
-				}
-
-				deployment, err = specificExpBE.ExportDeploymentForVersion(ctx, s, version)
-				if err != nil {
-					return err
-				}
+				deployment, err = exportDeploymentForVersion(ctx, s, version)
+			}
+			if err != nil {
+				return err
 			}
 
 			// Read from stdin or a specified file.
@@ -90,25 +79,10 @@ func newStackExportCmd() *cobra.Command {
 			}
 
 			if showSecrets {
-				snap, err := stack.DeserializeUntypedDeployment(deployment, stack.DefaultSecretsProvider)
-				if err != nil {
-					return checkDeploymentVersionError(err, stackName)
-				}
-
-				serializedDeployment, err := stack.SerializeDeployment(snap, snap.SecretsManager, true)
+				deployment, err = showDeploymentSecrets(deployment, stackName)
 				if err != nil {
 					return err
 				}
-
-				data, err := json.Marshal(serializedDeployment)
-				if err != nil {
-					return err
-				}
-
-				deployment = &apitype.UntypedDeployment{
-					Version:    3,
-					Deployment: data,
-				}
 			}
 
 			// Write the deployment.
@@ -132,3 +106,44 @@ func newStackExportCmd() *cobra.Command {
 		&showSecrets, "show-secrets", "", false, "Emit secrets in plaintext in exported stack. Defaults to `false`")
 	return cmd
 }
+
+// exportDeploymentForVersion exports a previous version of the given stack's deployment, if its backend supports it.
+func exportDeploymentForVersion(ctx context.Context, s backend.Stack,
+	version string) (*apitype.UntypedDeployment, error) {
+
+	// Check that the stack and its backend supports the ability to do this.
+	be := s.Backend()
+	specificExpBE, ok := be.(backend.SpecificDeploymentExporter)
+	if !ok {
+		return nil, errors.Errorf(
+			"the current backend (%s) does not provide the ability to export previous deployments",
+			be.Name())
+	}
+
+	return specificExpBE.ExportDeploymentForVersion(ctx, s, version)
+}
+
+// showDeploymentSecrets re-serializes the given deployment with its secrets in plaintext.
+func showDeploymentSecrets(deployment *apitype.UntypedDeployment,
+	stackName string) (*apitype.UntypedDeployment, error) {
+
+	snap, err := stack.DeserializeUntypedDeployment(deployment, stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, checkDeploymentVersionError(err, stackName)
+	}
+
+	serializedDeployment, err := stack.SerializeDeployment(snap, snap.SecretsManager, true)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(serializedDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: data,
+	}, nil
+}
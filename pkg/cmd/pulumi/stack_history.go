@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -27,6 +28,7 @@ func newStackHistoryCmd() *cobra.Command {
 	var pageSize int
 	var page int
 	var showFullDates bool
+	var showCheckpoint string
 
 	cmd := &cobra.Command{
 		Use:        "history",
@@ -35,7 +37,10 @@ func newStackHistoryCmd() *cobra.Command {
 		Short:      "[PREVIEW] Display history for a stack",
 		Long: `Display history for a stack
 
-This command displays data about previous updates for a stack.`,
+This command displays data about previous updates for a stack.
+
+Use --show-checkpoint to display the state of the stack as of the end of a previous update, in the same format
+as 'pulumi stack export'.`,
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			opts := display.Options{
 				Color: cmdutil.GetGlobalColorization(),
@@ -44,6 +49,10 @@ This command displays data about previous updates for a stack.`,
 			if err != nil {
 				return err
 			}
+			if showCheckpoint != "" {
+				return displayHistoryCheckpoint(s, showCheckpoint, showSecrets)
+			}
+
 			b := s.Backend()
 			updates, err := b.GetHistory(commandContext(), s.Ref(), pageSize, page)
 			if err != nil {
@@ -71,7 +80,7 @@ This command displays data about previous updates for a stack.`,
 		"Choose a stack other than the currently selected one")
 	cmd.Flags().BoolVar(
 		&showSecrets, "show-secrets", false,
-		"Show secret values when listing config instead of displaying blinded values")
+		"Show secret values when listing config or displaying a checkpoint instead of displaying blinded values")
 	cmd.PersistentFlags().BoolVarP(
 		&jsonOut, "json", "j", false, "Emit output as JSON")
 	cmd.PersistentFlags().BoolVar(
@@ -80,9 +89,29 @@ This command displays data about previous updates for a stack.`,
 		&pageSize, "page-size", 10, "Used with 'page' to control number of results returned")
 	cmd.PersistentFlags().IntVar(
 		&page, "page", 1, "Used with 'page-size' to paginate results")
+	cmd.PersistentFlags().StringVar(
+		&showCheckpoint, "show-checkpoint", "", "Display the stack's state as of the end of the given version")
 	return cmd
 }
 
+// displayHistoryCheckpoint prints the deployment saved at the end of the given version of the stack's history.
+func displayHistoryCheckpoint(s backend.Stack, version string, showSecrets bool) error {
+	deployment, err := exportDeploymentForVersion(commandContext(), s, version)
+	if err != nil {
+		return err
+	}
+	if showSecrets {
+		deployment, err = showDeploymentSecrets(deployment, string(s.Ref().Name()))
+		if err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "    ")
+	return enc.Encode(deployment)
+}
+
 // updateInfoJSON is the shape of the --json output for a configuration value.  While we can add fields to this
 // structure in the future, we should not change existing fields.
 type updateInfoJSON struct {
@@ -0,0 +1,97 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStackRollbackStateCmd() *cobra.Command {
+	var stackName string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "rollback-state <version>",
+		Args:  cmdutil.ExactArgs(1),
+		Short: "Restore a stack's state to the state at the end of a previous update",
+		Long: "Restore a stack's state to the state at the end of a previous update.\n" +
+			"\n" +
+			"This command replaces the stack's current state with the state that was saved when the\n" +
+			"given version of the stack finished updating. Versions are listed by `pulumi stack history`,\n" +
+			"and `pulumi stack history --show-checkpoint <version>` displays the state that will be restored.\n" +
+			"\n" +
+			"No resources are created, updated or deleted. Any changes that were made to the stack's\n" +
+			"resources after the given version will no longer be tracked by the stack; use\n" +
+			"`pulumi refresh` afterwards to reconcile the restored state with the actual resources.",
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			version := args[0]
+			yes = yes || skipConfirmations()
+
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			s, err := requireStack(stackName, false, opts, false /*setCurrent*/)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			ctx := commandContext()
+			deployment, err := exportDeploymentForVersion(ctx, s, version)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			// Make sure that the old state is usable before replacing the current state with it.
+			snapshot, err := stack.DeserializeUntypedDeployment(deployment, stack.DefaultSecretsProvider)
+			if err != nil {
+				return result.FromError(checkDeploymentVersionError(err, string(s.Ref().Name())))
+			}
+			if err := snapshot.VerifyIntegrity(); err != nil {
+				return result.FromError(errors.Wrapf(err, "the state of version %s is invalid", version))
+			}
+
+			if !yes && cmdutil.Interactive() {
+				prompt := fmt.Sprintf("This will replace the current state of '%s' with the state of version %s!",
+					s.Ref(), version)
+				if !confirmPrompt(prompt, s.Ref().String(), opts) {
+					fmt.Println("confirmation declined")
+					return result.Bail()
+				}
+			}
+
+			if err = s.ImportDeployment(ctx, deployment); err != nil {
+				return result.FromError(errors.Wrap(err, "could not restore deployment"))
+			}
+			fmt.Printf("Restored the state of '%s' to version %s.\n", s.Ref(), version)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false, "Skip confirmation prompts, and proceed with the rollback anyway")
+
+	return cmd
+}
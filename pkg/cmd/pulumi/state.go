@@ -19,6 +19,7 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/backend/display"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
@@ -46,7 +47,11 @@ troubleshooting a stack or when performing specific edits that otherwise would r
 	}
 
 	cmd.AddCommand(newStateDeleteCommand())
+	cmd.AddCommand(newStateMoveCommand())
+	cmd.AddCommand(newStateRenameCommand())
+	cmd.AddCommand(newStateRepairCommand())
 	cmd.AddCommand(newStateUnprotectCommand())
+	cmd.AddCommand(newStateUpgradeCommand())
 	return cmd
 }
 
@@ -140,16 +145,7 @@ func runTotalStateEdit(
 	}
 
 	if showPrompt && cmdutil.Interactive() {
-		confirm := false
-		surveycore.DisableColor = true
-		surveycore.QuestionIcon = ""
-		surveycore.SelectFocusIcon = opts.Color.Colorize(colors.BrightGreen + ">" + colors.Reset)
-		prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
-		prompt += "This command will edit your stack's state directly. Confirm?"
-		cmdutil.EndKeypadTransmitMode()
-		if err = survey.AskOne(&survey.Confirm{
-			Message: prompt,
-		}, &confirm, nil); err != nil || !confirm {
+		if !confirmStateEdit(opts, "This command will edit your stack's state directly. Confirm?") {
 			fmt.Println("confirmation declined")
 			return result.Bail()
 		}
@@ -168,6 +164,28 @@ func runTotalStateEdit(
 		contract.AssertNoErrorf(snap.VerifyIntegrity(), "state edit produced an invalid snapshot")
 	}
 
+	return saveSnapshot(s, snap)
+}
+
+// confirmStateEdit prompts the user to confirm a state edit, returning true if they accepted.
+func confirmStateEdit(opts display.Options, message string) bool {
+	confirm := false
+	surveycore.DisableColor = true
+	surveycore.QuestionIcon = ""
+	surveycore.SelectFocusIcon = opts.Color.Colorize(colors.BrightGreen + ">" + colors.Reset)
+	prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
+	prompt += message
+	cmdutil.EndKeypadTransmitMode()
+	if err := survey.AskOne(&survey.Confirm{
+		Message: prompt,
+	}, &confirm, nil); err != nil {
+		return false
+	}
+	return confirm
+}
+
+// saveSnapshot serializes the given snapshot with its own secrets manager and imports it into the given stack.
+func saveSnapshot(s backend.Stack, snap *deploy.Snapshot) result.Result {
 	sdep, err := stack.SerializeDeployment(snap, snap.SecretsManager, false /* showSecrets */)
 	if err != nil {
 		return result.FromError(errors.Wrap(err, "serializing deployment"))
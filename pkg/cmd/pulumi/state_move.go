@@ -0,0 +1,239 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/version"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateMoveCommand() *cobra.Command {
+	var source string
+	var dest string
+	var includeChildren bool
+	var includeDependencies bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "move <resource URN>...",
+		Short: "Move resources from one stack's state to another",
+		Long: `Move resources from one stack's state to another
+
+This command moves one or more resources from the state of a source stack into the state of a destination stack,
+without touching the resources themselves. The resources are specified by their Pulumi URNs (use
+` + "`pulumi stack --show-urns`" + ` to get them).
+
+Moved resources have their URNs rewritten to belong to the destination stack and project. Any providers they use are
+copied into the destination stack, and secret values are re-encrypted with the destination stack's secrets provider.
+If the destination stack already has a provider with the same name and configuration, the moved resources use it
+instead; a provider whose name is taken by a differently configured one is copied with a "_moved" suffix.
+Resources that are parented to the source stack are parented to the destination stack instead.
+Resources that are children of, or dependencies of, the requested resources can be moved along with them using the
+--include-children and --include-dependencies flags.
+
+Resources can't be moved if doing so would leave a resource in either stack with a parent or dependency in the other.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+
+Example:
+pulumi state move --dest networking 'urn:pulumi:prod::infra::aws:ec2/vpc:Vpc::main'
+`,
+		Args: cmdutil.MinimumNArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			var urns []resource.URN
+			for _, arg := range args {
+				urns = append(urns, resource.URN(arg))
+			}
+
+			res := runStateMove(source, dest, urns, includeChildren, includeDependencies, showPrompt)
+			if res != nil {
+				switch e := res.Error().(type) {
+				case edit.ResourceHasDanglingReferencesError:
+					message := fmt.Sprintf(
+						"These resources can't be moved because %q would be left referring to resources in another stack:\n",
+						e.Resource.URN)
+					for _, ref := range e.References {
+						message += fmt.Sprintf(" * %-15q (%s)\n", ref.Name(), ref)
+					}
+
+					message += "\nMove those resources as well, for example by passing " +
+						"--include-children or --include-dependencies."
+					return result.Error(message)
+				default:
+					return res
+				}
+			}
+			fmt.Println("Resources moved successfully")
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&source, "source", "s", "",
+		"The name of the stack to move resources from. Defaults to the current stack")
+	cmd.PersistentFlags().StringVarP(
+		&dest, "dest", "d", "",
+		"The name of the stack to move resources to")
+	contract.AssertNoError(cmd.MarkPersistentFlagRequired("dest"))
+	cmd.Flags().BoolVar(&includeChildren, "include-children", false,
+		"Also move the descendants of the given resources")
+	cmd.Flags().BoolVar(&includeDependencies, "include-dependencies", false,
+		"Also move the resources that the given resources depend on")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	return cmd
+}
+
+func runStateMove(sourceName, destName string, urns []resource.URN,
+	includeChildren, includeDependencies, showPrompt bool) result.Result {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+
+	sourceStack, err := requireStack(sourceName, false, opts, false /*setCurrent*/)
+	if err != nil {
+		return result.FromError(err)
+	}
+	destStack, err := requireStack(destName, true, opts, false /*setCurrent*/)
+	if err != nil {
+		return result.FromError(err)
+	}
+	if sourceStack.Ref().String() == destStack.Ref().String() {
+		return result.Error("the source and destination stacks must be different")
+	}
+
+	sourceSnap, err := sourceStack.Snapshot(commandContext())
+	if err != nil {
+		return result.FromError(err)
+	}
+	if sourceSnap == nil {
+		return result.Errorf("stack %s has no resources to move", sourceStack.Ref())
+	}
+	destSnap, err := destStack.Snapshot(commandContext())
+	if err != nil {
+		return result.FromError(err)
+	}
+	if destSnap == nil {
+		if destSnap, err = newEmptySnapshot(destStack); err != nil {
+			return result.FromError(err)
+		}
+	}
+
+	var roots []*resource.State
+	for _, urn := range urns {
+		res, err := locateStackResource(opts, sourceSnap, urn)
+		if err != nil {
+			return result.FromError(err)
+		}
+		roots = append(roots, res)
+	}
+	resources := edit.CollectResources(sourceSnap, roots, includeChildren, includeDependencies)
+
+	destProject, err := stackProject(destStack, destSnap)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	if showPrompt && cmdutil.Interactive() {
+		fmt.Printf("The following resources will be moved from %s to %s:\n", sourceStack.Ref(), destStack.Ref())
+		for _, res := range resources {
+			fmt.Printf(" * %s\n", res.URN)
+		}
+		fmt.Println()
+		if !confirmStateEdit(opts, "This command will edit the state of both stacks directly. Confirm?") {
+			fmt.Println("confirmation declined")
+			return result.Bail()
+		}
+	}
+
+	// As with other state edits, only insist that the edit preserves the integrity of snapshots that were valid to
+	// begin with.
+	sourceIsAlreadyHosed := sourceSnap.VerifyIntegrity() != nil
+	destIsAlreadyHosed := destSnap.VerifyIntegrity() != nil
+	err = edit.MoveResources(sourceSnap, destSnap, resources, destStack.Ref().Name(), destProject)
+	if err != nil {
+		return result.FromError(err)
+	}
+	if !sourceIsAlreadyHosed {
+		contract.AssertNoErrorf(sourceSnap.VerifyIntegrity(), "state move produced an invalid source snapshot")
+	}
+	if !destIsAlreadyHosed {
+		contract.AssertNoErrorf(destSnap.VerifyIntegrity(), "state move produced an invalid destination snapshot")
+	}
+
+	// Save the destination first: if saving the source then fails, the resources are duplicated rather than lost.
+	if res := saveSnapshot(destStack, destSnap); res != nil {
+		return res
+	}
+	if res := saveSnapshot(sourceStack, sourceSnap); res != nil {
+		return result.FromError(errors.Wrapf(res.Error(),
+			"resources were copied to %s but could not be removed from %s", destStack.Ref(), sourceStack.Ref()))
+	}
+	return nil
+}
+
+// stackProject returns the project that the given stack belongs to. This is recorded by the stack's reference if its
+// backend scopes stacks by project, and otherwise by the URN of the stack's root resource. A stack that has neither is
+// assumed to belong to the project in the current directory.
+func stackProject(s backend.Stack, snap *deploy.Snapshot) (tokens.PackageName, error) {
+	if project, ok := s.Ref().Project(); ok {
+		return project, nil
+	}
+	for _, res := range snap.Resources {
+		if res.Type == resource.RootStackType {
+			return res.URN.Project(), nil
+		}
+	}
+
+	proj, _, err := readProject()
+	if err != nil {
+		return "", errors.Wrapf(err, "could not determine the project of stack %s; "+
+			"run this command from the stack's project directory", s.Ref())
+	}
+	return proj.Name, nil
+}
+
+// newEmptySnapshot creates an empty snapshot for a stack that has never been deployed, encrypting secrets with the
+// stack's secrets manager.
+func newEmptySnapshot(s backend.Stack) (*deploy.Snapshot, error) {
+	sm, err := getStackSecretsManager(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting secrets manager")
+	}
+
+	manifest := deploy.Manifest{
+		Time:    time.Now(),
+		Version: version.Version,
+	}
+	manifest.Magic = manifest.NewMagic()
+	return deploy.NewSnapshot(manifest, sm, nil, nil), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateRenameCommand() *cobra.Command {
+	var stack string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "rename <resource URN> <new name>",
+		Short: "Renames a resource in a stack's state",
+		Long: `Renames a resource in a stack's state
+
+This command changes the logical name of a resource in a stack's state, without touching the resource itself. The
+resource is specified by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it). Every reference to the
+resource held by other resources in the stack, such as dependencies, parents and providers, is updated to match.
+
+This is useful after renaming a resource in a program, when neither an alias nor a replacement is desired.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+
+Example:
+pulumi state rename 'urn:pulumi:stage::demo::aws:s3/bucket:Bucket::logs' access-logs
+`,
+		Args: cmdutil.ExactArgs(2),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			yes = yes || skipConfirmations()
+			urn := resource.URN(args[0])
+			newName := tokens.QName(args[1])
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			// The name is the last component of the URN, so it can't contain the URN's delimiter.
+			if newName == "" || strings.Contains(args[1], resource.URNNameDelimiter) {
+				return result.Errorf("%q is not a valid resource name", args[1])
+			}
+
+			res := runStateEdit(stack, showPrompt, urn, func(snap *deploy.Snapshot, res *resource.State) error {
+				if err := edit.RenameResource(snap, res, newName); err != nil {
+					return err
+				}
+
+				// Refuse to save a snapshot that the rename has left in an inconsistent state.
+				return errors.Wrap(snap.VerifyIntegrity(), "renaming resource produced an invalid snapshot")
+			})
+			if res != nil {
+				return res
+			}
+			fmt.Println("Resource renamed successfully")
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	return cmd
+}
@@ -0,0 +1,85 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStateUpgradeCommand() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Migrates the current backend to the latest supported version",
+		Long: `Migrates the current backend to the latest supported version
+
+This only has an effect on self-managed backends. Backends created by older versions of the CLI store every stack
+at the root of the backend, so stack names must be unique across all projects. Upgrading moves each stack, along
+with its history and backups, under a directory for its project, so that different projects may use the same stack
+names. Once upgraded, older versions of the CLI will no longer be able to read the backend's stacks.
+
+If the upgrade is interrupted, or the project of a stack cannot be determined, the backend keeps using the old
+layout, and any stacks that were already moved remain available. Run the upgrade again to finish it.`,
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			return runStateUpgrade(showPrompt)
+		}),
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	return cmd
+}
+
+func runStateUpgrade(showPrompt bool) result.Result {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+
+	b, err := currentBackend(opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+	lb, ok := b.(filestate.Backend)
+	if !ok {
+		return result.FromError(errors.New("upgrading is only supported for self-managed backends"))
+	}
+
+	if showPrompt && cmdutil.Interactive() {
+		if !confirmStateEdit(opts, "This will upgrade the current backend to the latest supported version.\n"+
+			"Older versions of the CLI will not be able to read the upgraded backend. Confirm?") {
+			fmt.Println("confirmation declined")
+			return result.Bail()
+		}
+	}
+
+	if err := lb.Upgrade(commandContext()); err != nil {
+		return result.FromError(err)
+	}
+	fmt.Println("Backend upgraded successfully")
+	return nil
+}
@@ -60,6 +60,7 @@ func newUpCmd() *cobra.Command {
 	var diffDisplay bool
 	var eventLogPath string
 	var parallel int
+	var parallelLimits []string
 	var refresh bool
 	var showConfig bool
 	var showReplacementSteps bool
@@ -74,6 +75,10 @@ func newUpCmd() *cobra.Command {
 	var replaces []string
 	var targetReplaces []string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
+	var continueOnError bool
+	var planFilePath string
 
 	// up implementation used when the source of the Pulumi program is in the current working directory.
 	upWorkingDirectory := func(opts backend.UpdateOptions) result.Result {
@@ -92,6 +97,11 @@ func newUpCmd() *cobra.Command {
 			return result.FromError(err)
 		}
 
+		limits, err := parseParallelLimits(proj, parallelLimits)
+		if err != nil {
+			return result.FromError(err)
+		}
+
 		m, err := getUpdateMetadata(message, root, execKind, execAgent)
 		if err != nil {
 			return result.FromError(errors.Wrap(err, "gathering environment metadata"))
@@ -122,9 +132,15 @@ func newUpCmd() *cobra.Command {
 			replaceURNs = append(replaceURNs, resource.URN(tr))
 		}
 
+		excludeURNs := []resource.URN{}
+		for _, e := range excludes {
+			excludeURNs = append(excludeURNs, resource.URN(e))
+		}
+
 		opts.Engine = engine.UpdateOptions{
 			LocalPolicyPacks:          engine.MakeLocalPolicyPacks(policyPackPaths, policyPackConfigPaths),
 			Parallel:                  parallel,
+			ParallelLimits:            limits,
 			Debug:                     debug,
 			Refresh:                   refresh,
 			RefreshTargets:            targetURNs,
@@ -134,6 +150,17 @@ func newUpCmd() *cobra.Command {
 			DisableResourceReferences: disableResourceReferences(),
 			UpdateTargets:             targetURNs,
 			TargetDependents:          targetDependents,
+			ExcludeTargets:            excludeURNs,
+			ExcludeDependents:         excludeDependents,
+			ContinueOnError:           continueOnError,
+		}
+
+		if planFilePath != "" {
+			plan, err := readPlan(planFilePath)
+			if err != nil {
+				return result.FromError(err)
+			}
+			opts.Engine.Plan = plan
 		}
 
 		changes, res := s.Update(commandContext(), backend.UpdateOperation{
@@ -245,6 +272,12 @@ func newUpCmd() *cobra.Command {
 		if err != nil {
 			return result.FromError(err)
 		}
+
+		limits, err := parseParallelLimits(proj, parallelLimits)
+		if err != nil {
+			return result.FromError(err)
+		}
+
 		proj.Name = tokens.PackageName(name)
 		proj.Description = &description
 		proj.Template = nil
@@ -289,8 +322,10 @@ func newUpCmd() *cobra.Command {
 		opts.Engine = engine.UpdateOptions{
 			LocalPolicyPacks: engine.MakeLocalPolicyPacks(policyPackPaths, policyPackConfigPaths),
 			Parallel:         parallel,
+			ParallelLimits:   limits,
 			Debug:            debug,
 			Refresh:          refresh,
+			ContinueOnError:  continueOnError,
 		}
 
 		// TODO for the URL case:
@@ -391,6 +426,9 @@ func newUpCmd() *cobra.Command {
 			}
 
 			if len(args) > 0 {
+				if planFilePath != "" {
+					return result.FromError(errors.New("--plan may not be used when creating a stack from a template"))
+				}
 				return upTemplateNameOrURL(args[0], opts)
 			}
 
@@ -428,14 +466,19 @@ func newUpCmd() *cobra.Command {
 	cmd.PersistentFlags().StringVarP(
 		&message, "message", "m", "",
 		"Optional message to associate with the update operation")
+	cmd.PersistentFlags().StringVar(
+		&planFilePath, "plan", "",
+		"A plan file saved by `pulumi preview --save-plan`. The update stops with an error if it would do "+
+			"anything that the plan does not allow")
 
 	cmd.PersistentFlags().StringArrayVarP(
 		&targets, "target", "t", []string{},
 		"Specify a single resource URN to update. Other resources will not be updated."+
-			" Multiple resources can be specified using --target urn1 --target urn2")
+			" Multiple resources can be specified using --target urn1 --target urn2."+urnPatternHelp)
 	cmd.PersistentFlags().StringArrayVar(
 		&replaces, "replace", []string{},
-		"Specify resources to replace. Multiple resources can be specified using --replace urn1 --replace urn2")
+		"Specify resources to replace. Multiple resources can be specified using --replace urn1 --replace urn2."+
+			urnPatternHelp)
 	cmd.PersistentFlags().StringArrayVar(
 		&targetReplaces, "target-replace", []string{},
 		"Specify a single resource URN to replace. Other resources will not be updated."+
@@ -443,6 +486,16 @@ func newUpCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows updating of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to leave untouched. All other resources will be updated."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+urnPatternHelp)
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Allows excluding resources that depend on a resource specified in the --exclude list")
+	cmd.PersistentFlags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue updating resources that do not depend on a failed resource, rather than stopping at the first error")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().StringSliceVar(
@@ -457,6 +510,9 @@ func newUpCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().StringArrayVar(
+		&parallelLimits, "parallel-limit", []string{},
+		parallelLimitHelp)
 	cmd.PersistentFlags().BoolVarP(
 		&refresh, "refresh", "r", false,
 		"Refresh the state of the stack's resources before this update")
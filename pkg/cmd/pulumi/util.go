@@ -117,6 +117,16 @@ func currentBackend(opts display.Options) (backend.Backend, error) {
 	return httpstate.Login(commandContext(), cmdutil.Diag(), url, opts)
 }
 
+// parallelLimitHelp is the help for the --parallel-limit flag.
+const parallelLimitHelp = "Limit the number of concurrent operations on resources of a provider package or resource" +
+	" type, given as KEY=N, e.g. 'aws=4' or 'aws:s3/bucket:Bucket=2'. Overrides any 'parallelLimits' in Pulumi.yaml." +
+	" Multiple limits can be specified using --parallel-limit aws=4 --parallel-limit gcp=2"
+
+// urnPatternHelp is appended to the help of flags that accept a list of resource URNs.
+const urnPatternHelp = " A URN may also be a glob pattern such as '**::aws:s3/bucket:Bucket::logs-*', where '**'" +
+	" matches anything and '*' matches within a single '::'-separated part, or a resource type token such as" +
+	" 'aws:s3/bucket:Bucket'."
+
 // This is used to control the contents of the tracing header.
 var tracingHeader = os.Getenv("PULUMI_TRACING_HEADER")
 
@@ -424,6 +434,30 @@ func parseAndSaveConfigArray(s backend.Stack, configArray []string, path bool) e
 	return nil
 }
 
+// parseParallelLimits combines the parallelism limits in the given project with those given on the command line as
+// KEY=N pairs, where KEY is a provider package or resource type. Limits given on the command line take precedence.
+func parseParallelLimits(proj *workspace.Project, limitArray []string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for key, limit := range proj.ParallelLimits {
+		limits[key] = limit
+	}
+	for _, arg := range limitArray {
+		eq := strings.LastIndex(arg, "=")
+		if eq <= 0 {
+			return nil, errors.Errorf("parallel limit '%s' must be of the form KEY=N", arg)
+		}
+		limit, err := strconv.Atoi(arg[eq+1:])
+		if err != nil || limit < 1 {
+			return nil, errors.Errorf("parallel limit '%s' must be a positive integer", arg)
+		}
+		limits[arg[:eq]] = limit
+	}
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	return limits, nil
+}
+
 // readProjectForUpdate attempts to detect and read a Pulumi project for the current workspace. If
 // the project is successfully detected and read, it is returned along with the path to its
 // containing directory, which will be used as the root of the project's Pulumi program. If a
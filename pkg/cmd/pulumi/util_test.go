@@ -20,6 +20,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/backend"
 	pul_testing "github.com/pulumi/pulumi/sdk/v3/go/common/testing"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/gitutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -229,3 +230,20 @@ func TestReadingGitLabMetadata(t *testing.T) {
 		assertEnvValue(t, test, backend.VCSRepoKind, gitutil.GitLabHostName)
 	}
 }
+
+func TestParseParallelLimits(t *testing.T) {
+	proj := &workspace.Project{ParallelLimits: map[string]int{"aws": 4, "gcp": 2}}
+
+	limits, err := parseParallelLimits(&workspace.Project{}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, limits)
+
+	limits, err = parseParallelLimits(proj, []string{"aws=1", "aws:s3/bucket:Bucket=2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"aws": 1, "gcp": 2, "aws:s3/bucket:Bucket": 2}, limits)
+
+	for _, arg := range []string{"aws", "=2", "aws=0", "aws=two"} {
+		_, err = parseParallelLimits(proj, []string{arg})
+		assert.Error(t, err, arg)
+	}
+}
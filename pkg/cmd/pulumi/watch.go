@@ -41,6 +41,7 @@ func newWatchCmd() *cobra.Command {
 	var policyPackPaths []string
 	var policyPackConfigPaths []string
 	var parallel int
+	var parallelLimits []string
 	var refresh bool
 	var showConfig bool
 	var showReplacementSteps bool
@@ -98,6 +99,11 @@ func newWatchCmd() *cobra.Command {
 				return result.FromError(err)
 			}
 
+			limits, err := parseParallelLimits(proj, parallelLimits)
+			if err != nil {
+				return result.FromError(err)
+			}
+
 			m, err := getUpdateMetadata(message, root, execKind, "" /* execAgent */)
 			if err != nil {
 				return result.FromError(errors.Wrap(err, "gathering environment metadata"))
@@ -116,6 +122,7 @@ func newWatchCmd() *cobra.Command {
 			opts.Engine = engine.UpdateOptions{
 				LocalPolicyPacks:          engine.MakeLocalPolicyPacks(policyPackPaths, policyPackConfigPaths),
 				Parallel:                  parallel,
+				ParallelLimits:            limits,
 				Debug:                     debug,
 				Refresh:                   refresh,
 				UseLegacyDiff:             useLegacyDiff(),
@@ -177,6 +184,9 @@ func newWatchCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().StringArrayVar(
+		&parallelLimits, "parallel-limit", []string{},
+		parallelLimitHelp)
 	cmd.PersistentFlags().BoolVarP(
 		&refresh, "refresh", "r", false,
 		"Refresh the state of the stack's resources before each update")
@@ -122,6 +122,9 @@ type deploymentOptions struct {
 	// true if we're executing a refresh.
 	isRefresh bool
 
+	// operations left pending by an interrupted update that this refresh is to resolve, if any.
+	pendingOperations []resource.Operation
+
 	// true if we should trust the dependency graph reported by the language host. Not all Pulumi-supported languages
 	// correctly report their dependencies, in which case this will be false.
 	trustDependencies bool
@@ -161,6 +164,13 @@ func newDeployment(ctx *Context, info *deploymentContext, opts deploymentOptions
 
 	localPolicyPackPaths := ConvertLocalPolicyPacksToPaths(opts.LocalPolicyPacks)
 
+	// If this refresh is to resolve any operations left pending by an interrupted update, detach them from the base
+	// snapshot so that the deployment may proceed. The deployment executor resolves them as part of the refresh, and
+	// puts back any that it could not resolve so that they remain pending.
+	if opts.isRefresh && opts.ClearPendingOperations && target.Snapshot != nil {
+		opts.pendingOperations, target.Snapshot.PendingOperations = target.Snapshot.PendingOperations, nil
+	}
+
 	var depl *deploy.Deployment
 	if !opts.isImport {
 		depl, err = deploy.NewDeployment(
@@ -209,12 +219,12 @@ type runActions interface {
 
 // run executes the deployment. It is primarily responsible for handling cancellation.
 func (deployment *deployment) run(cancelCtx *Context, actions runActions, policyPacks map[string]string,
-	preview bool) (ResourceChanges, result.Result) {
+	preview bool) (*deploy.Plan, ResourceChanges, result.Result) {
 
 	// Change into the plugin context's working directory.
 	chdir, err := fsutil.Chdir(deployment.Plugctx.Pwd)
 	if err != nil {
-		return nil, result.FromError(err)
+		return nil, nil, result.FromError(err)
 	}
 	defer chdir()
 
@@ -238,6 +248,7 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 		opts := deploy.Options{
 			Events:                    actions,
 			Parallel:                  deployment.Options.Parallel,
+			ParallelLimits:            deployment.Options.ParallelLimits,
 			Refresh:                   deployment.Options.Refresh,
 			RefreshOnly:               deployment.Options.isRefresh,
 			RefreshTargets:            deployment.Options.RefreshTargets,
@@ -245,9 +256,14 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 			DestroyTargets:            deployment.Options.DestroyTargets,
 			UpdateTargets:             deployment.Options.UpdateTargets,
 			TargetDependents:          deployment.Options.TargetDependents,
+			ContinueOnError:           deployment.Options.ContinueOnError,
+			ExcludeTargets:            deployment.Options.ExcludeTargets,
+			ExcludeDependents:         deployment.Options.ExcludeDependents,
 			TrustDependencies:         deployment.Options.trustDependencies,
 			UseLegacyDiff:             deployment.Options.UseLegacyDiff,
 			DisableResourceReferences: deployment.Options.DisableResourceReferences,
+			Plan:                      deployment.Options.Plan,
+			PendingOperations:         deployment.Options.pendingOperations,
 		}
 		walkResult = deployment.Deployment.Execute(ctx, opts, preview)
 		close(done)
@@ -280,7 +296,7 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 	// Emit a summary event.
 	deployment.Options.Events.summaryEvent(preview, actions.MaybeCorrupt(), duration, changes, policyPacks)
 
-	return changes, res
+	return deployment.Deployment.Plan(), changes, res
 }
 
 func (deployment *deployment) Close() error {
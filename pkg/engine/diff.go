@@ -60,6 +60,10 @@ func printStepHeader(b io.StringWriter, step StepEventMetadata) {
 		// show a locked symbol, since we are either newly protecting this resource, or retaining protection.
 		extra = " 🔒"
 	}
+	if (step.Op == deploy.OpDelete || step.Op == deploy.OpDeleteReplaced) && old != nil && old.RetainOnDelete {
+		// show that the resource is only being removed from the stack, rather than actually being deleted.
+		extra += " [retain]"
+	}
 	writeString(b, fmt.Sprintf("%s: (%s)%s\n", string(step.Type), step.Op, extra))
 }
 
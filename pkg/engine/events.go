@@ -54,6 +54,8 @@ func NewEvent(typ EventType, payload interface{}) Event {
 		_, ok = payload.(SummaryEventPayload)
 	case ResourcePreEvent:
 		_, ok = payload.(ResourcePreEventPayload)
+	case ResourceWaitingEvent:
+		_, ok = payload.(ResourceWaitingEventPayload)
 	case ResourceOutputsEvent:
 		_, ok = payload.(ResourceOutputsEventPayload)
 	case ResourceOperationFailed:
@@ -80,6 +82,7 @@ const (
 	PreludeEvent            EventType = "prelude"
 	SummaryEvent            EventType = "summary"
 	ResourcePreEvent        EventType = "resource-pre"
+	ResourceWaitingEvent    EventType = "resource-waiting"
 	ResourceOutputsEvent    EventType = "resource-outputs"
 	ResourceOperationFailed EventType = "resource-operationfailed"
 	PolicyViolationEvent    EventType = "policy-violation"
@@ -152,6 +155,15 @@ type ResourcePreEventPayload struct {
 	Debug    bool
 }
 
+// ResourceWaitingEventPayload is the payload for an event with type `resource-waiting`, which is emitted when a step
+// is queued behind a parallelism limit.
+type ResourceWaitingEventPayload struct {
+	Metadata StepEventMetadata
+	Limit    string // the provider package or resource type whose parallelism limit the step is waiting on.
+	Planning bool
+	Debug    bool
+}
+
 // StepEventMetadata contains the metadata associated with a step the engine is performing.
 type StepEventMetadata struct {
 	Op           deploy.StepOp                  // the operation performed by this step.
@@ -185,6 +197,8 @@ type StepEventStateMetadata struct {
 	Parent resource.URN
 	// true to "protect" this resource (protected resources cannot be deleted).
 	Protect bool
+	// true if deleting this resource only removes it from the stack's state, without deleting it from its provider.
+	RetainOnDelete bool
 	// the resource's input properties (as specified by the program). Note: because this will cross
 	// over rpc boundaries it will be slightly different than the Inputs found in resource_state.
 	// Specifically, secrets will have been filtered out, and large values (like assets) will be
@@ -329,18 +343,19 @@ func makeStepEventStateMetadata(state *resource.State, debug bool) *StepEventSta
 	}
 
 	return &StepEventStateMetadata{
-		State:      state,
-		Type:       state.Type,
-		URN:        state.URN,
-		Custom:     state.Custom,
-		Delete:     state.Delete,
-		ID:         state.ID,
-		Parent:     state.Parent,
-		Protect:    state.Protect,
-		Inputs:     filterPropertyMap(state.Inputs, debug),
-		Outputs:    filterPropertyMap(state.Outputs, debug),
-		Provider:   state.Provider,
-		InitErrors: state.InitErrors,
+		State:          state,
+		Type:           state.Type,
+		URN:            state.URN,
+		Custom:         state.Custom,
+		Delete:         state.Delete,
+		ID:             state.ID,
+		Parent:         state.Parent,
+		Protect:        state.Protect,
+		RetainOnDelete: state.RetainOnDelete,
+		Inputs:         filterPropertyMap(state.Inputs, debug),
+		Outputs:        filterPropertyMap(state.Outputs, debug),
+		Provider:       state.Provider,
+		InitErrors:     state.InitErrors,
 	}
 }
 
@@ -500,6 +515,19 @@ func (e *eventEmitter) resourcePreEvent(
 	})
 }
 
+func (e *eventEmitter) resourceWaitingEvent(
+	step deploy.Step, limit string, planning bool, debug bool) {
+
+	contract.Requiref(e != nil, "e", "!= nil")
+
+	e.ch <- NewEvent(ResourceWaitingEvent, ResourceWaitingEventPayload{
+		Metadata: makeStepEventMetadata(step.Op(), step, debug),
+		Limit:    limit,
+		Planning: planning,
+		Debug:    debug,
+	})
+}
+
 func (e *eventEmitter) preludeEvent(isPreview bool, cfg config.Map) {
 	contract.Requiref(e != nil, "e", "!= nil")
 
@@ -103,8 +103,11 @@ func (entries JournalEntries) Snap(base *deploy.Snapshot) *deploy.Snapshot {
 		}
 	}
 
-	// Append any pending operations.
+	// Append any pending operations, including those left in the base snapshot.
 	var operations []resource.Operation
+	if base != nil {
+		operations = append(operations, base.PendingOperations...)
+	}
 	for _, op := range ops {
 		if !doneOps[op.Resource] {
 			operations = append(operations, op)
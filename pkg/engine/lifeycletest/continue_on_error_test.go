@@ -0,0 +1,216 @@
+package lifecycletest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// Test that an update with ContinueOnError keeps creating independent resources after a failure, and skips the
+// resources that depend on the failed one.
+func TestContinueOnErrorUpdate(t *testing.T) {
+	var lock sync.Mutex
+	created := map[resource.URN]bool{}
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, inputs resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					lock.Lock()
+					defer lock.Unlock()
+					created[urn] = true
+					if urn.Name() == "resA" {
+						return "", nil, resource.StatusOK, errors.New("resA failed")
+					}
+					return resource.ID(urn.Name() + "-id"), resource.PropertyMap{}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	p := &TestPlan{}
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.Error(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{resA},
+		})
+		assert.Error(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		assert.NoError(t, err)
+		return nil
+	})
+
+	p.Options = UpdateOptions{
+		Host:            deploytest.NewPluginHost(nil, nil, program, loaders...),
+		ContinueOnError: true,
+	}
+	p.Steps = []TestStep{{Op: Update, SkipPreview: true, ExpectFailure: true}}
+	snap := p.Run(t, nil)
+
+	assert.True(t, created[resA])
+	assert.False(t, created[p.NewURN("pkgA:m:typA", "resB", "")])
+	assert.True(t, created[p.NewURN("pkgA:m:typA", "resC", "")])
+
+	// Only the default provider and resC should be in the snapshot.
+	assert.Len(t, snap.Resources, 2)
+	assert.Equal(t, p.NewURN("pkgA:m:typA", "resC", ""), snap.Resources[1].URN)
+}
+
+// Test that a destroy with ContinueOnError deletes what it can, and leaves behind the dependencies of a resource
+// whose delete failed.
+func TestContinueOnErrorDestroy(t *testing.T) {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, inputs resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					return resource.ID(urn.Name() + "-id"), resource.PropertyMap{}, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64) (resource.Status, error) {
+
+					if urn.Name() == "resB" {
+						return resource.StatusOK, errors.New("resB failed")
+					}
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	p := &TestPlan{}
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.NoError(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{resA},
+		})
+		assert.NoError(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		assert.NoError(t, err)
+		return nil
+	})
+
+	p.Options = UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, program, loaders...)}
+	p.Steps = []TestStep{{Op: Update, SkipPreview: true}}
+	snap := p.Run(t, nil)
+	assert.Len(t, snap.Resources, 4)
+
+	p.Options.ContinueOnError = true
+	p.Steps = []TestStep{{Op: Destroy, SkipPreview: true, ExpectFailure: true}}
+	snap = p.Run(t, snap)
+
+	// resC is deleted, resB failed to delete and resA is still in use by resB.
+	var urns []resource.URN
+	for _, r := range snap.Resources {
+		urns = append(urns, r.URN)
+	}
+	assert.Contains(t, urns, resA)
+	assert.Contains(t, urns, p.NewURN("pkgA:m:typA", "resB", ""))
+	assert.NotContains(t, urns, p.NewURN("pkgA:m:typA", "resC", ""))
+}
+
+// Test that when the program fails during an update with ContinueOnError, resources that the program did not register
+// are not deleted.
+func TestContinueOnErrorProgramFailure(t *testing.T) {
+	var lock sync.Mutex
+	var deleted []string
+	failA := false
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DiffF: func(urn resource.URN, id resource.ID,
+					olds, news resource.PropertyMap, ignoreChanges []string) (plugin.DiffResult, error) {
+
+					if !olds.DeepEquals(news) {
+						return plugin.DiffResult{Changes: plugin.DiffSome}, nil
+					}
+					return plugin.DiffResult{}, nil
+				},
+				UpdateF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap, timeout float64,
+					ignoreChanges []string, preview bool) (resource.PropertyMap, resource.Status, error) {
+
+					if failA && urn.Name() == "resA" {
+						return nil, resource.StatusOK, errors.New("resA failed")
+					}
+					return news, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64) (resource.Status, error) {
+
+					lock.Lock()
+					defer lock.Unlock()
+					deleted = append(deleted, string(urn.Name()))
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	p := &TestPlan{}
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+
+	// The first update creates resA, resB which depends on resA, and the unrelated resC.
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{resA},
+		})
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		assert.NoError(t, err)
+		return nil
+	})
+	p.Options = UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, program, loaders...)}
+	p.Steps = []TestStep{{Op: Update, SkipPreview: true}}
+	snap := p.Run(t, nil)
+	assert.Len(t, snap.Resources, 4)
+
+	// The second update fails to update resA, and the program then fails without registering resB or resC.
+	failA = true
+	program = deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: resource.PropertyMap{"foo": resource.NewStringProperty("bar")},
+		})
+		assert.Error(t, err)
+		return err
+	})
+	p.Options = UpdateOptions{
+		Host:            deploytest.NewPluginHost(nil, nil, program, loaders...),
+		ContinueOnError: true,
+	}
+	p.Steps = []TestStep{{Op: Update, SkipPreview: true, ExpectFailure: true}}
+	snap = p.Run(t, snap)
+
+	// Nothing is deleted: the program may have failed to register resB and resC only because it stopped early.
+	assert.Empty(t, deleted)
+	var urns []resource.URN
+	for _, r := range snap.Resources {
+		urns = append(urns, r.URN)
+	}
+	assert.Contains(t, urns, resA)
+	assert.Contains(t, urns, p.NewURN("pkgA:m:typA", "resB", ""))
+	assert.Contains(t, urns, p.NewURN("pkgA:m:typA", "resC", ""))
+}
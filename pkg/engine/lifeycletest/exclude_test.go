@@ -0,0 +1,249 @@
+package lifecycletest
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newExcludeTestPlan returns a plan whose program registers resA, resB (which depends on resA), and resC. Each
+// resource's "value" property is taken from the value pointed to by the given string.
+func newExcludeTestPlan(t *testing.T, value *string) *TestPlan {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	p := &TestPlan{}
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		inputs := resource.PropertyMap{"value": resource.NewStringProperty(*value)}
+
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		assert.NoError(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Inputs:       inputs,
+			Dependencies: []resource.URN{resA},
+		})
+		assert.NoError(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		assert.NoError(t, err)
+
+		return nil
+	})
+
+	p.Options.Host = deploytest.NewPluginHost(nil, nil, program, loaders...)
+	p.Steps = []TestStep{{Op: Update}}
+	return p
+}
+
+// collectOps returns the URNs of the custom resources operated upon by the given journal entries, keyed by operation.
+func collectOps(entries JournalEntries) map[deploy.StepOp]map[resource.URN]bool {
+	ops := make(map[deploy.StepOp]map[resource.URN]bool)
+	for _, entry := range entries {
+		if entry.Step.URN().Type() != "pkgA:m:typA" {
+			continue
+		}
+		if ops[entry.Step.Op()] == nil {
+			ops[entry.Step.Op()] = make(map[resource.URN]bool)
+		}
+		ops[entry.Step.Op()][entry.Step.URN()] = true
+	}
+	return ops
+}
+
+func TestExcludeUpdate(t *testing.T) {
+	for _, excludeDependents := range []bool{false, true} {
+		value := "foo"
+		p := newExcludeTestPlan(t, &value)
+		snap := p.Run(t, nil)
+
+		resA := p.NewURN("pkgA:m:typA", "resA", "")
+		resB := p.NewURN("pkgA:m:typA", "resB", "")
+		resC := p.NewURN("pkgA:m:typA", "resC", "")
+
+		value = "bar"
+		p.Options.ExcludeTargets = []resource.URN{resA}
+		p.Options.ExcludeDependents = excludeDependents
+		p.Steps = []TestStep{{
+			Op: Update,
+			Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+				evts []Event, res result.Result) result.Result {
+
+				assert.Nil(t, res)
+
+				ops := collectOps(entries)
+				if excludeDependents {
+					assert.Equal(t, map[resource.URN]bool{resA: true, resB: true}, ops[deploy.OpSame])
+					assert.Equal(t, map[resource.URN]bool{resC: true}, ops[deploy.OpUpdate])
+				} else {
+					assert.Equal(t, map[resource.URN]bool{resA: true}, ops[deploy.OpSame])
+					assert.Equal(t, map[resource.URN]bool{resB: true, resC: true}, ops[deploy.OpUpdate])
+				}
+				return res
+			},
+		}}
+		p.Run(t, snap)
+	}
+}
+
+func TestExcludeInvalidTarget(t *testing.T) {
+	value := "foo"
+	p := newExcludeTestPlan(t, &value)
+	snap := p.Run(t, nil)
+
+	p.Options.ExcludeTargets = []resource.URN{"foo"}
+	p.Steps = []TestStep{{
+		Op:            Update,
+		ExpectFailure: true,
+	}}
+	p.Run(t, snap)
+}
+
+func TestExcludeUntargetedCreateReferencedByUpdate(t *testing.T) {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	program1 := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.NoError(t, err)
+		return nil
+	})
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, program1, loaders...)},
+		Steps:   []TestStep{{Op: Update}},
+	}
+	snap := p.Run(t, nil)
+
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+	resB := p.NewURN("pkgA:m:typA", "resB", "")
+
+	// Now create resB and reference it from resA. Excluding resB means that resA depends on a resource that will
+	// not be created.
+	program2 := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resB", true)
+		assert.NoError(t, err)
+
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{resB},
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+
+	p.Options.Host = deploytest.NewPluginHost(nil, nil, program2, loaders...)
+	p.Options.ExcludeTargets = []resource.URN{resB}
+	p.Steps = []TestStep{{
+		Op:            Update,
+		ExpectFailure: true,
+	}}
+	p.Run(t, snap)
+
+	// With --exclude-dependents, resA is excluded as well and the update succeeds without creating resB.
+	p.Options.ExcludeDependents = true
+	p.Steps = []TestStep{{
+		Op: Update,
+		Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+			evts []Event, res result.Result) result.Result {
+
+			assert.Nil(t, res)
+
+			ops := collectOps(entries)
+			assert.True(t, ops[deploy.OpSame][resA])
+			assert.Empty(t, ops[deploy.OpCreate])
+			assert.Empty(t, ops[deploy.OpUpdate])
+			return res
+		},
+	}}
+	p.Run(t, snap)
+}
+
+func TestExcludeDestroy(t *testing.T) {
+	value := "foo"
+	p := newExcludeTestPlan(t, &value)
+	snap := p.Run(t, nil)
+
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+	resB := p.NewURN("pkgA:m:typA", "resB", "")
+	resC := p.NewURN("pkgA:m:typA", "resC", "")
+
+	// Excluding resA leaves it alone and destroys everything else.
+	p.Options.ExcludeTargets = []resource.URN{resA}
+	p.Steps = []TestStep{{
+		Op: Destroy,
+		Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+			evts []Event, res result.Result) result.Result {
+
+			assert.Nil(t, res)
+			assert.Equal(t, map[resource.URN]bool{resB: true, resC: true}, collectOps(entries)[deploy.OpDelete])
+			return res
+		},
+	}}
+	p.Run(t, CloneSnapshot(t, snap))
+
+	// Excluding resB would delete resA out from under it, which is an error...
+	p.Options.ExcludeTargets = []resource.URN{resB}
+	p.Steps = []TestStep{{
+		Op:            Destroy,
+		ExpectFailure: true,
+	}}
+	p.Run(t, CloneSnapshot(t, snap))
+
+	// ...unless --exclude-dependents is passed, in which case resA is kept as well.
+	p.Options.ExcludeDependents = true
+	p.Steps = []TestStep{{
+		Op: Destroy,
+		Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+			evts []Event, res result.Result) result.Result {
+
+			assert.Nil(t, res)
+			assert.Equal(t, map[resource.URN]bool{resC: true}, collectOps(entries)[deploy.OpDelete])
+			return res
+		},
+	}}
+	p.Run(t, CloneSnapshot(t, snap))
+}
+
+func TestExcludeRefresh(t *testing.T) {
+	value := "foo"
+	p := newExcludeTestPlan(t, &value)
+	snap := p.Run(t, nil)
+
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+	resC := p.NewURN("pkgA:m:typA", "resC", "")
+
+	p.Options.ExcludeTargets = []resource.URN{resA}
+	p.Options.ExcludeDependents = true
+	p.Steps = []TestStep{{
+		Op: Refresh,
+		Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+			evts []Event, res result.Result) result.Result {
+
+			assert.Nil(t, res)
+			assert.Equal(t, map[resource.URN]bool{resC: true}, collectOps(entries)[deploy.OpRefresh])
+			return res
+		},
+	}}
+	p.Run(t, snap)
+}
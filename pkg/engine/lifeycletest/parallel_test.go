@@ -0,0 +1,179 @@
+package lifecycletest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestParallelLimits(t *testing.T) {
+	const resourceCount = 8
+
+	// Track the number of creates that are in flight for the package and for each resource type.
+	var lock sync.Mutex
+	inFlight, maxInFlight := map[string]int{}, map[string]int{}
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, inputs resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					keys := []string{"pkgA", string(urn.Type())}
+
+					lock.Lock()
+					for _, k := range keys {
+						inFlight[k]++
+						if inFlight[k] > maxInFlight[k] {
+							maxInFlight[k] = inFlight[k]
+						}
+					}
+					lock.Unlock()
+
+					time.Sleep(10 * time.Millisecond)
+
+					lock.Lock()
+					for _, k := range keys {
+						inFlight[k]--
+					}
+					lock.Unlock()
+
+					return resource.ID(urn.Name()), resource.PropertyMap{}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		errors := make([]error, 2*resourceCount)
+		var resources sync.WaitGroup
+		resources.Add(2 * resourceCount)
+		for i := 0; i < resourceCount; i++ {
+			for j, typ := range []tokens.Type{"pkgA:m:typA", "pkgA:m:typB"} {
+				go func(idx int, typ tokens.Type) {
+					_, _, _, errors[idx] = monitor.RegisterResource(typ, fmt.Sprintf("res%d", idx), true)
+					resources.Done()
+				}(2*i+j, typ)
+			}
+		}
+		resources.Wait()
+		for _, err := range errors {
+			assert.NoError(t, err)
+		}
+		return nil
+	})
+
+	p := &TestPlan{
+		Options: UpdateOptions{
+			Parallel:       2 * resourceCount,
+			ParallelLimits: map[string]int{"pkgA": 3, "pkgA:m:typA": 1},
+			Host:           deploytest.NewPluginHost(nil, nil, program, loaders...),
+		},
+		Steps: []TestStep{{
+			Op: Update,
+			Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+				evts []Event, res result.Result) result.Result {
+
+				assert.Nil(t, res)
+
+				waiting := 0
+				for _, e := range evts {
+					if e.Type == ResourceWaitingEvent {
+						waiting++
+					}
+				}
+				assert.NotZero(t, waiting)
+				return res
+			},
+		}},
+	}
+	p.Run(t, nil)
+
+	// Only one typA resource may be created at a time, and no more than three pkgA resources in total.
+	assert.Equal(t, 1, maxInFlight["pkgA:m:typA"])
+	assert.Equal(t, 3, maxInFlight["pkgA"])
+}
+
+// Test that steps that are waiting on a parallelism limit do not hold back steps for other providers.
+func TestParallelLimitsDoNotBlockOtherProviders(t *testing.T) {
+	startedA, createdB := make(chan bool), make(chan bool)
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, inputs resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					// The first pkgA resource holds on to the pkgA limit until the pkgB resource has been created.
+					if urn.Name() == "resA1" {
+						close(startedA)
+						select {
+						case <-createdB:
+						case <-time.After(10 * time.Second):
+							return "", nil, resource.StatusOK, errors.New("timed out waiting for resB")
+						}
+					}
+					return resource.ID(urn.Name()), resource.PropertyMap{}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+		deploytest.NewProviderLoader("pkgB", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, inputs resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					close(createdB)
+					return resource.ID(urn.Name()), resource.PropertyMap{}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		var resources sync.WaitGroup
+		register := func(typ tokens.Type, name string) {
+			resources.Add(1)
+			go func() {
+				defer resources.Done()
+				_, _, _, err := monitor.RegisterResource(typ, name, true)
+				assert.NoError(t, err)
+			}()
+		}
+
+		// With two workers, resA1 occupies one of them and resA2 must wait on the pkgA limit. That must not tie up
+		// the other worker, which is needed to create resB.
+		register("pkgA:m:typA", "resA1")
+		<-startedA
+		register("pkgA:m:typA", "resA2")
+		time.Sleep(100 * time.Millisecond)
+		register("pkgB:m:typB", "resB")
+
+		resources.Wait()
+		return nil
+	})
+
+	p := &TestPlan{
+		Options: UpdateOptions{
+			Parallel:       2,
+			ParallelLimits: map[string]int{"pkgA": 1},
+			Host:           deploytest.NewPluginHost(nil, nil, program, loaders...),
+		},
+		Steps: []TestStep{{Op: Update, SkipPreview: true}},
+	}
+	snap := p.Run(t, nil)
+	assert.Len(t, snap.Resources, 5)
+}
@@ -1446,6 +1446,302 @@ func TestCustomTimeouts(t *testing.T) {
 	assert.Equal(t, snap.Resources[1].CustomTimeouts.Delete, float64(60))
 }
 
+func TestRetainOnDelete(t *testing.T) {
+	deleted := false
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64) (resource.Status, error) {
+					deleted = true
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	createResource := true
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if createResource {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+				RetainOnDelete: true,
+			})
+			assert.NoError(t, err)
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 2)
+	assert.True(t, snap.Resources[1].RetainOnDelete)
+
+	// Removing the resource from the program removes it from the snapshot without deleting it.
+	createResource = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, entries JournalEntries, _ []Event, res result.Result) result.Result {
+			for _, entry := range entries {
+				if entry.Step.URN().Name() == "resA" {
+					assert.Equal(t, deploy.OpDelete, entry.Step.Op())
+				}
+			}
+			return res
+		})
+	assert.Nil(t, res)
+	assert.False(t, deleted)
+	assert.Empty(t, snap.Resources)
+}
+
+func TestReplaceOnChanges(t *testing.T) {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	inputs := resource.PropertyMap{"foo": resource.NewStringProperty("bar"), "baz": resource.NewStringProperty("qux")}
+	var deleteBeforeReplace *bool
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs:              inputs,
+			ReplaceOnChanges:    []string{"foo"},
+			DeleteBeforeReplace: deleteBeforeReplace,
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 2)
+	assert.Equal(t, []string{"foo"}, snap.Resources[1].ReplaceOnChanges)
+
+	// collectOps returns the operations performed on resA by an update.
+	collectOps := func(ops *[]deploy.StepOp) ValidateFunc {
+		return func(_ workspace.Project, _ deploy.Target, entries JournalEntries, _ []Event,
+			res result.Result) result.Result {
+
+			for _, entry := range entries {
+				if entry.Kind == JournalEntrySuccess && entry.Step.URN().Name() == "resA" {
+					*ops = append(*ops, entry.Step.Op())
+				}
+			}
+			return res
+		}
+	}
+
+	// Changing a property that is not in replaceOnChanges updates the resource.
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("bar"), "baz": resource.NewStringProperty("zed")}
+	var ops []deploy.StepOp
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, collectOps(&ops))
+	assert.Nil(t, res)
+	assert.Equal(t, []deploy.StepOp{deploy.OpUpdate}, ops)
+
+	// Changing a property that is in replaceOnChanges replaces the resource, creating the replacement first.
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("baz"), "baz": resource.NewStringProperty("zed")}
+	ops = nil
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, collectOps(&ops))
+	assert.Nil(t, res)
+	assert.Equal(t, []deploy.StepOp{deploy.OpCreateReplacement, deploy.OpReplace, deploy.OpDeleteReplaced}, ops)
+
+	// The replacement honors deleteBeforeReplace.
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("qux"), "baz": resource.NewStringProperty("zed")}
+	deleteBeforeReplace = new(bool)
+	*deleteBeforeReplace = true
+	ops = nil
+	_, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, collectOps(&ops))
+	assert.Nil(t, res)
+	assert.Equal(t, []deploy.StepOp{deploy.OpDeleteReplaced, deploy.OpReplace, deploy.OpCreateReplacement}, ops)
+}
+
+func TestDeletedWith(t *testing.T) {
+	var deleted []string
+	var mu sync.Mutex
+	failA := false
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64) (resource.Status, error) {
+					if failA && urn.Name() == "resA" {
+						return resource.StatusOK, errors.New("could not delete resA")
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					deleted = append(deleted, string(urn.Name()))
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	// resB is deleted with resA, and resC depends on resB.
+	createA, createBC := true, true
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if !createA {
+			return nil
+		}
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.NoError(t, err)
+		if !createBC {
+			return nil
+		}
+		urnB, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{urnA},
+			DeletedWith:  urnA,
+		})
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{urnB},
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 4)
+	assert.Equal(t, snap.Resources[1].URN, snap.Resources[2].DeletedWith)
+
+	// Deleting resB without resA asks its provider to delete it.
+	createBC = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Equal(t, []string{"resC", "resB"}, deleted)
+	assert.Len(t, snap.Resources, 2)
+
+	createBC = true
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 4)
+
+	// Deleting resB along with resA does not, and resB is only removed from the snapshot once resA is deleted: if
+	// resA fails to delete, resB remains.
+	createA, failA, deleted = false, true, nil
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, nil)
+	assert.NotNil(t, res)
+	assert.Equal(t, []string{"resC"}, deleted)
+	assert.Len(t, snap.Resources, 3)
+
+	failA, deleted = false, nil
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, entries JournalEntries, _ []Event, res result.Result) result.Result {
+			var names []string
+			for _, entry := range entries {
+				if entry.Kind == JournalEntrySuccess && entry.Step.Op() == deploy.OpDelete {
+					names = append(names, string(entry.Step.URN().Name()))
+				}
+			}
+			// resB is removed before the default provider that it depends on is deleted.
+			assert.Equal(t, []string{"resA", "resB", "default"}, names)
+			return res
+		})
+	assert.Nil(t, res)
+	assert.Equal(t, []string{"resA"}, deleted)
+	assert.Empty(t, snap.Resources)
+}
+
+// Test that replacing a resource does not ask the provider to delete the replaced resources that are deleted with it,
+// whether the replacement deletes the old resources before or after creating the new ones.
+func TestDeletedWithReplace(t *testing.T) {
+	for _, deleteBeforeReplace := range []bool{true, false} {
+		deleteBeforeReplace := deleteBeforeReplace
+		t.Run(fmt.Sprintf("deleteBeforeReplace=%v", deleteBeforeReplace), func(t *testing.T) {
+			var deleted []string
+			var mu sync.Mutex
+			loaders := []*deploytest.ProviderLoader{
+				deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+					return &deploytest.Provider{
+						DiffF: func(urn resource.URN, id resource.ID,
+							olds, news resource.PropertyMap, ignoreChanges []string) (plugin.DiffResult, error) {
+
+							if !olds["A"].DeepEquals(news["A"]) {
+								return plugin.DiffResult{
+									ReplaceKeys:         []resource.PropertyKey{"A"},
+									DeleteBeforeReplace: deleteBeforeReplace,
+								}, nil
+							}
+							return plugin.DiffResult{}, nil
+						},
+						DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+							timeout float64) (resource.Status, error) {
+
+							mu.Lock()
+							defer mu.Unlock()
+							deleted = append(deleted, string(urn.Name()))
+							return resource.StatusOK, nil
+						},
+					}, nil
+				}),
+			}
+
+			// resB's input A comes from resA, so replacing resA replaces resB too.
+			inputs := resource.PropertyMap{"A": resource.NewStringProperty("foo")}
+			program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+				urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+					Inputs: inputs,
+				})
+				assert.NoError(t, err)
+				_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+					Inputs:       inputs,
+					Dependencies: []resource.URN{urnA},
+					PropertyDeps: map[resource.PropertyKey][]resource.URN{"A": {urnA}},
+					DeletedWith:  urnA,
+				})
+				assert.NoError(t, err)
+				return nil
+			})
+
+			p := &TestPlan{
+				Options: UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, program, loaders...)},
+			}
+			project := p.GetProject()
+			snap, res := TestOp(Update).Run(project, p.GetTarget(nil), p.Options, false, p.BackendClient, nil)
+			assert.Nil(t, res)
+			assert.Len(t, snap.Resources, 3)
+
+			inputs = resource.PropertyMap{"A": resource.NewStringProperty("bar")}
+			snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient,
+				func(_ workspace.Project, _ deploy.Target, entries JournalEntries, _ []Event,
+					res result.Result) result.Result {
+
+					replaced := map[string]bool{}
+					for _, entry := range entries {
+						if entry.Kind == JournalEntrySuccess && entry.Step.Op() == deploy.OpDeleteReplaced {
+							replaced[string(entry.Step.URN().Name())] = true
+						}
+					}
+					assert.Equal(t, map[string]bool{"resA": true, "resB": true}, replaced)
+					return res
+				})
+			assert.Nil(t, res)
+			assert.Equal(t, []string{"resA"}, deleted)
+			assert.Len(t, snap.Resources, 3)
+			for _, r := range snap.Resources[1:] {
+				assert.Equal(t, "bar", r.Inputs["A"].StringValue())
+			}
+		})
+	}
+}
+
 func TestProviderDiffMissingOldOutputs(t *testing.T) {
 	loaders := []*deploytest.ProviderLoader{
 		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
@@ -2040,3 +2336,113 @@ func TestComponentOutputs(t *testing.T) {
 	}
 	p.Run(t, nil)
 }
+
+func TestPlannedUpdate(t *testing.T) {
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	inputs := resource.PropertyMap{"foo": resource.NewStringProperty("bar")}
+	createB := false
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		assert.NoError(t, err)
+
+		if createB {
+			_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true)
+			assert.NoError(t, err)
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 2)
+	urnA := snap.Resources[1].URN
+
+	// preview runs a preview and returns the plan it recorded.
+	preview := func(snap *deploy.Snapshot) *deploy.Plan {
+		var plan *deploy.Plan
+		op := TestOp(func(info UpdateInfo, ctx *Context, opts UpdateOptions,
+			dryRun bool) (*deploy.Plan, ResourceChanges, result.Result) {
+
+			p, changes, res := Update(info, ctx, opts, dryRun)
+			plan = p
+			return p, changes, res
+		})
+		_, res := op.Run(project, p.GetTarget(snap), p.Options, true, p.BackendClient, nil)
+		assert.Nil(t, res)
+		return plan
+	}
+
+	// violation returns a validator that checks that the update failed because it violated its plan.
+	violation := func(message string) ValidateFunc {
+		return func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event,
+			res result.Result) result.Result {
+
+			assert.NotNil(t, res)
+			found := false
+			for _, e := range events {
+				if e.Type == DiagEvent {
+					p := e.Payload().(DiagEventPayload)
+					if p.Severity == diag.Error && strings.Contains(p.Message, message) {
+						found = true
+					}
+				}
+			}
+			assert.True(t, found, "expected an error containing %q", message)
+			return res
+		}
+	}
+
+	// Preview a change to resA and check the recorded plan.
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("baz")}
+	plan := preview(snap)
+	assert.Equal(t, []deploy.StepOp{deploy.OpUpdate}, plan.ResourcePlans[urnA].Ops)
+	assert.Equal(t, inputs, plan.ResourcePlans[urnA].Inputs)
+
+	// An update that follows the plan succeeds.
+	opts := p.Options
+	opts.Plan = plan
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), opts, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Equal(t, inputs, snap.Resources[1].Inputs)
+
+	// An update whose inputs differ from the plan fails without changing resA.
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("qux")}
+	opts.Plan = preview(snap)
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("zed")}
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), opts, false, p.BackendClient,
+		violation("input foo has value zed, but the plan expected qux"))
+	assert.NotNil(t, res)
+	assert.Equal(t, resource.NewStringProperty("baz"), snap.Resources[1].Inputs["foo"])
+
+	// An update that registers a resource that is not in the plan fails.
+	inputs = resource.PropertyMap{"foo": resource.NewStringProperty("baz")}
+	opts.Plan = preview(snap)
+	createB = true
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), opts, false, p.BackendClient,
+		violation("the plan does not contain this resource"))
+	assert.NotNil(t, res)
+	assert.Len(t, snap.Resources, 2)
+
+	// An update that deletes a resource that the plan expected to keep fails.
+	opts.Plan = preview(snap)
+	snap, res = TestOp(Update).Run(project, p.GetTarget(snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snap.Resources, 3)
+	createB = false
+	_, res = TestOp(Update).Run(project, p.GetTarget(snap), opts, false, p.BackendClient,
+		violation("operation delete is not one of the planned operations"))
+	assert.NotNil(t, res)
+}
@@ -2,6 +2,7 @@ package lifecycletest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -859,3 +860,247 @@ func TestRefreshUpdateWithDeletedResource(t *testing.T) {
 	snap := p.Run(t, old)
 	assert.Equal(t, 0, len(snap.Resources))
 }
+
+// Tests that a refresh can resolve the operations left pending by an interrupted update.
+func TestRefreshClearPendingOperations(t *testing.T) {
+	p := &TestPlan{}
+
+	const resType = "pkgA:m:typA"
+	provURN := p.NewProviderURN("pkgA", "default", "")
+	provRef, err := providers.NewReference(provURN, "0")
+	assert.NoError(t, err)
+
+	newResource := func(name string, id resource.ID, inputs resource.PropertyMap) *resource.State {
+		if inputs == nil {
+			inputs = resource.PropertyMap{}
+		}
+		return &resource.State{
+			Type:     resType,
+			URN:      p.NewURN(resType, name, ""),
+			Custom:   true,
+			ID:       id,
+			Inputs:   inputs,
+			Outputs:  resource.PropertyMap{},
+			Provider: provRef.String(),
+		}
+	}
+	named := func(name string) resource.PropertyMap {
+		return resource.PropertyMap{"name": resource.NewStringProperty(name)}
+	}
+
+	resA, resB := newResource("resA", "a", nil), newResource("resB", "b", nil)
+	old := &deploy.Snapshot{
+		Resources: []*resource.State{
+			{
+				Type:    provURN.Type(),
+				URN:     provURN,
+				Custom:  true,
+				ID:      "0",
+				Inputs:  resource.PropertyMap{},
+				Outputs: resource.PropertyMap{},
+			},
+			resA,
+			resB,
+		},
+		PendingOperations: []resource.Operation{
+			resource.NewOperation(newResource("resA", "a", nil), resource.OperationTypeUpdating),
+			resource.NewOperation(resB, resource.OperationTypeDeleting),
+			resource.NewOperation(newResource("resC", "", named("c")), resource.OperationTypeCreating),
+			resource.NewOperation(newResource("resD", "", nil), resource.OperationTypeCreating),
+			resource.NewOperation(newResource("resE", "e", nil), resource.OperationTypeCreating),
+		},
+	}
+
+	// Only the resources with IDs "a" and "c" still exist.
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				ReadF: func(urn resource.URN, id resource.ID,
+					inputs, state resource.PropertyMap) (plugin.ReadResult, resource.Status, error) {
+
+					if id != "a" && id != "c" {
+						return plugin.ReadResult{}, resource.StatusOK, nil
+					}
+					return plugin.ReadResult{Outputs: resource.PropertyMap{}}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	op := TestOp(Refresh)
+	options := UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, nil, loaders...)}
+	project := p.GetProject()
+
+	// Without ClearPendingOperations, the refresh refuses to proceed.
+	_, res := op.Run(project, p.GetTarget(old), options, false, nil, nil)
+	assertIsErrorOrBailResult(t, res)
+	assert.EqualError(t, res.Error(), deploy.PlanPendingOperationsError{}.Error())
+
+	options.ClearPendingOperations = true
+	snap, res := op.Run(project, p.GetTarget(old), options, false, nil, nil)
+	assert.Nil(t, res)
+
+	// resD has neither an ID nor a name, so its creation cannot be resolved and remains pending. resE's recorded ID
+	// does not exist, so its creation is discarded.
+	assert.Len(t, snap.PendingOperations, 1)
+	assert.Equal(t, p.NewURN(resType, "resD", ""), snap.PendingOperations[0].Resource.URN)
+	ids := make(map[resource.URN]resource.ID)
+	for _, r := range snap.Resources {
+		ids[r.URN] = r.ID
+	}
+	assert.Equal(t, map[resource.URN]resource.ID{
+		provURN:                       "0",
+		p.NewURN(resType, "resA", ""): "a",
+		p.NewURN(resType, "resC", ""): "c",
+	}, ids)
+}
+
+// Tests that a refresh does not discard the interrupted creation of a resource that it fails to find by its name, as
+// its provider may have assigned it an ID that is not its name.
+func TestRefreshClearPendingOperationsNameIsNotID(t *testing.T) {
+	p := &TestPlan{}
+
+	const resType = "pkgA:m:typA"
+	provURN := p.NewProviderURN("pkgA", "default", "")
+	provRef, err := providers.NewReference(provURN, "0")
+	assert.NoError(t, err)
+
+	resURN := p.NewURN(resType, "resA", "")
+	old := &deploy.Snapshot{
+		Resources: []*resource.State{{
+			Type:    provURN.Type(),
+			URN:     provURN,
+			Custom:  true,
+			ID:      "0",
+			Inputs:  resource.PropertyMap{},
+			Outputs: resource.PropertyMap{},
+		}},
+		PendingOperations: []resource.Operation{
+			resource.NewOperation(&resource.State{
+				Type:     resType,
+				URN:      resURN,
+				Custom:   true,
+				Inputs:   resource.PropertyMap{"name": resource.NewStringProperty("web")},
+				Outputs:  resource.PropertyMap{},
+				Provider: provRef.String(),
+			}, resource.OperationTypeCreating),
+		},
+	}
+
+	// The resource exists, but its provider assigned it the ID "i-1234" rather than its name.
+	var readIDs []resource.ID
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				ReadF: func(urn resource.URN, id resource.ID,
+					inputs, state resource.PropertyMap) (plugin.ReadResult, resource.Status, error) {
+
+					readIDs = append(readIDs, id)
+					if id != "i-1234" {
+						return plugin.ReadResult{}, resource.StatusOK, nil
+					}
+					return plugin.ReadResult{Outputs: resource.PropertyMap{}}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	options := UpdateOptions{
+		Host:                   deploytest.NewPluginHost(nil, nil, nil, loaders...),
+		ClearPendingOperations: true,
+	}
+	snap, res := TestOp(Refresh).Run(p.GetProject(), p.GetTarget(old), options, false, nil, nil)
+	assert.Nil(t, res)
+	assert.Equal(t, []resource.ID{"web"}, readIDs)
+
+	// The resource is not adopted, and its creation remains pending exactly as it was recorded.
+	assert.Len(t, snap.Resources, 1)
+	if assert.Len(t, snap.PendingOperations, 1) {
+		op := snap.PendingOperations[0]
+		assert.Equal(t, resURN, op.Resource.URN)
+		assert.Equal(t, resource.OperationTypeCreating, op.Type)
+		assert.Equal(t, resource.ID(""), op.Resource.ID)
+	}
+}
+
+// Tests that a refresh that fails to read some resources still resolves the pending operations that it can, and
+// leaves the rest pending.
+func TestRefreshClearPendingOperationsWithFailure(t *testing.T) {
+	p := &TestPlan{}
+
+	const resType = "pkgA:m:typA"
+	provURN := p.NewProviderURN("pkgA", "default", "")
+	provRef, err := providers.NewReference(provURN, "0")
+	assert.NoError(t, err)
+
+	newResource := func(name string, id resource.ID) *resource.State {
+		return &resource.State{
+			Type:     resType,
+			URN:      p.NewURN(resType, name, ""),
+			Custom:   true,
+			ID:       id,
+			Inputs:   resource.PropertyMap{},
+			Outputs:  resource.PropertyMap{},
+			Provider: provRef.String(),
+		}
+	}
+
+	resA := newResource("resA", "a")
+	old := &deploy.Snapshot{
+		Resources: []*resource.State{
+			{
+				Type:    provURN.Type(),
+				URN:     provURN,
+				Custom:  true,
+				ID:      "0",
+				Inputs:  resource.PropertyMap{},
+				Outputs: resource.PropertyMap{},
+			},
+			resA,
+		},
+		PendingOperations: []resource.Operation{
+			resource.NewOperation(newResource("resA", "a"), resource.OperationTypeUpdating),
+			resource.NewOperation(newResource("resB", "b"), resource.OperationTypeCreating),
+			resource.NewOperation(newResource("resC", "c"), resource.OperationTypeCreating),
+		},
+	}
+
+	// Reading resA and resC fails, but resB can be read.
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				ReadF: func(urn resource.URN, id resource.ID,
+					inputs, state resource.PropertyMap) (plugin.ReadResult, resource.Status, error) {
+
+					if id != "b" {
+						return plugin.ReadResult{}, resource.StatusUnknown, errors.New("read failed")
+					}
+					return plugin.ReadResult{Outputs: resource.PropertyMap{}}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	options := UpdateOptions{
+		Host:                   deploytest.NewPluginHost(nil, nil, nil, loaders...),
+		ClearPendingOperations: true,
+	}
+	snap, res := TestOp(Refresh).Run(p.GetProject(), p.GetTarget(old), options, false, nil, nil)
+	assertIsErrorOrBailResult(t, res)
+
+	// resB is adopted, and the operations for resA and resC remain pending.
+	var urns []resource.URN
+	for _, r := range snap.Resources {
+		urns = append(urns, r.URN)
+	}
+	assert.Equal(t, []resource.URN{provURN, resA.URN, p.NewURN(resType, "resB", "")}, urns)
+
+	pending := make(map[resource.URN]resource.OperationType)
+	for _, op := range snap.PendingOperations {
+		pending[op.Resource.URN] = op.Type
+	}
+	assert.Equal(t, map[resource.URN]resource.OperationType{
+		resA.URN:                      resource.OperationTypeUpdating,
+		p.NewURN(resType, "resC", ""): resource.OperationTypeCreating,
+	}, pending)
+}
@@ -0,0 +1,76 @@
+package lifecycletest
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// Test that steps that fail with a transient provider error are retried, and that other failures are not.
+func TestRetryTransientErrors(t *testing.T) {
+	attempts := 0
+	failure := codes.Unavailable
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, inputs resource.PropertyMap, timeout float64,
+					preview bool) (resource.ID, resource.PropertyMap, resource.Status, error) {
+
+					if attempts++; attempts == 1 {
+						return "", nil, resource.StatusOK, rpcerror.New(failure, "try again later")
+					}
+					return "created-id", resource.PropertyMap{}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		return err
+	})
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, program, loaders...)},
+		Steps: []TestStep{{
+			Op:          Update,
+			SkipPreview: true,
+			Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+				evts []Event, res result.Result) result.Result {
+
+				assert.Nil(t, res)
+
+				warned := false
+				for _, e := range evts {
+					if e.Type == DiagEvent && e.Payload().(DiagEventPayload).Severity == diag.Warning {
+						warned = true
+					}
+				}
+				assert.True(t, warned)
+				return res
+			},
+		}},
+	}
+	snap := p.Run(t, nil)
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, snap.Resources, 2)
+
+	// A failure that is not transient fails the update without retrying the step.
+	attempts, failure = 0, codes.InvalidArgument
+	p.Steps = []TestStep{{Op: Update, SkipPreview: true, ExpectFailure: true}}
+	p.Run(t, nil)
+	assert.Equal(t, 1, attempts)
+}
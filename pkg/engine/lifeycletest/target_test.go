@@ -580,3 +580,57 @@ func TestReplaceSpecificTargets(t *testing.T) {
 
 	p.Run(t, old)
 }
+
+func TestTargetPatterns(t *testing.T) {
+	value := "foo"
+	p := newExcludeTestPlan(t, &value)
+	snap := p.Run(t, nil)
+
+	resA := p.NewURN("pkgA:m:typA", "resA", "")
+	resB := p.NewURN("pkgA:m:typA", "resB", "")
+	resC := p.NewURN("pkgA:m:typA", "resC", "")
+
+	// A pattern that does not match any resource is an error.
+	value = "bar"
+	p.Options.UpdateTargets = []resource.URN{"**::resA", "urn:pulumi:test::test::*::resD"}
+	p.Steps = []TestStep{{
+		Op:            Update,
+		ExpectFailure: true,
+	}}
+	p.Run(t, CloneSnapshot(t, snap))
+
+	// Update only the resources matched by the given glob patterns.
+	p.Options.UpdateTargets = []resource.URN{"**::resA", "urn:pulumi:test::test::*::res?"}
+	p.Options.ExcludeTargets = []resource.URN{resB}
+	p.Steps = []TestStep{{
+		Op: Update,
+		Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+			evts []Event, res result.Result) result.Result {
+
+			assert.Nil(t, res)
+
+			ops := collectOps(entries)
+			assert.Equal(t, map[resource.URN]bool{resA: true, resC: true}, ops[deploy.OpUpdate])
+			assert.Equal(t, map[resource.URN]bool{resB: true}, ops[deploy.OpSame])
+			return res
+		},
+	}}
+	p.Run(t, CloneSnapshot(t, snap))
+
+	// Replace every resource of the given type.
+	p.Options.UpdateTargets = nil
+	p.Options.ExcludeTargets = nil
+	p.Options.ReplaceTargets = []resource.URN{"pkgA:m:typA"}
+	p.Steps = []TestStep{{
+		Op: Update,
+		Validate: func(project workspace.Project, target deploy.Target, entries JournalEntries,
+			evts []Event, res result.Result) result.Result {
+
+			assert.Nil(t, res)
+			assert.Equal(t, map[resource.URN]bool{resA: true, resB: true, resC: true},
+				collectOps(entries)[deploy.OpReplace])
+			return res
+		},
+	}}
+	p.Run(t, CloneSnapshot(t, snap))
+}
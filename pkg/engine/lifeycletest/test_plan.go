@@ -39,12 +39,14 @@ func (u *updateInfo) GetTarget() *deploy.Target {
 }
 
 func ImportOp(imports []deploy.Import) TestOp {
-	return TestOp(func(info UpdateInfo, ctx *Context, opts UpdateOptions, dryRun bool) (ResourceChanges, result.Result) {
+	return TestOp(func(info UpdateInfo, ctx *Context, opts UpdateOptions,
+		dryRun bool) (*deploy.Plan, ResourceChanges, result.Result) {
+
 		return Import(info, ctx, opts, imports, dryRun)
 	})
 }
 
-type TestOp func(UpdateInfo, *Context, UpdateOptions, bool) (ResourceChanges, result.Result)
+type TestOp func(UpdateInfo, *Context, UpdateOptions, bool) (*deploy.Plan, ResourceChanges, result.Result)
 
 type ValidateFunc func(project workspace.Project, target deploy.Target, entries JournalEntries,
 	events []Event, res result.Result) result.Result
@@ -93,7 +95,7 @@ func (op TestOp) RunWithContext(
 	}()
 
 	// Run the step and its validator.
-	_, res := op(info, ctx, opts, dryRun)
+	_, _, res := op(info, ctx, opts, dryRun)
 	contract.IgnoreClose(journal)
 
 	if dryRun {
@@ -106,6 +106,10 @@ type UpdateOptions struct {
 	// the degree of parallelism for resource operations (<=1 for serial).
 	Parallel int
 
+	// caps on the degree of parallelism for operations on resources of a given provider package or type, keyed by
+	// package name or type token.
+	ParallelLimits map[string]int
+
 	// true if debugging output it enabled
 	Debug bool
 
@@ -128,6 +132,21 @@ type UpdateOptions struct {
 	// XXXTargets lists.
 	TargetDependents bool
 
+	// Specific resources to leave untouched during an update, refresh, or destroy operation.
+	ExcludeTargets []resource.URN
+
+	// true if resources that depend on an excluded resource should be excluded as well, rather than
+	// causing the operation to fail.
+	ExcludeDependents bool
+
+	// true if the engine should keep executing steps that do not depend on a failed step, rather than stopping at the
+	// first failure.
+	ContinueOnError bool
+
+	// true if a refresh should resolve any operations left pending by an interrupted update rather than refusing to
+	// proceed.
+	ClearPendingOperations bool
+
 	// true if the engine should use legacy diffing behavior during an update.
 	UseLegacyDiff bool
 
@@ -140,6 +159,9 @@ type UpdateOptions struct {
 	// true if we should report events for steps that involve default providers.
 	reportDefaultProviderSteps bool
 
+	// an optional plan, produced by an earlier preview, that the update must conform to.
+	Plan *deploy.Plan
+
 	// the plugin host to use for this update
 	Host plugin.Host
 }
@@ -161,7 +183,11 @@ func (changes ResourceChanges) HasChanges() bool {
 	return c > 0
 }
 
-func Update(u UpdateInfo, ctx *Context, opts UpdateOptions, dryRun bool) (ResourceChanges, result.Result) {
+// Update runs an update or preview of the given stack, returning the plan of the operations it performed or would have
+// performed along with a summary of its changes.
+func Update(u UpdateInfo, ctx *Context, opts UpdateOptions,
+	dryRun bool) (*deploy.Plan, ResourceChanges, result.Result) {
+
 	contract.Require(u != nil, "update")
 	contract.Require(ctx != nil, "ctx")
 
@@ -169,13 +195,13 @@ func Update(u UpdateInfo, ctx *Context, opts UpdateOptions, dryRun bool) (Resour
 
 	info, err := newDeploymentContext(u, "update", ctx.ParentSpan)
 	if err != nil {
-		return nil, result.FromError(err)
+		return nil, nil, result.FromError(err)
 	}
 	defer info.Close()
 
 	emitter, err := makeEventEmitter(ctx.Events, u)
 	if err != nil {
-		return nil, result.FromError(err)
+		return nil, nil, result.FromError(err)
 	}
 	defer emitter.Close()
 
@@ -413,7 +439,7 @@ func newUpdateSource(
 }
 
 func update(ctx *Context, info *deploymentContext, opts deploymentOptions,
-	preview bool) (ResourceChanges, result.Result) {
+	preview bool) (*deploy.Plan, ResourceChanges, result.Result) {
 
 	// Refresh and Import do not execute Policy Packs.
 	policies := map[string]string{}
@@ -438,7 +464,7 @@ func update(ctx *Context, info *deploymentContext, opts deploymentOptions,
 
 	deployment, err := newDeployment(ctx, info, opts, preview)
 	if err != nil {
-		return nil, result.FromError(err)
+		return nil, nil, result.FromError(err)
 	}
 	defer contract.IgnoreClose(deployment)
 
@@ -494,6 +520,12 @@ func newUpdateActions(context *Context, u UpdateInfo, opts deploymentOptions) *u
 	}
 }
 
+func (acts *updateActions) OnResourceStepWaiting(step deploy.Step, limit string) {
+	if shouldReportStep(step, acts.Opts) {
+		acts.Opts.Events.resourceWaitingEvent(step, limit, false /*planning*/, acts.Opts.Debug)
+	}
+}
+
 func (acts *updateActions) OnResourceStepPre(step deploy.Step) (interface{}, error) {
 	// Ensure we've marked this step as observed.
 	acts.MapLock.Lock()
@@ -659,6 +691,12 @@ func newPreviewActions(opts deploymentOptions) *previewActions {
 	}
 }
 
+func (acts *previewActions) OnResourceStepWaiting(step deploy.Step, limit string) {
+	if shouldReportStep(step, acts.Opts) {
+		acts.Opts.Events.resourceWaitingEvent(step, limit, true /*planning*/, acts.Opts.Debug)
+	}
+}
+
 func (acts *previewActions) OnResourceStepPre(step deploy.Step) (interface{}, error) {
 	acts.MapLock.Lock()
 	acts.Seen[step.URN()] = step
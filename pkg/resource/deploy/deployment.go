@@ -51,6 +51,7 @@ type BackendClient interface {
 type Options struct {
 	Events                    Events         // an optional events callback interface.
 	Parallel                  int            // the degree of parallelism for resource operations (<=1 for serial).
+	ParallelLimits            map[string]int // per-provider package or per-resource type caps on parallelism.
 	Refresh                   bool           // whether or not to refresh before executing the deployment.
 	RefreshOnly               bool           // whether or not to exit after refreshing.
 	RefreshTargets            []resource.URN // The specific resources to refresh during a refresh op.
@@ -58,9 +59,16 @@ type Options struct {
 	DestroyTargets            []resource.URN // Specific resources to destroy.
 	UpdateTargets             []resource.URN // Specific resources to update.
 	TargetDependents          bool           // true if we're allowing things to proceed, even with unspecified targets
+	ContinueOnError           bool           // true to keep executing steps that don't depend on a failed step
+	ExcludeTargets            []resource.URN // Specific resources to leave untouched.
+	ExcludeDependents         bool           // true if exclusions extend to resources related to excluded resources
 	TrustDependencies         bool           // whether or not to trust the resource dependency graph.
 	UseLegacyDiff             bool           // whether or not to use legacy diffing behavior.
 	DisableResourceReferences bool           // true to disable resource reference support.
+	Plan                      *Plan          // an optional plan that the deployment's steps must conform to.
+
+	// PendingOperations are operations left pending by an interrupted update that a refresh should resolve.
+	PendingOperations []resource.Operation
 }
 
 // DegreeOfParallelism returns the degree of parallelism that should be used during the
@@ -79,6 +87,7 @@ func (o Options) InfiniteParallelism() bool {
 
 // StepExecutorEvents is an interface that can be used to hook resource lifecycle events.
 type StepExecutorEvents interface {
+	OnResourceStepWaiting(step Step, limit string)
 	OnResourceStepPre(step Step) (interface{}, error)
 	OnResourceStepPost(ctx interface{}, step Step, status resource.Status, err error) error
 	OnResourceOutputs(step Step) error
@@ -162,6 +171,7 @@ type Deployment struct {
 	providers            *providers.Registry              // the provider registry for this deployment.
 	goals                *goalMap                         // the set of resource goals generated by the deployment.
 	news                 *resourceMap                     // the set of new resources generated by the deployment.
+	plans                *resourcePlans                   // the plan recorded for this deployment's steps.
 }
 
 // addDefaultProviders adds any necessary default provider definitions and references to the given snapshot. Version
@@ -350,6 +360,7 @@ func NewDeployment(ctx *plugin.Context, target *Target, prev *Snapshot, source S
 		providers:            reg,
 		goals:                newGoals,
 		news:                 newResources,
+		plans:                newResourcePlans(),
 	}, nil
 }
 
@@ -360,6 +371,10 @@ func (d *Deployment) Prev() *Snapshot                        { return d.prev }
 func (d *Deployment) Olds() map[resource.URN]*resource.State { return d.olds }
 func (d *Deployment) Source() Source                         { return d.source }
 
+// Plan returns the plan recorded for the steps this deployment has generated so far. A plan recorded by a preview may
+// be passed to a later deployment's Options to ensure that it performs only the operations that were previewed.
+func (d *Deployment) Plan() *Plan { return d.plans.snapshot() }
+
 func (d *Deployment) GetProvider(ref providers.Reference) (plugin.Provider, bool) {
 	return d.providers.GetProvider(ref)
 }
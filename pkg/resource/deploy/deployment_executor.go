@@ -17,6 +17,7 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -37,30 +38,37 @@ type deploymentExecutor struct {
 
 	stepGen  *stepGenerator // step generator owned by this deployment
 	stepExec *stepExecutor  // step executor owned by this deployment
+
+	// the URNs of resources that were skipped because they depend on a resource whose step failed, mapped to the URN
+	// of that resource. Only populated if the deployment continues after errors.
+	skipped map[resource.URN]resource.URN
 }
 
-// A set is returned of all the target URNs to facilitate later callers.  The set can be 'nil'
-// indicating no targets, or will be non-nil and non-empty if there are targets.  Only URNs in the
-// original array are in the set.  i.e. it's only checked for containment.  The value of the map is
-// unused.
-func createTargetMap(targets []resource.URN) map[resource.URN]bool {
-	if len(targets) == 0 {
-		return nil
+// dependsOnAny returns true if the given resource references any of the given URNs through its parent, provider, or
+// dependencies.
+func dependsOnAny(res *resource.State, urns *targetSet) bool {
+	if urns.Contains(res.Parent) {
+		return true
 	}
-
-	targetMap := make(map[resource.URN]bool)
-	for _, target := range targets {
-		targetMap[target] = true
+	if res.Provider != "" {
+		if ref, err := providers.ParseReference(res.Provider); err == nil && urns.Contains(ref.URN()) {
+			return true
+		}
 	}
-
-	return targetMap
+	for _, dep := range res.Dependencies {
+		if urns.Contains(dep) {
+			return true
+		}
+	}
+	return false
 }
 
 // checkTargets validates that all the targets passed in refer to existing resources.  Diagnostics
 // are generated for any target that cannot be found.  The target must either have existed in the stack
-// prior to running the operation, or it must be the urn for a resource that was created.
-func (ex *deploymentExecutor) checkTargets(targets []resource.URN, op StepOp) result.Result {
-	if len(targets) == 0 {
+// prior to running the operation, or it must be the urn for a resource that was created.  Patterns
+// must match at least one such resource.
+func (ex *deploymentExecutor) checkTargets(targets *targetSet, op StepOp) result.Result {
+	if targets == nil {
 		return nil
 	}
 
@@ -70,8 +78,36 @@ func (ex *deploymentExecutor) checkTargets(targets []resource.URN, op StepOp) re
 		news = ex.stepGen.urns
 	}
 
+	// Resolve any patterns against the old and new resources.
+	for urn := range olds {
+		targets.Contains(urn)
+	}
+	for urn := range news {
+		targets.Contains(urn)
+	}
+
 	hasUnknownTarget := false
-	for _, target := range targets {
+	matches := targets.patternMatches()
+	patterns := make([]string, 0, len(matches))
+	for pattern := range matches {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if len(matches[pattern]) == 0 {
+			hasUnknownTarget = true
+
+			logging.V(7).Infof("Pattern of resources to %v (%v) did not match any resources in the stack.", op, pattern)
+			ex.deployment.Diag().Errorf(diag.GetTargetPatternDidNotMatchError(), pattern)
+		}
+	}
+
+	urns := make([]resource.URN, 0, len(targets.urns))
+	for urn := range targets.urns {
+		urns = append(urns, urn)
+	}
+	sort.Slice(urns, func(i, j int) bool { return urns[i] < urns[j] })
+	for _, target := range urns {
 		hasOld := false
 		if _, has := olds[target]; has {
 			hasOld = true
@@ -97,6 +133,57 @@ func (ex *deploymentExecutor) checkTargets(targets []resource.URN, op StepOp) re
 	return nil
 }
 
+// reportTargetMatches reports the resources matched by each pattern in the given target sets.
+func (ex *deploymentExecutor) reportTargetMatches(sets ...*targetSet) {
+	for _, set := range sets {
+		matches := set.patternMatches()
+		patterns := make([]string, 0, len(matches))
+		for pattern := range matches {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+
+		for _, pattern := range patterns {
+			var msg strings.Builder
+			fmt.Fprintf(&msg, "Pattern '%v' matched %d resource(s)", pattern, len(matches[pattern]))
+			for _, urn := range matches[pattern] {
+				fmt.Fprintf(&msg, "\n    %v", urn)
+			}
+			ex.deployment.Diag().Infof(diag.RawMessage("", msg.String()))
+		}
+	}
+}
+
+// reportFailures reports a summary of the steps that failed during a deployment that continued after errors, along
+// with the resources that were skipped because they depend on a resource whose step failed.
+func (ex *deploymentExecutor) reportFailures() {
+	failures := ex.stepExec.Failures()
+	if len(failures) == 0 && len(ex.skipped) == 0 {
+		return
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d resource operation(s) failed", len(failures))
+	for _, failure := range failures {
+		fmt.Fprintf(&msg, "\n    %v (%v): %v", failure.Step.URN(), failure.Step.Op(), failure.Err)
+	}
+
+	if len(ex.skipped) != 0 {
+		skipped := make([]string, 0, len(ex.skipped))
+		for urn := range ex.skipped {
+			skipped = append(skipped, string(urn))
+		}
+		sort.Strings(skipped)
+
+		fmt.Fprintf(&msg, "\n%d resource(s) were skipped because they depend on a failed resource", len(skipped))
+		for _, urn := range skipped {
+			fmt.Fprintf(&msg, "\n    %v (depends on %v)", urn, ex.skipped[resource.URN(urn)])
+		}
+	}
+
+	ex.reportError("", errors.New(msg.String()))
+}
+
 // reportExecResult issues an appropriate diagnostic depending on went wrong.
 func (ex *deploymentExecutor) reportExecResult(message string, preview bool) {
 	kind := "update"
@@ -152,13 +239,14 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	// Non-nill means 'update only in this set'.  We don't error if the user specifies an target
 	// during `update` that we don't know about because it might be the urn for a resource they
 	// want to create.
-	updateTargetsOpt := createTargetMap(opts.UpdateTargets)
-	replaceTargetsOpt := createTargetMap(opts.ReplaceTargets)
-	destroyTargetsOpt := createTargetMap(opts.DestroyTargets)
-	if res := ex.checkTargets(opts.ReplaceTargets, OpReplace); res != nil {
+	updateTargetsOpt := newTargetSet(opts.UpdateTargets)
+	replaceTargetsOpt := newTargetSet(opts.ReplaceTargets)
+	destroyTargetsOpt := newTargetSet(opts.DestroyTargets)
+	excludeTargetsOpt := newTargetSet(opts.ExcludeTargets)
+	if res := ex.checkTargets(replaceTargetsOpt, OpReplace); res != nil {
 		return res
 	}
-	if res := ex.checkTargets(opts.DestroyTargets, OpDelete); res != nil {
+	if res := ex.checkTargets(destroyTargetsOpt, OpDelete); res != nil {
 		return res
 	}
 
@@ -173,7 +261,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	}
 
 	// Set up a step generator for this deployment.
-	ex.stepGen = newStepGenerator(ex.deployment, opts, updateTargetsOpt, replaceTargetsOpt)
+	ex.stepGen = newStepGenerator(ex.deployment, opts, updateTargetsOpt, replaceTargetsOpt, excludeTargetsOpt)
 
 	// Retire any pending deletes that are currently present in this deployment.
 	if res := ex.retirePendingDeletes(callerCtx, opts, preview); res != nil {
@@ -185,7 +273,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	ctx, cancel := context.WithCancel(callerCtx)
 
 	// Set up a step generator and executor for this deployment.
-	ex.stepExec = newStepExecutor(ctx, cancel, ex.deployment, opts, preview, false)
+	ex.stepExec = newStepExecutor(ctx, cancel, ex.deployment, opts, preview, opts.ContinueOnError)
 
 	// We iterate the source in its own goroutine because iteration is blocking and we want the main loop to be able to
 	// respond to cancellation requests promptly.
@@ -229,7 +317,13 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 					if !event.Result.IsBail() {
 						ex.reportError("", event.Result.Error())
 					}
-					cancel()
+
+					// If we are continuing after errors, let any steps that are already running complete.
+					if opts.ContinueOnError {
+						ex.stepExec.SignalCompletion()
+					} else {
+						cancel()
+					}
 
 					// We reported any errors above.  So we can just bail now.
 					return false, result.Bail()
@@ -264,7 +358,13 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	// valid.  We have to do this *after* performing the steps as the target list may have referred
 	// to a resource that was created in one of hte steps.
 	if res == nil {
-		res = ex.checkTargets(opts.UpdateTargets, OpUpdate)
+		res = ex.checkTargets(updateTargetsOpt, OpUpdate)
+	}
+	if res == nil {
+		res = ex.checkTargets(excludeTargetsOpt, OpSame)
+	}
+	if preview {
+		ex.reportTargetMatches(updateTargetsOpt, replaceTargetsOpt, destroyTargetsOpt, excludeTargetsOpt)
 	}
 
 	if res != nil && res.IsBail() {
@@ -284,6 +384,11 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 		}
 	}
 
+	// If we continued after errors, summarize the steps that failed and the resources that were skipped as a result.
+	if opts.ContinueOnError {
+		ex.reportFailures()
+	}
+
 	// Figure out if execution failed and why. Step generation and execution errors trump cancellation.
 	if res != nil || ex.stepExec.Errored() || ex.stepGen.Errored() {
 		// TODO(cyrusn): We seem to be losing any information about the original 'res's errors.  Should
@@ -299,7 +404,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 }
 
 func (ex *deploymentExecutor) performDeletes(
-	ctx context.Context, updateTargetsOpt, destroyTargetsOpt map[resource.URN]bool) result.Result {
+	ctx context.Context, updateTargetsOpt, destroyTargetsOpt *targetSet) result.Result {
 
 	defer func() {
 		// We're done here - signal completion so that the step executor knows to terminate.
@@ -316,7 +421,7 @@ func (ex *deploymentExecutor) performDeletes(
 	// At this point we have generated the set of resources above that we would normally want to
 	// delete.  However, if the user provided -target's we will only actually delete the specific
 	// resources that are in the set explicitly asked for.
-	var targetsOpt map[resource.URN]bool
+	var targetsOpt *targetSet
 	if updateTargetsOpt != nil {
 		targetsOpt = updateTargetsOpt
 	} else if destroyTargetsOpt != nil {
@@ -329,7 +434,11 @@ func (ex *deploymentExecutor) performDeletes(
 		return res
 	}
 
-	deletes := ex.stepGen.ScheduleDeletes(deleteSteps)
+	// Deletes of resources that are deleted along with their DeletedWith resource do not call their providers, and
+	// must not remove their resources from the snapshot until the resource they are deleted with has been deleted.
+	// These deletes are performed after all others, and the deletes of the resources that they depend on are held
+	// back until after them where possible, so that the snapshot never refers to a resource that is already deleted.
+	deleteSteps, deletedWithSteps, heldSteps := ex.partitionDeletes(deleteSteps)
 
 	// ScheduleDeletes gives us a list of lists of steps. Each list of steps can safely be executed
 	// in parallel, but each list must execute completes before the next list can safely begin
@@ -338,16 +447,33 @@ func (ex *deploymentExecutor) performDeletes(
 	// This is not "true" delete parallelism, since there may be resources that could safely begin
 	// deleting but we won't until the previous set of deletes fully completes. This approximation
 	// is conservative, but correct.
-	for _, antichain := range deletes {
-		logging.V(4).Infof("deploymentExecutor.Execute(...): beginning delete antichain")
-		tok := ex.stepExec.ExecuteParallel(antichain)
-		tok.Wait(ctx)
-		logging.V(4).Infof("deploymentExecutor.Execute(...): antichain complete")
+	//
+	// If we are continuing after errors, resources that are still depended upon by a resource whose deletion failed
+	// are not deleted.
+	blocked := make(map[resource.URN]resource.URN)
+	execute := func(deletes []antichain) {
+		for _, antichain := range deletes {
+			if ex.stepExec.continueOnError {
+				antichain = ex.skipBlockedDeletes(antichain, blocked)
+			}
+
+			logging.V(4).Infof("deploymentExecutor.Execute(...): beginning delete antichain")
+			tok := ex.stepExec.ExecuteParallel(antichain)
+			tok.Wait(ctx)
+			logging.V(4).Infof("deploymentExecutor.Execute(...): antichain complete")
+		}
+	}
+
+	execute(ex.stepGen.ScheduleDeletes(append(deleteSteps, deletedWithSteps...)))
+	if len(deletedWithSteps) > 0 {
+		execute([]antichain{ex.deletableWith(deletedWithSteps, blocked)})
+		execute(ex.stepGen.ScheduleDeletes(heldSteps))
 	}
+	deleteSteps = append(append(deleteSteps, deletedWithSteps...), heldSteps...)
 
 	// After executing targeted deletes, we may now have resources that depend on the resource that
 	// were deleted.  Go through and clean things up accordingly for them.
-	if targetsOpt != nil {
+	if targetsOpt != nil || ex.stepGen.excludeTargetsOpt != nil {
 		resourceToStep := make(map[*resource.State]Step)
 		for _, step := range deleteSteps {
 			resourceToStep[ex.deployment.olds[step.URN()]] = step
@@ -359,6 +485,200 @@ func (ex *deploymentExecutor) performDeletes(
 	return nil
 }
 
+// skipBlockedDeletes removes from the given antichain any deletes of resources that must not be deleted because a
+// resource whose step failed, or whose deletion was itself skipped, still depends on them. The given map records the
+// resources that are blocked in this way, along with the URN of the resource that blocks them, and is updated as
+// deletes are skipped.
+func (ex *deploymentExecutor) skipBlockedDeletes(deletes antichain, blocked map[resource.URN]resource.URN) antichain {
+	block := func(res *resource.State) {
+		for dep := range ex.deployment.depGraph.DependenciesOf(res) {
+			if _, has := blocked[dep.URN]; !has {
+				blocked[dep.URN] = res.URN
+			}
+		}
+	}
+
+	// The old states of updated and deleted resources are part of the base dependency graph.
+	for _, failure := range ex.stepExec.Failures() {
+		switch failure.Step.Op() {
+		case OpUpdate, OpDelete, OpDeleteReplaced:
+			block(failure.Step.Old())
+		}
+	}
+
+	var steps antichain
+	for _, step := range deletes {
+		old := step.Old()
+		dep, isBlocked := blocked[old.URN]
+		if !isBlocked {
+			steps = append(steps, step)
+			continue
+		}
+
+		logging.V(7).Infof("deploymentExecutor.skipBlockedDeletes(...): skipping delete of %v, blocked by %v",
+			old.URN, dep)
+		ex.skip(old.URN, dep)
+		block(old)
+	}
+	return steps
+}
+
+// partitionDeletes splits the given deletes into the deletes of resources that are deleted along with their
+// DeletedWith resource, the deletes of resources that those resources depend on and that can be held back until they
+// have been removed from the snapshot, and all other deletes. A resource can only be held back if it does not itself
+// depend, directly or indirectly, on a resource that must be deleted first.
+func (ex *deploymentExecutor) partitionDeletes(steps []Step) (others, deletedWith, held []Step) {
+	deletedWith = onlyDeletedWith(steps)
+	if len(deletedWith) == 0 || !ex.stepGen.opts.TrustDependencies {
+		return withoutDeletedWith(steps), deletedWith, nil
+	}
+
+	dg := ex.deployment.depGraph
+	condemned := make(map[*resource.State]bool)
+	for _, step := range withoutDeletedWith(steps) {
+		condemned[step.Res()] = true
+	}
+	removedWith := make(map[*resource.State]bool)
+	deletedWithURNs := make(map[resource.URN]bool)
+	for _, step := range deletedWith {
+		removedWith[step.Res()] = true
+		deletedWithURNs[step.Old().DeletedWith] = true
+	}
+
+	// A resource can be held back if it is not one that others are deleted with, and if each resource it depends on
+	// is either not being deleted or can itself be held back.
+	canHold := make(map[*resource.State]bool)
+	var holdable func(res *resource.State) bool
+	holdable = func(res *resource.State) bool {
+		if result, has := canHold[res]; has {
+			return result
+		}
+		canHold[res] = false
+		if deletedWithURNs[res.URN] {
+			return false
+		}
+		for dep := range dg.DependenciesOf(res) {
+			if removedWith[dep] || condemned[dep] && !holdable(dep) {
+				return false
+			}
+		}
+		canHold[res] = true
+		return true
+	}
+
+	isHeld := make(map[*resource.State]bool)
+	for _, step := range deletedWith {
+		for dep := range dg.DependenciesOf(step.Res()) {
+			if condemned[dep] && holdable(dep) {
+				isHeld[dep] = true
+			}
+		}
+	}
+
+	// Everything that a held resource depends on is held too.
+	for changed := true; changed; {
+		changed = false
+		for res := range isHeld {
+			for dep := range dg.DependenciesOf(res) {
+				if condemned[dep] && !isHeld[dep] {
+					isHeld[dep], changed = true, true
+				}
+			}
+		}
+	}
+
+	for _, step := range withoutDeletedWith(steps) {
+		if isHeld[step.Res()] {
+			logging.V(7).Infof("deploymentExecutor.partitionDeletes(...): holding back delete of %v", step.URN())
+			held = append(held, step)
+		} else {
+			others = append(others, step)
+		}
+	}
+	return others, deletedWith, held
+}
+
+// deletableWith returns the given steps that delete resources along with their DeletedWith resource for which that
+// resource has actually been deleted. The other resources remain in the snapshot, and if we are continuing after
+// errors, they are recorded as skipped and block the deletes of the resources that they depend on.
+func (ex *deploymentExecutor) deletableWith(steps []Step, blocked map[resource.URN]resource.URN) antichain {
+	if ex.stepExec.Errored() && !ex.stepExec.continueOnError {
+		return nil
+	}
+
+	byURN := make(map[resource.URN]Step)
+	for _, step := range steps {
+		byURN[step.URN()] = step
+	}
+
+	// A resource is deleted if its delete neither failed nor was skipped, or if it is itself deleted along with a
+	// resource that was deleted.
+	visiting := make(map[resource.URN]bool)
+	var deleted func(urn resource.URN) bool
+	deleted = func(urn resource.URN) bool {
+		if _, skipped := ex.skipped[urn]; skipped || ex.stepExec.Failed(urn) || visiting[urn] {
+			return false
+		}
+		if step, ok := byURN[urn]; ok {
+			visiting[urn] = true
+			defer delete(visiting, urn)
+			return deleted(step.Old().DeletedWith)
+		}
+		return true
+	}
+
+	var result antichain
+	for _, step := range steps {
+		old := step.Old()
+		if deleted(old.DeletedWith) {
+			result = append(result, step)
+			continue
+		}
+
+		logging.V(7).Infof("deploymentExecutor.deletableWith(...): keeping %v, %v was not deleted",
+			old.URN, old.DeletedWith)
+		if ex.stepExec.continueOnError {
+			ex.skip(old.URN, old.DeletedWith)
+			for dep := range ex.deployment.depGraph.DependenciesOf(old) {
+				if _, has := blocked[dep.URN]; !has {
+					blocked[dep.URN] = old.URN
+				}
+			}
+		}
+	}
+	return result
+}
+
+// failedDependency returns the URN of the first of the given parent, provider reference and dependencies whose
+// resource failed or was skipped during this deployment, if any.
+func (ex *deploymentExecutor) failedDependency(parent resource.URN, provider string,
+	dependencies []resource.URN) (resource.URN, bool) {
+
+	urns := append([]resource.URN{parent}, dependencies...)
+	if provider != "" {
+		if ref, err := providers.ParseReference(provider); err == nil {
+			urns = append(urns, ref.URN())
+		}
+	}
+
+	for _, urn := range urns {
+		if _, skipped := ex.skipped[urn]; urn != "" && (skipped || ex.stepExec.Failed(urn)) {
+			return urn, true
+		}
+	}
+	return "", false
+}
+
+// skip records that the resource with the given URN was skipped because it depends on the given failed resource. The
+// resource is left untouched by the deployment.
+func (ex *deploymentExecutor) skip(urn, dep resource.URN) {
+	if ex.skipped == nil {
+		ex.skipped = make(map[resource.URN]resource.URN)
+	}
+	ex.skipped[urn] = dep
+	ex.stepGen.skip(urn)
+}
+
 // handleSingleEvent handles a single source event. For all incoming events, it produces a chain that needs
 // to be executed and schedules the chain for execution.
 func (ex *deploymentExecutor) handleSingleEvent(event SourceEvent) result.Result {
@@ -369,9 +689,24 @@ func (ex *deploymentExecutor) handleSingleEvent(event SourceEvent) result.Result
 	switch e := event.(type) {
 	case RegisterResourceEvent:
 		logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): received RegisterResourceEvent")
+		if ex.stepExec.continueOnError {
+			goal := e.Goal()
+			if dep, failed := ex.failedDependency(goal.Parent, goal.Provider, goal.Dependencies); failed {
+				ex.skip(ex.deployment.generateEventURN(e), dep)
+				e.Done(&RegisterResult{Failed: true})
+				return nil
+			}
+		}
 		steps, res = ex.stepGen.GenerateSteps(e)
 	case ReadResourceEvent:
 		logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): received ReadResourceEvent")
+		if ex.stepExec.continueOnError {
+			if dep, failed := ex.failedDependency(e.Parent(), e.Provider(), e.Dependencies()); failed {
+				ex.skip(ex.deployment.generateEventURN(e), dep)
+				e.Done(&ReadResult{Failed: true})
+				return nil
+			}
+		}
 		steps, res = ex.stepGen.GenerateReadSteps(e)
 	case RegisterResourceOutputsEvent:
 		logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): received register resource outputs")
@@ -472,28 +807,52 @@ func (ex *deploymentExecutor) importResources(callerCtx context.Context, opts Op
 // refresh refreshes the state of the base checkpoint file for the current deployment in memory.
 func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, preview bool) result.Result {
 	prev := ex.deployment.prev
-	if prev == nil || len(prev.Resources) == 0 {
+	if prev == nil || len(prev.Resources) == 0 && len(opts.PendingOperations) == 0 {
 		return nil
 	}
 
 	// Make sure if there were any targets specified, that they all refer to existing resources.
-	targetMapOpt := createTargetMap(opts.RefreshTargets)
-	if res := ex.checkTargets(opts.RefreshTargets, OpRefresh); res != nil {
+	targetSetOpt := newTargetSet(opts.RefreshTargets)
+	if res := ex.checkTargets(targetSetOpt, OpRefresh); res != nil {
 		return res
 	}
+	excludeSetOpt := newTargetSet(opts.ExcludeTargets)
+	if opts.RefreshOnly {
+		if res := ex.checkTargets(excludeSetOpt, OpRefresh); res != nil {
+			return res
+		}
+	}
+
+	// Work out how to resolve any operations left pending by an interrupted update.
+	refreshing, adopting, unresolved := ex.resolvePendingOperations(opts.PendingOperations)
+	pending := make(map[resource.URN]bool)
+	for _, op := range refreshing {
+		pending[op.Resource.URN] = true
+	}
 
 	// If the user did not provide any --target's, create a refresh step for each resource in the
 	// old snapshot.  If they did provider --target's then only create refresh steps for those
-	// specific targets.
+	// specific targets. Resources in the --exclude list, and if requested their dependents, are
+	// never refreshed. Resources with pending operations are always refreshed.
 	steps := []Step{}
 	resourceToStep := map[*resource.State]Step{}
 	for _, res := range prev.Resources {
-		if targetMapOpt == nil || targetMapOpt[res.URN] {
+		if excludeSetOpt != nil && opts.ExcludeDependents && !excludeSetOpt.Contains(res.URN) &&
+			dependsOnAny(res, excludeSetOpt) {
+			excludeSetOpt.add(res.URN)
+		}
+		if pending[res.URN] ||
+			(targetSetOpt == nil || targetSetOpt.Contains(res.URN)) && !excludeSetOpt.Contains(res.URN) {
 			step := NewRefreshStep(ex.deployment, res, nil)
 			steps = append(steps, step)
 			resourceToStep[res] = step
 		}
 	}
+	for _, a := range adopting {
+		step := NewRefreshStep(ex.deployment, a.res, nil)
+		steps = append(steps, step)
+		resourceToStep[a.res] = step
+	}
 
 	// Fire up a worker pool and issue each refresh in turn.
 	ctx, cancel := context.WithCancel(callerCtx)
@@ -502,7 +861,54 @@ func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, p
 	stepExec.SignalCompletion()
 	stepExec.WaitForCompletion()
 
+	// Adopt any resources whose creation was interrupted, so long as the refresh read them successfully. Those that
+	// the refresh found to be missing are dropped from the base state when it is rebuilt. If the refresh could not
+	// read a resource at all, or was canceled before it did, its operation is left pending. So is the operation of a
+	// resource that was looked up by its name and not found, as its provider may have assigned it a different ID.
+	failed := make(map[Step]bool)
+	for _, f := range stepExec.Failures() {
+		failed[f.Step] = true
+	}
+	interrupted := ctx.Err() != nil
+	for _, a := range adopting {
+		if !interrupted && !failed[resourceToStep[a.res]] {
+			prev.Resources = append(prev.Resources, a.res)
+		}
+	}
 	ex.rebuildBaseState(resourceToStep, true /*refresh*/)
+	for _, a := range adopting {
+		res := a.res
+		switch {
+		case interrupted || failed[resourceToStep[res]]:
+			ex.deployment.Diag().Warningf(diag.RawMessage(res.URN,
+				"could not resolve the interrupted creation of this resource; it remains pending"))
+			unresolved = append(unresolved, a.op)
+		case resourceToStep[res].New() == nil && a.guessedID:
+			ex.deployment.Diag().Warningf(diag.RawMessage(res.URN, fmt.Sprintf(
+				"could not resolve the interrupted creation of this resource: no resource with ID '%v' exists, but "+
+					"it may have been created with a different ID, so it remains pending. If it was created, use "+
+					"`pulumi import` to adopt it, then `pulumi stack export` and `pulumi stack import` to remove the "+
+					"pending operation.", res.ID)))
+			unresolved = append(unresolved, a.op)
+		case resourceToStep[res].New() == nil:
+			ex.deployment.Diag().Warningf(diag.RawMessage(res.URN, fmt.Sprintf(
+				"discarded resource whose creation was interrupted: no resource with ID '%v' exists", res.ID)))
+		default:
+			ex.deployment.Diag().Infof(diag.RawMessage(res.URN, "adopted resource whose creation was interrupted"))
+		}
+	}
+	for _, op := range refreshing {
+		if interrupted || stepExec.Failed(op.Resource.URN) {
+			unresolved = append(unresolved, op)
+		}
+	}
+	if len(opts.PendingOperations) != 0 {
+		prev.PendingOperations = unresolved
+	}
+
+	if preview && opts.RefreshOnly {
+		ex.reportTargetMatches(targetSetOpt, excludeSetOpt)
+	}
 
 	// NOTE: we use the presence of an error in the caller context in order to distinguish caller-initiated
 	// cancellation from internally-initiated cancellation.
@@ -518,6 +924,59 @@ func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, p
 	return nil
 }
 
+// pendingAdoption is an interrupted creation or import that a refresh attempts to resolve by adopting the resource.
+type pendingAdoption struct {
+	op        resource.Operation // the pending operation.
+	res       *resource.State    // the state to read and adopt.
+	guessedID bool               // true if the resource had no ID, and res is identified by its name instead.
+}
+
+// resolvePendingOperations works out how a refresh should resolve the given operations, which were left pending by
+// an interrupted update. Resources whose updates, deletions or reads were interrupted are still present in the base
+// snapshot; these are refreshed regardless of the refresh's targets, and their operations are returned in the first
+// result. Resources whose creations or imports were interrupted are not, and are returned in the second result. The
+// refresh attempts to read each of these from its provider by its ID, or failing that by its name, and adopts those
+// that it finds into the base snapshot. Those that have neither an ID nor a name cannot be read at all, and are
+// returned in the third result so that they remain pending.
+func (ex *deploymentExecutor) resolvePendingOperations(
+	ops []resource.Operation) ([]resource.Operation, []pendingAdoption, []resource.Operation) {
+
+	var refreshing, unresolved []resource.Operation
+	var adopting []pendingAdoption
+	for _, op := range ops {
+		res, guessedID := op.Resource, false
+		switch op.Type {
+		case resource.OperationTypeCreating, resource.OperationTypeImporting:
+			// Providers hold no state of their own, so any provider whose creation was interrupted is simply
+			// discarded. It will be recreated by the next update that needs it.
+			if providers.IsProviderType(res.Type) {
+				continue
+			}
+
+			if res.Custom && res.ID == "" {
+				if name, ok := res.Inputs["name"]; ok && name.IsString() && name.StringValue() != "" {
+					// Read a copy, so that the operation is left untouched if it remains pending.
+					guessed := *res
+					guessed.ID = resource.ID(name.StringValue())
+					res, guessedID = &guessed, true
+				} else {
+					ex.deployment.Diag().Warningf(diag.RawMessage(res.URN,
+						"could not resolve the interrupted creation of this resource: it has no ID or name by "+
+							"which it can be read from its provider, so it remains pending. If it was created, use "+
+							"`pulumi import` to adopt it, then `pulumi stack export` and `pulumi stack import` to "+
+							"remove the pending operation."))
+					unresolved = append(unresolved, op)
+					continue
+				}
+			}
+			adopting = append(adopting, pendingAdoption{op: op, res: res, guessedID: guessedID})
+		default:
+			refreshing = append(refreshing, op)
+		}
+	}
+	return refreshing, adopting, unresolved
+}
+
 func (ex *deploymentExecutor) rebuildBaseState(resourceToStep map[*resource.State]Step, refresh bool) {
 	// Rebuild this deployment's map of old resources and dependency graph, stripping out any deleted
 	// resources and repairing dependency lists as necessary. Note that this updates the base
@@ -100,6 +100,9 @@ type ResourceOptions struct {
 	CustomTimeouts        *resource.CustomTimeouts
 	SupportsPartialValues *bool
 	Remote                bool
+	RetainOnDelete        bool
+	ReplaceOnChanges      []string
+	DeletedWith           resource.URN
 
 	DisableSecrets            bool
 	DisableResourceReferences bool
@@ -185,6 +188,9 @@ func (rm *ResourceMonitor) RegisterResource(t tokens.Type, name string, custom b
 		CustomTimeouts:             &timeouts,
 		SupportsPartialValues:      supportsPartialValues,
 		Remote:                     opts.Remote,
+		RetainOnDelete:             opts.RetainOnDelete,
+		ReplaceOnChanges:           opts.ReplaceOnChanges,
+		DeletedWith:                string(opts.DeletedWith),
 	}
 
 	// submit request
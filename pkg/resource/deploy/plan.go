@@ -0,0 +1,220 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// A Plan records the operations that a deployment intends to perform on each resource, along with the inputs that it
+// expects each resource to have. Plans are produced by previews, and may be passed to a later
+// deployment of the same stack, which will then refuse to perform any operation that its plan does not allow.
+type Plan struct {
+	ResourcePlans map[resource.URN]*ResourcePlan // the plans for each resource, keyed by URN.
+}
+
+// NewPlan creates a new, empty plan.
+func NewPlan() *Plan {
+	return &Plan{ResourcePlans: make(map[resource.URN]*ResourcePlan)}
+}
+
+// A ResourcePlan records the operations planned for a single resource.
+type ResourcePlan struct {
+	Ops    []StepOp             // the operations planned for this resource, in the order they were generated.
+	Inputs resource.PropertyMap // the expected inputs for this resource, if it is registered or read by the program.
+}
+
+// resourcePlans accumulates the plan for a deployment while it executes. Access is synchronized so that the plan
+// can be snapshotted while steps are still being generated.
+type resourcePlans struct {
+	m    sync.Mutex
+	plan *Plan
+}
+
+func newResourcePlans() *resourcePlans {
+	return &resourcePlans{plan: NewPlan()}
+}
+
+func (p *resourcePlans) get(urn resource.URN) *ResourcePlan {
+	rp, ok := p.plan.ResourcePlans[urn]
+	if !ok {
+		rp = &ResourcePlan{}
+		p.plan.ResourcePlans[urn] = rp
+	}
+	return rp
+}
+
+// recordSteps records the operations, and any new inputs, of the given steps.
+func (p *resourcePlans) recordSteps(steps []Step) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, step := range steps {
+		rp := p.get(step.URN())
+		rp.Ops = append(rp.Ops, step.Op())
+		if step.New() != nil && step.Op() != OpRemovePendingReplace {
+			rp.Inputs = step.New().Inputs
+		}
+	}
+}
+
+// snapshot returns the plan recorded so far.
+func (p *resourcePlans) snapshot() *Plan {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	plan := NewPlan()
+	for urn, rp := range p.plan.ResourcePlans {
+		plan.ResourcePlans[urn] = &ResourcePlan{
+			Ops:    append([]StepOp(nil), rp.Ops...),
+			Inputs: rp.Inputs,
+		}
+	}
+	return plan
+}
+
+// checkStep returns an error if the given step is not allowed by the plan.
+func (p *Plan) checkStep(step Step) error {
+	urn := step.URN()
+	rp, ok := p.ResourcePlans[urn]
+	if !ok {
+		return errors.Errorf("resource %v violates plan: the plan does not contain this resource", urn)
+	}
+
+	if !rp.allowsOp(step.Op()) {
+		return errors.Errorf("resource %v violates plan: operation %v is not one of the planned operations %v",
+			urn, step.Op(), rp.Ops)
+	}
+
+	// Deletes do not carry new inputs, and the inputs of a read or register are only checked if the plan expected
+	// the program to provide them.
+	if step.New() == nil || rp.Inputs == nil || step.Op() == OpRemovePendingReplace {
+		return nil
+	}
+	if err := checkPlannedProperties(rp.Inputs, step.New().Inputs, ""); err != nil {
+		return errors.Errorf("resource %v violates plan: %v", urn, err)
+	}
+	return nil
+}
+
+// allowsOp returns true if the given operation is permitted by this resource plan. Previews cannot always tell
+// whether a resource will change, so a plan that expects a resource to be updated or replaced also allows it to be
+// left unchanged, and a plan that expects it to be replaced also allows it to be updated in place.
+func (rp *ResourcePlan) allowsOp(op StepOp) bool {
+	has := func(ops ...StepOp) bool {
+		for _, planned := range rp.Ops {
+			for _, o := range ops {
+				if planned == o {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch op {
+	case OpSame:
+		return has(OpSame, OpUpdate, OpReplace, OpCreateReplacement)
+	case OpUpdate:
+		return has(OpUpdate, OpReplace, OpCreateReplacement)
+	default:
+		return has(op)
+	}
+}
+
+// checkPlannedProperties returns an error if the actual properties do not match the planned properties. Values that
+// were unknown when the plan was made match any actual value.
+func checkPlannedProperties(planned, actual resource.PropertyMap, path string) error {
+	keys := make(map[resource.PropertyKey]bool)
+	for k := range planned {
+		keys[k] = true
+	}
+	for k := range actual {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, string(k))
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		key := resource.PropertyKey(k)
+		p := path + "." + k
+		if path == "" {
+			p = k
+		}
+
+		plannedValue, hasPlanned := planned[key]
+		actualValue, hasActual := actual[key]
+		switch {
+		case !hasPlanned:
+			return errors.Errorf("input %v was not in the plan", p)
+		case !hasActual:
+			return errors.Errorf("planned input %v is missing", p)
+		}
+		if err := checkPlannedValue(plannedValue, actualValue, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkPlannedValue(planned, actual resource.PropertyValue, path string) error {
+	// Secret values are compared by their underlying values, which must not appear in any errors.
+	secret := planned.ContainsSecrets() || actual.ContainsSecrets()
+	for planned.IsSecret() {
+		planned = planned.SecretValue().Element
+	}
+	for actual.IsSecret() {
+		actual = actual.SecretValue().Element
+	}
+
+	// Unknown values in the plan match any value, and unknown values seen while previewing against a plan are only
+	// known, and so only checked, once the plan is applied.
+	if planned.IsComputed() || planned.IsOutput() || actual.IsComputed() || actual.IsOutput() {
+		return nil
+	}
+
+	switch {
+	case planned.IsObject() && actual.IsObject():
+		return checkPlannedProperties(planned.ObjectValue(), actual.ObjectValue(), path)
+	case planned.IsArray() && actual.IsArray():
+		plannedArr, actualArr := planned.ArrayValue(), actual.ArrayValue()
+		if len(plannedArr) != len(actualArr) {
+			return errors.Errorf("input %v has %d elements, but the plan expected %d",
+				path, len(actualArr), len(plannedArr))
+		}
+		for i := range plannedArr {
+			if err := checkPlannedValue(plannedArr[i], actualArr[i], fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case !planned.DeepEquals(actual):
+		if secret {
+			return errors.Errorf("input %v has a secret value that differs from the plan", path)
+		}
+		return errors.Errorf("input %v has value %v, but the plan expected %v", path, actual.Mappable(),
+			planned.Mappable())
+	}
+	return nil
+}
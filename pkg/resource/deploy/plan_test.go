@@ -0,0 +1,163 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestPlanAllowsOp(t *testing.T) {
+	cases := []struct {
+		planned []StepOp
+		op      StepOp
+		allowed bool
+	}{
+		{[]StepOp{OpCreate}, OpCreate, true},
+		{[]StepOp{OpCreate}, OpUpdate, false},
+		{[]StepOp{OpSame}, OpSame, true},
+		{[]StepOp{OpSame}, OpUpdate, false},
+		{[]StepOp{OpUpdate}, OpSame, true},
+		{[]StepOp{OpReplace, OpCreateReplacement, OpDeleteReplaced}, OpUpdate, true},
+		{[]StepOp{OpReplace, OpCreateReplacement, OpDeleteReplaced}, OpSame, true},
+		{[]StepOp{OpReplace, OpCreateReplacement, OpDeleteReplaced}, OpDeleteReplaced, true},
+		{[]StepOp{OpUpdate}, OpReplace, false},
+		{[]StepOp{OpDelete}, OpSame, false},
+		{[]StepOp{OpSame}, OpDelete, false},
+	}
+	for _, c := range cases {
+		rp := &ResourcePlan{Ops: c.planned}
+		assert.Equal(t, c.allowed, rp.allowsOp(c.op), "%v -> %v", c.planned, c.op)
+	}
+}
+
+func TestCheckPlannedProperties(t *testing.T) {
+	cases := []struct {
+		name    string
+		planned resource.PropertyMap
+		actual  resource.PropertyMap
+		err     string
+	}{
+		{
+			name:    "equal",
+			planned: resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo", "b": []interface{}{1.0}}),
+			actual:  resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo", "b": []interface{}{1.0}}),
+		},
+		{
+			name:    "different value",
+			planned: resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo"}),
+			actual:  resource.NewPropertyMapFromMap(map[string]interface{}{"a": "bar"}),
+			err:     "input a has value bar, but the plan expected foo",
+		},
+		{
+			name:    "extra key",
+			planned: resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo"}),
+			actual:  resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo", "b": "bar"}),
+			err:     "input b was not in the plan",
+		},
+		{
+			name:    "missing key",
+			planned: resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo", "b": "bar"}),
+			actual:  resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo"}),
+			err:     "planned input b is missing",
+		},
+		{
+			name: "unknown",
+			planned: resource.PropertyMap{
+				"a": resource.MakeComputed(resource.NewStringProperty("")),
+			},
+			actual: resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo"}),
+		},
+		{
+			name: "nested unknown",
+			planned: resource.PropertyMap{
+				"a": resource.NewObjectProperty(resource.PropertyMap{
+					"b": resource.NewArrayProperty([]resource.PropertyValue{
+						resource.NewStringProperty("foo"),
+						resource.MakeComputed(resource.NewStringProperty("")),
+					}),
+				}),
+			},
+			actual: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"a": map[string]interface{}{"b": []interface{}{"foo", "bar"}},
+			}),
+		},
+		{
+			name: "nested difference",
+			planned: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"a": map[string]interface{}{"b": []interface{}{"foo", "bar"}},
+			}),
+			actual: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"a": map[string]interface{}{"b": []interface{}{"foo", "baz"}},
+			}),
+			err: "input a.b[1] has value baz, but the plan expected bar",
+		},
+		{
+			name: "array length",
+			planned: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"a": []interface{}{"foo"},
+			}),
+			actual: resource.NewPropertyMapFromMap(map[string]interface{}{
+				"a": []interface{}{"foo", "bar"},
+			}),
+			err: "input a has 2 elements, but the plan expected 1",
+		},
+		{
+			name: "secret",
+			planned: resource.PropertyMap{
+				"a": resource.MakeSecret(resource.NewStringProperty("foo")),
+			},
+			actual: resource.PropertyMap{
+				"a": resource.MakeSecret(resource.NewStringProperty("foo")),
+			},
+		},
+		{
+			name: "secret difference",
+			planned: resource.PropertyMap{
+				"a": resource.MakeSecret(resource.NewStringProperty("foo")),
+			},
+			actual: resource.PropertyMap{
+				"a": resource.MakeSecret(resource.NewStringProperty("bar")),
+			},
+			err: "input a has a secret value that differs from the plan",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkPlannedProperties(c.planned, c.actual, "")
+			if c.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, c.err)
+			}
+		})
+	}
+}
+
+func TestPlanCheckStep(t *testing.T) {
+	urn := resource.URN("urn:pulumi:stack::project::pkg:index:typ::a")
+	other := resource.URN("urn:pulumi:stack::project::pkg:index:typ::b")
+	inputs := resource.NewPropertyMapFromMap(map[string]interface{}{"a": "foo"})
+
+	plan := NewPlan()
+	plan.ResourcePlans[urn] = &ResourcePlan{Ops: []StepOp{OpCreate}, Inputs: inputs}
+
+	newState := func(urn resource.URN, inputs resource.PropertyMap) *resource.State {
+		return &resource.State{URN: urn, Type: urn.Type(), Inputs: inputs}
+	}
+
+	assert.NoError(t, plan.checkStep(&CreateStep{new: newState(urn, inputs)}))
+
+	err := plan.checkStep(&CreateStep{new: newState(other, inputs)})
+	assert.EqualError(t, err, "resource "+string(other)+" violates plan: the plan does not contain this resource")
+
+	err = plan.checkStep(&DeleteStep{old: newState(urn, inputs)})
+	assert.EqualError(t, err,
+		"resource "+string(urn)+" violates plan: operation delete is not one of the planned operations [create]")
+
+	changed := resource.NewPropertyMapFromMap(map[string]interface{}{"a": "bar"})
+	err = plan.checkStep(&CreateStep{new: newState(urn, changed)})
+	assert.EqualError(t, err,
+		"resource "+string(urn)+" violates plan: input a has value bar, but the plan expected foo")
+}
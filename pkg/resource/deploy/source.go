@@ -88,7 +88,8 @@ type RegisterResourceEvent interface {
 
 // RegisterResult is the state of the resource after it has been registered.
 type RegisterResult struct {
-	State *resource.State // the resource state.
+	State  *resource.State // the resource state.
+	Failed bool            // true if the resource could not be registered because an operation on it failed.
 }
 
 // RegisterResourceOutputsEvent is an event that asks the engine to complete the provisioning of a resource.
@@ -127,5 +128,6 @@ type ReadResourceEvent interface {
 }
 
 type ReadResult struct {
-	State *resource.State
+	State  *resource.State
+	Failed bool // true if the resource could not be read because an operation on it failed.
 }
@@ -303,7 +303,7 @@ func (d *defaultProviders) newRegisterDefaultProviderEvent(
 	event := &registerResourceEvent{
 		goal: resource.NewGoal(
 			providers.MakeProviderType(req.Package()),
-			req.Name(), true, inputs, "", false, nil, "", nil, nil, nil, nil, nil, nil, "", nil),
+			req.Name(), true, inputs, "", false, nil, "", nil, nil, nil, nil, nil, nil, "", nil, false, nil, ""),
 		done: done,
 	}
 	return event, done, nil
@@ -350,6 +350,10 @@ func (d *defaultProviders) handleRequest(req providers.ProviderRequest) (provide
 		return providers.Reference{}, context.Canceled
 	}
 
+	if result.Failed {
+		return providers.Reference{}, errors.Errorf("failed to register the default provider for package %s", req)
+	}
+
 	logging.V(5).Infof("registered default provider for package %s: %s", req, result.State.URN)
 
 	id := result.State.ID
@@ -729,6 +733,10 @@ func (rm *resmon) ReadResource(ctx context.Context,
 	}
 
 	contract.Assert(result != nil)
+	if result.Failed {
+		return nil, errors.Errorf("failed to read resource %s", name)
+	}
+
 	marshaled, err := plugin.MarshalProperties(result.State.Outputs, plugin.MarshalOptions{
 		Label:         label,
 		KeepUnknowns:  true,
@@ -759,6 +767,9 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	ignoreChanges := req.GetIgnoreChanges()
 	id := resource.ID(req.GetImportId())
 	customTimeouts := req.GetCustomTimeouts()
+	retainOnDelete := req.GetRetainOnDelete()
+	replaceOnChanges := req.GetReplaceOnChanges()
+	deletedWith := resource.URN(req.GetDeletedWith())
 
 	// Custom resources must have a three-part type so that we can 1) identify if they are providers and 2) retrieve the
 	// provider responsible for managing a particular resource (based on the type's Package).
@@ -881,9 +892,9 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	logging.V(5).Infof(
 		"ResourceMonitor.RegisterResource received: t=%v, name=%v, custom=%v, #props=%v, parent=%v, protect=%v, "+
 			"provider=%v, deps=%v, deleteBeforeReplace=%v, ignoreChanges=%v, aliases=%v, customTimeouts=%v, "+
-			"providers=%v",
+			"providers=%v, retainOnDelete=%v, replaceOnChanges=%v, deletedWith=%v",
 		t, name, custom, len(props), parent, protect, providerRef, dependencies, deleteBeforeReplace, ignoreChanges,
-		aliases, timeouts, providerRefs)
+		aliases, timeouts, providerRefs, retainOnDelete, replaceOnChanges, deletedWith)
 
 	// If this is a remote component, fetch its provider and issue the construct call. Otherwise, register the resource.
 	var result *RegisterResult
@@ -920,7 +931,7 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 		step := &registerResourceEvent{
 			goal: resource.NewGoal(t, name, custom, props, parent, protect, dependencies,
 				providerRef.String(), nil, propertyDependencies, deleteBeforeReplace, ignoreChanges,
-				additionalSecretOutputs, aliases, id, &timeouts),
+				additionalSecretOutputs, aliases, id, &timeouts, retainOnDelete, replaceOnChanges, deletedWith),
 			done: make(chan *RegisterResult),
 		}
 
@@ -939,6 +950,9 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 			return nil, rpcerror.New(codes.Unavailable, "resource monitor shut down while waiting on step's done channel")
 		}
 	}
+	if result.Failed {
+		return nil, errors.Errorf("failed to register resource %s", name)
+	}
 
 	// Filter out partially-known values if the requestor does not support them.
 	outputs := result.State.Outputs
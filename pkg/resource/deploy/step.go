@@ -255,9 +255,10 @@ func (s *CreateStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 // DeleteStep is a mutating step that deletes an existing resource. If `old` is marked "External",
 // DeleteStep is a no-op.
 type DeleteStep struct {
-	deployment *Deployment     // the current deployment.
-	old        *resource.State // the state of the existing resource.
-	replacing  bool            // true if part of a replacement.
+	deployment  *Deployment     // the current deployment.
+	old         *resource.State // the state of the existing resource.
+	replacing   bool            // true if part of a replacement.
+	deletedWith bool            // true if the resource's DeletedWith resource is deleted in the same deployment.
 }
 
 var _ Step = (*DeleteStep)(nil)
@@ -332,8 +333,10 @@ func (s *DeleteStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 				"`pulumi state unprotect %s`", s.old.URN, s.old.URN)
 	}
 
-	// Deleting an External resource is a no-op, since Pulumi does not own the lifecycle.
-	if !preview && !s.old.External {
+	// Deleting an External resource is a no-op, since Pulumi does not own the lifecycle. Likewise, deleting a
+	// resource that is retained on deletion only removes it from the snapshot, leaving the actual resource intact,
+	// and deleting a resource whose DeletedWith resource is also being deleted leaves that deletion to remove it.
+	if !preview && !s.old.External && !s.old.RetainOnDelete && !s.deletedWith {
 		if s.old.Custom {
 			// Invoke the Delete RPC function for this provider:
 			prov, err := getProvider(s)
@@ -761,7 +764,7 @@ func (s *RefreshStep) Apply(preview bool) (resource.Status, StepCompleteFunc, er
 		s.new = resource.NewState(s.old.Type, s.old.URN, s.old.Custom, s.old.Delete, resourceID, inputs, outputs,
 			s.old.Parent, s.old.Protect, s.old.External, s.old.Dependencies, initErrors, s.old.Provider,
 			s.old.PropertyDependencies, s.old.PendingReplacement, s.old.AdditionalSecretOutputs, s.old.Aliases,
-			&s.old.CustomTimeouts, s.old.ImportID)
+			&s.old.CustomTimeouts, s.old.ImportID, s.old.RetainOnDelete, s.old.ReplaceOnChanges, s.old.DeletedWith)
 	} else {
 		s.new = nil
 	}
@@ -903,7 +906,8 @@ func (s *ImportStep) Apply(preview bool) (resource.Status, StepCompleteFunc, err
 	// differences between the old and new states are between the inputs and outputs.
 	s.old = resource.NewState(s.new.Type, s.new.URN, s.new.Custom, false, s.new.ID, read.Inputs, read.Outputs,
 		s.new.Parent, s.new.Protect, false, s.new.Dependencies, s.new.InitErrors, s.new.Provider,
-		s.new.PropertyDependencies, false, nil, nil, &s.new.CustomTimeouts, s.new.ImportID)
+		s.new.PropertyDependencies, false, nil, nil, &s.new.CustomTimeouts, s.new.ImportID, s.new.RetainOnDelete,
+		s.new.ReplaceOnChanges, s.new.DeletedWith)
 
 	// If this step came from an import deployment, we need to fetch any required inputs from the state.
 	if s.planned {
@@ -1142,6 +1146,25 @@ func (op StepOp) Suffix() string {
 	return ""
 }
 
+// failStep reports to the program that registered or read the given step's resource, if any, that the step failed
+// without producing a state for the resource. The program would otherwise wait for the resource forever.
+func failStep(step Step) {
+	switch s := step.(type) {
+	case *SameStep:
+		s.reg.Done(&RegisterResult{State: s.new, Failed: true})
+	case *CreateStep:
+		s.reg.Done(&RegisterResult{State: s.new, Failed: true})
+	case *UpdateStep:
+		s.reg.Done(&RegisterResult{State: s.new, Failed: true})
+	case *ImportStep:
+		if s.reg != nil {
+			s.reg.Done(&RegisterResult{State: s.new, Failed: true})
+		}
+	case *ReadStep:
+		s.event.Done(&ReadResult{State: s.new, Failed: true})
+	}
+}
+
 // getProvider fetches the provider for the given step.
 func getProvider(s Step) (plugin.Provider, error) {
 	if providers.IsProviderType(s.Type()) {
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
@@ -71,10 +72,17 @@ func (c completionToken) Wait(ctx context.Context) {
 	}
 }
 
+// stepFailure records a step that failed and the error with which it failed.
+type stepFailure struct {
+	Step Step
+	Err  error
+}
+
 // incomingChain represents a request to the step executor to execute a chain.
 type incomingChain struct {
 	Chain          chain     // The chain we intend to execute
 	CompletionChan chan bool // A completion channel to be closed when the chain has completed execution
+	Release        func()    // If non-nil, releases the parallelism limits already acquired for the chain's first step
 }
 
 // stepExecutor is the component of the engine responsible for taking steps and executing
@@ -85,18 +93,26 @@ type incomingChain struct {
 // resolved, we (the engine) can assume that any chain given to us by the step generator is already
 // ready to execute.
 type stepExecutor struct {
-	deployment      *Deployment // The deployment currently being executed.
-	opts            Options     // The options for this current deployment.
-	preview         bool        // Whether or not we are doing a preview.
-	pendingNews     sync.Map    // Resources that have been created but are pending a RegisterResourceOutputs.
-	continueOnError bool        // True if we want to continue the deployment after a step error.
+	deployment      *Deployment  // The deployment currently being executed.
+	opts            Options      // The options for this current deployment.
+	preview         bool         // Whether or not we are doing a preview.
+	pendingNews     sync.Map     // Resources that have been created but are pending a RegisterResourceOutputs.
+	continueOnError bool         // True if we want to continue the deployment after a step error.
+	limiter         *stepLimiter // Per-provider package and per-resource type parallelism limits, if any.
+	retries         retryPolicy  // The policy for retrying steps that fail with transient provider errors.
 
 	workers        sync.WaitGroup     // WaitGroup tracking the worker goroutines that are owned by this step executor.
+	chains         sync.WaitGroup     // WaitGroup tracking the chains that have been submitted but not yet completed.
 	incomingChains chan incomingChain // Incoming chains that we are to execute
+	resumedChains  chan incomingChain // Parked chains whose next step has acquired its parallelism limits
 
 	ctx      context.Context    // cancellation context for the current deployment.
 	cancel   context.CancelFunc // CancelFunc that cancels the above context.
 	sawError atomic.Value       // atomic boolean indicating whether or not the step excecutor saw that there was an error.
+
+	failuresLock sync.Mutex            // Lock protecting failures and failed.
+	failures     []stepFailure         // The steps that failed, in the order in which they failed.
+	failed       map[resource.URN]bool // The URNs of the resources whose steps failed.
 }
 
 //
@@ -112,9 +128,11 @@ func (se *stepExecutor) ExecuteSerial(chain chain) completionToken {
 	// If one is pending, we should exit early - we will shortly be tearing down the engine and exiting.
 
 	completion := make(chan bool)
+	se.chains.Add(1)
 	select {
 	case se.incomingChains <- incomingChain{Chain: chain, CompletionChan: completion}:
 	case <-se.ctx.Done():
+		se.chains.Done()
 		close(completion)
 	}
 
@@ -187,10 +205,33 @@ func (se *stepExecutor) Errored() bool {
 	return se.sawError.Load().(bool)
 }
 
+// Failures returns the steps that have failed so far, in the order in which they failed.
+func (se *stepExecutor) Failures() []stepFailure {
+	se.failuresLock.Lock()
+	defer se.failuresLock.Unlock()
+
+	failures := make([]stepFailure, len(se.failures))
+	copy(failures, se.failures)
+	return failures
+}
+
+// Failed returns true if a step for the resource with the given URN has failed.
+func (se *stepExecutor) Failed(urn resource.URN) bool {
+	se.failuresLock.Lock()
+	defer se.failuresLock.Unlock()
+
+	return se.failed[urn]
+}
+
 // SignalCompletion signals to the stepExecutor that there are no more chains left to execute. All worker
 // threads will terminate as soon as they retire all of the work they are currently executing.
 func (se *stepExecutor) SignalCompletion() {
-	close(se.incomingChains)
+	// Chains that are parked on a parallelism limit are handed back to the workers once they can proceed, so the
+	// workers must keep running until every chain that has been submitted has completed.
+	go func() {
+		se.chains.Wait()
+		close(se.incomingChains)
+	}()
 }
 
 // WaitForCompletion blocks the calling goroutine until the step executor completes execution of all in-flight
@@ -208,17 +249,34 @@ func (se *stepExecutor) WaitForCompletion() {
 //
 
 // executeChain executes a chain, one step at a time. If any step in the chain fails to execute, or if the
-// context is canceled, the chain stops execution.
-func (se *stepExecutor) executeChain(workerID int, chain chain) {
-	for _, step := range chain {
+// context is canceled, the chain stops execution. If a step cannot execute yet because of a parallelism limit,
+// the rest of the chain is parked until it can, and executeChain returns false; the chain is then not complete yet.
+func (se *stepExecutor) executeChain(workerID int, request incomingChain) bool {
+	chain, release := request.Chain, request.Release
+	for i, step := range chain {
 		select {
 		case <-se.ctx.Done():
 			se.log(workerID, "step %v on %v canceled", step.Op(), step.URN())
-			return
+			if release != nil {
+				release()
+			}
+			return true
 		default:
 		}
 
-		if err := se.executeStep(workerID, step); err != nil {
+		if release == nil {
+			r, limit, ok := se.limiter.TryAcquire(step)
+			if !ok {
+				se.park(workerID, request, chain[i:], limit)
+				return false
+			}
+			release = r
+		}
+
+		err := se.executeStep(workerID, step)
+		release()
+		release = nil
+		if err != nil {
 			se.log(workerID, "step %v on %v failed, signalling cancellation", step.Op(), step.URN())
 			se.cancelDueToError()
 			if err != errStepApplyFailed {
@@ -229,10 +287,66 @@ func (se *stepExecutor) executeChain(workerID int, chain chain) {
 				// error and that we shouldn't log it. Everything else should be logged to the diag system as usual.
 				diagMsg := diag.RawMessage(step.URN(), err.Error())
 				se.deployment.Diag().Errorf(diagMsg)
+				se.recordFailure(step, err, false)
 			}
-			return
+
+			// If we are continuing after errors, the program that registered the resources affected by the rest of
+			// the chain is still running, so tell it that they failed.
+			if se.continueOnError {
+				for _, rest := range chain[i+1:] {
+					failStep(rest)
+				}
+			}
+			return true
 		}
 	}
+	return true
+}
+
+// park waits for the parallelism limit that is holding back the first step of the given rest of a chain without
+// tying up a worker. Once the step has acquired all of its limits, the rest of the chain is handed back to the
+// workers. If the deployment is canceled in the meantime, the chain is completed without executing the rest of it.
+func (se *stepExecutor) park(workerID int, request incomingChain, rest chain, limit string) {
+	step := rest[0]
+	se.log(workerID, "step %v on %v waiting on parallelism limit for %v", step.Op(), step.URN(), limit)
+	if events := se.opts.Events; events != nil {
+		events.OnResourceStepWaiting(step, limit)
+	}
+
+	go func() {
+		release, ok := se.limiter.Acquire(se.ctx, step, func(string) {})
+		if ok {
+			resumed := incomingChain{Chain: rest, CompletionChan: request.CompletionChan, Release: release}
+			select {
+			case se.resumedChains <- resumed:
+				return
+			case <-se.ctx.Done():
+				release()
+			}
+		}
+
+		se.log(workerID, "step %v on %v canceled", step.Op(), step.URN())
+		se.completeChain(request)
+	}()
+}
+
+// completeChain signals that the given chain has completed execution.
+func (se *stepExecutor) completeChain(request incomingChain) {
+	close(request.CompletionChan)
+	se.chains.Done()
+}
+
+// recordFailure records that the given step failed with the given error. If we are continuing after errors and the
+// step did not report its result to the program that registered its resource, it reports the failure instead.
+func (se *stepExecutor) recordFailure(step Step, err error, reported bool) {
+	se.failuresLock.Lock()
+	se.failures = append(se.failures, stepFailure{Step: step, Err: err})
+	se.failed[step.URN()] = true
+	se.failuresLock.Unlock()
+
+	if se.continueOnError && !reported {
+		failStep(step)
+	}
 }
 
 func (se *stepExecutor) cancelDueToError() {
@@ -268,7 +382,7 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 	}
 
 	se.log(workerID, "applying step %v on %v (preview %v)", step.Op(), step.URN(), se.preview)
-	status, stepComplete, err := step.Apply(se.preview)
+	status, stepComplete, err := se.applyStep(workerID, step)
 
 	if err == nil {
 		// If we have a state object, and this is a create or update, remember it, as we may need to update it later.
@@ -314,12 +428,49 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 
 	if err != nil {
 		se.log(workerID, "step %v on %v failed with an error: %v", step.Op(), step.URN(), err)
+		se.recordFailure(step, err, stepComplete != nil)
 		return errStepApplyFailed
 	}
 
 	return nil
 }
 
+// applyStep applies the given step. If the step fails with a transient provider error, such as the provider's API
+// throttling requests or being briefly unavailable, it is retried with exponential backoff until it succeeds, fails
+// with an error that is not transient, or runs out of the time allotted to the resource's operation.
+func (se *stepExecutor) applyStep(workerID int, step Step) (resource.Status, StepCompleteFunc, error) {
+	status, stepComplete, err := step.Apply(se.preview)
+	if err == nil || !callsProvider(step.Op()) {
+		return status, stepComplete, err
+	}
+
+	deadline := time.Now().Add(se.retries.timeout(step))
+	delay := se.retries.initialDelay
+	for attempt := 1; err != nil && status == resource.StatusOK && isRetryableError(err); attempt++ {
+		if time.Now().Add(delay).After(deadline) {
+			se.log(workerID, "step %v on %v out of time to retry: %v", step.Op(), step.URN(), err)
+			break
+		}
+
+		se.log(workerID, "step %v on %v failed with a transient error, retrying in %v: %v",
+			step.Op(), step.URN(), delay, err)
+		se.deployment.Diag().Warningf(diag.RawMessage(step.URN(),
+			fmt.Sprintf("%v failed with a transient error (attempt %d); retrying in %v: %v",
+				step.Op(), attempt, delay, err)))
+
+		select {
+		case <-time.After(delay):
+		case <-se.ctx.Done():
+			se.log(workerID, "step %v on %v canceled while waiting to retry", step.Op(), step.URN())
+			return status, stepComplete, err
+		}
+
+		status, stepComplete, err = step.Apply(se.preview)
+		delay = se.retries.nextDelay(delay)
+	}
+	return status, stepComplete, err
+}
+
 // log is a simple logging helper for the step executor.
 func (se *stepExecutor) log(workerID int, msg string, args ...interface{}) {
 	if logging.V(stepExecutorLogLevel) {
@@ -353,36 +504,41 @@ func (se *stepExecutor) worker(workerID int, launchAsync bool) {
 	oneshotWorkerID := 0
 	for {
 		se.log(workerID, "worker waiting for incoming chains")
+		var request incomingChain
 		select {
-		case request := <-se.incomingChains:
+		case request = <-se.incomingChains:
 			if request.Chain == nil {
 				se.log(workerID, "worker received nil chain, exiting")
 				return
 			}
-
 			se.log(workerID, "worker received chain for execution")
-			if !launchAsync {
-				se.executeChain(workerID, request.Chain)
-				close(request.CompletionChan)
-				continue
-			}
-
-			// If we're launching asynchronously, make up a new worker ID for this new oneshot worker and record its
-			// launch with our worker wait group.
-			se.workers.Add(1)
-			newWorkerID := oneshotWorkerID
-			go func() {
-				defer se.workers.Done()
-				se.log(newWorkerID, "launching oneshot worker")
-				se.executeChain(newWorkerID, request.Chain)
-				close(request.CompletionChan)
-			}()
-
-			oneshotWorkerID++
+		case request = <-se.resumedChains:
+			se.log(workerID, "worker received parked chain for execution")
 		case <-se.ctx.Done():
 			se.log(workerID, "worker exiting due to cancellation")
 			return
 		}
+
+		if !launchAsync {
+			if se.executeChain(workerID, request) {
+				se.completeChain(request)
+			}
+			continue
+		}
+
+		// If we're launching asynchronously, make up a new worker ID for this new oneshot worker and record its
+		// launch with our worker wait group.
+		se.workers.Add(1)
+		newWorkerID := oneshotWorkerID
+		go func() {
+			defer se.workers.Done()
+			se.log(newWorkerID, "launching oneshot worker")
+			if se.executeChain(newWorkerID, request) {
+				se.completeChain(request)
+			}
+		}()
+
+		oneshotWorkerID++
 	}
 }
 
@@ -393,9 +549,13 @@ func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment
 		opts:            opts,
 		preview:         preview,
 		continueOnError: continueOnError,
+		limiter:         newStepLimiter(opts.ParallelLimits),
+		retries:         defaultRetryPolicy,
 		incomingChains:  make(chan incomingChain),
+		resumedChains:   make(chan incomingChain),
 		ctx:             ctx,
 		cancel:          cancel,
+		failed:          make(map[resource.URN]bool),
 	}
 
 	exec.sawError.Store(false)
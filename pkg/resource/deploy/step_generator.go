@@ -15,6 +15,7 @@
 package deploy
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -37,8 +38,9 @@ type stepGenerator struct {
 	deployment *Deployment // the deployment to which this step generator belongs
 	opts       Options     // options for this step generator
 
-	updateTargetsOpt  map[resource.URN]bool // the set of resources to update; resources not in this set will be same'd
-	replaceTargetsOpt map[resource.URN]bool // the set of resoures to replace
+	updateTargetsOpt  *targetSet // the set of resources to update; resources not in this set will be same'd
+	replaceTargetsOpt *targetSet // the set of resoures to replace
+	excludeTargetsOpt *targetSet // the set of resources to leave untouched; these will be same'd
 
 	// signals that one or more errors have been reported to the user, and the deployment should terminate
 	// in error. This primarily allows `preview` to aggregate many policy violation events and
@@ -68,25 +70,91 @@ type stepGenerator struct {
 	aliased map[resource.URN]resource.URN
 }
 
+// skip records that the resource with the given URN was skipped by the deployment, so that it is left untouched
+// rather than deleted.
+func (sg *stepGenerator) skip(urn resource.URN) {
+	sg.urns[urn] = true
+	sg.sames[urn] = true
+}
+
 func (sg *stepGenerator) isTargetedUpdate() bool {
-	return sg.updateTargetsOpt != nil || sg.replaceTargetsOpt != nil
+	return sg.updateTargetsOpt != nil || sg.replaceTargetsOpt != nil || sg.excludeTargetsOpt != nil
 }
 
 func (sg *stepGenerator) isTargetedForUpdate(urn resource.URN) bool {
-	return sg.updateTargetsOpt == nil || sg.updateTargetsOpt[urn]
+	return (sg.updateTargetsOpt == nil || sg.updateTargetsOpt.Contains(urn)) && !sg.isExcluded(urn)
+}
+
+func (sg *stepGenerator) isExcluded(urn resource.URN) bool {
+	return sg.excludeTargetsOpt.Contains(urn)
+}
+
+// dependsOnExcluded returns the first excluded resource that the given resource references through its parent,
+// provider, or dependencies, or the empty URN if it references none.
+func (sg *stepGenerator) dependsOnExcluded(parent resource.URN, provider string,
+	dependencies []resource.URN) resource.URN {
+
+	if sg.isExcluded(parent) {
+		return parent
+	}
+	if provider != "" {
+		if ref, err := providers.ParseReference(provider); err == nil && sg.isExcluded(ref.URN()) {
+			return ref.URN()
+		}
+	}
+	for _, dep := range dependencies {
+		if sg.isExcluded(dep) {
+			return dep
+		}
+	}
+	return ""
 }
 
 func (sg *stepGenerator) isTargetedReplace(urn resource.URN) bool {
-	return sg.replaceTargetsOpt != nil && sg.replaceTargetsOpt[urn]
+	return sg.replaceTargetsOpt.Contains(urn)
 }
 
 func (sg *stepGenerator) Errored() bool {
 	return sg.sawError
 }
 
+// checkPlan records the given steps in the plan for this deployment and, if the deployment must follow a plan,
+// ensures that the plan allows each of them.
+func (sg *stepGenerator) checkPlan(steps []Step) result.Result {
+	if sg.opts.Plan != nil {
+		violated := false
+		for _, step := range steps {
+			if err := sg.opts.Plan.checkStep(step); err != nil {
+				sg.deployment.Diag().Errorf(diag.RawMessage(step.URN(), err.Error()))
+				sg.sawError = true
+				violated = true
+			}
+		}
+
+		// In preview we keep going so that the user will hear about every way in which the program differs from
+		// the plan. Otherwise, we must not perform any operation that the plan does not allow.
+		if violated && !sg.deployment.preview {
+			return result.Bail()
+		}
+	}
+	sg.deployment.plans.recordSteps(steps)
+	return nil
+}
+
 // GenerateReadSteps is responsible for producing one or more steps required to service
 // a ReadResourceEvent coming from the language host.
 func (sg *stepGenerator) GenerateReadSteps(event ReadResourceEvent) ([]Step, result.Result) {
+	steps, res := sg.generateReadSteps(event)
+	if res != nil {
+		return nil, res
+	}
+	if res := sg.checkPlan(steps); res != nil {
+		return nil, res
+	}
+	return steps, nil
+}
+
+func (sg *stepGenerator) generateReadSteps(event ReadResourceEvent) ([]Step, result.Result) {
 	urn := sg.deployment.generateURN(event.Parent(), event.Type(), event.Name())
 	newState := resource.NewState(event.Type(),
 		urn,
@@ -104,9 +172,12 @@ func (sg *stepGenerator) GenerateReadSteps(event ReadResourceEvent) ([]Step, res
 		nil,   /* propertyDependencies */
 		false, /* deleteBeforeCreate */
 		event.AdditionalSecretOutputs(),
-		nil, /* aliases */
-		nil, /* customTimeouts */
-		"",  /* importID */
+		nil,   /* aliases */
+		nil,   /* customTimeouts */
+		"",    /* importID */
+		false, /* retainOnDelete */
+		nil,   /* replaceOnChanges */
+		"",    /* deletedWith */
 	)
 	old, hasOld := sg.deployment.Olds()[urn]
 
@@ -155,18 +226,31 @@ func (sg *stepGenerator) GenerateSteps(event RegisterResourceEvent) ([]Step, res
 		return nil, res
 	}
 	if !sg.isTargetedUpdate() {
+		if res := sg.checkPlan(steps); res != nil {
+			return nil, res
+		}
 		return steps, nil
 	}
 
 	// We got a set of steps to perfom during a targeted update. If any of the steps are not same steps and depend on
 	// creates we skipped because they were not in the --target list, issue an error that that the create was necessary
 	// and that the user must target the resource to create.
+	//
+	// Same steps for resources that are not excluded are checked as well if they depend on an excluded create, as
+	// their new state would otherwise refer to a resource that does not exist.
 	for _, step := range steps {
-		if step.Op() == OpSame || step.New() == nil {
+		if step.New() == nil {
+			continue
+		}
+		same := step.Op() == OpSame
+		if same && (sg.excludeTargetsOpt == nil || sg.isExcluded(step.URN()) || sg.skippedCreates[step.URN()]) {
 			continue
 		}
 
 		for _, urn := range step.New().Dependencies {
+			if same && !sg.isExcluded(urn) {
+				continue
+			}
 			if sg.skippedCreates[urn] {
 				// Targets were specified, but didn't include this resource to create.  And a
 				// resource we are producing a step for does depend on this created resource.
@@ -174,6 +258,9 @@ func (sg *stepGenerator) GenerateSteps(event RegisterResourceEvent) ([]Step, res
 				// in an error state so that we eventually will error out of the entire
 				// application run.
 				d := diag.GetResourceWillBeCreatedButWasNotSpecifiedInTargetList(step.URN())
+				if sg.isExcluded(urn) {
+					d = diag.GetResourceDependsOnExcludedResourceError(step.URN())
+				}
 
 				sg.deployment.Diag().Errorf(d, step.URN(), urn)
 				sg.sawError = true
@@ -194,6 +281,9 @@ func (sg *stepGenerator) GenerateSteps(event RegisterResourceEvent) ([]Step, res
 		}
 	}
 
+	if res := sg.checkPlan(steps); res != nil {
+		return nil, res
+	}
 	return steps, nil
 }
 
@@ -210,6 +300,15 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 	}
 	sg.urns[urn] = true
 
+	// If the user asked for exclusions to extend to dependents, exclude this resource as well if anything it
+	// references has been excluded. Resources are registered in dependency order, so this propagates transitively.
+	if sg.opts.ExcludeDependents && !sg.isExcluded(urn) {
+		if excluded := sg.dependsOnExcluded(goal.Parent, goal.Provider, goal.Dependencies); excluded != "" {
+			logging.V(7).Infof("Planner excluded '%v' because it depends on excluded resource '%v'", urn, excluded)
+			sg.excludeTargetsOpt.add(urn)
+		}
+	}
+
 	// Check for an old resource so that we can figure out if this is a create, delete, etc., and/or
 	// to diff.  We look up first by URN and then by any provided aliases.  If it is found using an
 	// alias, record that alias so that we do not delete the aliased resource later.
@@ -248,7 +347,8 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 	// get serialized into the checkpoint file.
 	new := resource.NewState(goal.Type, urn, goal.Custom, false, "", inputs, nil, goal.Parent, goal.Protect, false,
 		goal.Dependencies, goal.InitErrors, goal.Provider, goal.PropertyDependencies, false,
-		goal.AdditionalSecretOutputs, goal.Aliases, &goal.CustomTimeouts, "")
+		goal.AdditionalSecretOutputs, goal.Aliases, &goal.CustomTimeouts, "", goal.RetainOnDelete, goal.ReplaceOnChanges,
+		goal.DeletedWith)
 
 	// Mark the URN/resource as having been seen. So we can run analyzers on all resources seen, as well as
 	// lookup providers for calculating replacement of resources that use the provider.
@@ -283,7 +383,7 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 			oldImportID = old.ImportID
 		}
 	}
-	isImport := goal.Custom && goal.ID != "" && (!hasOld || old.External || oldImportID != goal.ID)
+	isImport := goal.Custom && goal.ID != "" && (!hasOld || old.External || oldImportID != goal.ID) && !sg.isExcluded(urn)
 	if isImport {
 		// Write the ID of the resource to import into the new state and return an ImportStep or an
 		// ImportReplacementStep
@@ -298,9 +398,10 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 		return []Step{NewImportStep(sg.deployment, event, new, goal.IgnoreChanges)}, nil
 	}
 
-	// Ensure the provider is okay with this resource and fetch the inputs to pass to subsequent methods.
+	// Ensure the provider is okay with this resource and fetch the inputs to pass to subsequent methods. Excluded
+	// resources are never sent to their provider.
 	var err error
-	if prov != nil {
+	if prov != nil && !sg.isExcluded(urn) {
 		var failures []plugin.CheckFailure
 
 		// If we are re-creating this resource because it was deleted earlier, the old inputs are now
@@ -437,7 +538,13 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 
 		// If the user requested only specific resources to update, and this resource was not in
 		// that set, then do nothin but create a SameStep for it.
-		if !sg.isTargetedForUpdate(urn) {
+		if sg.isExcluded(urn) {
+			// Excluded resources are left exactly as they are, so carry their old inputs and dependencies forward.
+			logging.V(7).Infof("Planner decided not to update '%v' due to being excluded (same)", urn)
+			new.Inputs = oldInputs
+			new.Dependencies = old.Dependencies
+			new.PropertyDependencies = old.PropertyDependencies
+		} else if !sg.isTargetedForUpdate(urn) {
 			logging.V(7).Infof(
 				"Planner decided not to update '%v' due to not being in target group (same) (inputs=%v)", urn, new.Inputs)
 		} else {
@@ -524,6 +631,12 @@ func (sg *stepGenerator) generateStepsFromDiff(
 			"unrecognized diff state for %s: %d", urn, diff.Changes)
 	}
 
+	// Upgrade the diff to a replacement if it changes any properties that the goal state asks to replace on.
+	diff, res := applyReplaceOnChanges(diff, goal.ReplaceOnChanges, oldInputs, inputs)
+	if res != nil {
+		return nil, res
+	}
+
 	// If there were changes, check for a replacement vs. an in-place update.
 	if diff.Changes == plugin.DiffSome {
 		if diff.Replace() {
@@ -619,8 +732,13 @@ func (sg *stepGenerator) generateStepsFromDiff(
 					}
 				}
 
+				// Dependents that are deleted along with this resource, or with another dependent, are not deleted by
+				// their providers. They are only removed once the resources they are deleted with have been deleted.
+				steps = append(steps, NewDeleteReplacementStep(sg.deployment, old, true))
+				markDeletedWith(steps)
+				steps = append(withoutDeletedWith(steps), onlyDeletedWith(steps)...)
+
 				return append(steps,
-					NewDeleteReplacementStep(sg.deployment, old, true),
 					NewReplaceStep(sg.deployment, old, new, diff.ReplaceKeys, diff.ChangedKeys, diff.DetailedDiff, false),
 					NewCreateReplacementStep(
 						sg.deployment, event, old, new, diff.ReplaceKeys, diff.ChangedKeys, diff.DetailedDiff, false),
@@ -656,7 +774,7 @@ func (sg *stepGenerator) generateStepsFromDiff(
 	return nil, nil
 }
 
-func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]Step, result.Result) {
+func (sg *stepGenerator) GenerateDeletes(targetsOpt *targetSet) ([]Step, result.Result) {
 	// To compute the deletion list, we must walk the list of old resources *backwards*.  This is because the list is
 	// stored in dependency order, and earlier elements are possibly leaf nodes for later elements.  We must not delete
 	// dependencies prior to their dependent nodes.
@@ -732,7 +850,7 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 	deletingUnspecifiedTarget := false
 	for _, step := range dels {
 		urn := step.URN()
-		if targetsOpt != nil && !targetsOpt[urn] && !sg.opts.TargetDependents {
+		if targetsOpt != nil && !targetsOpt.Contains(urn) && !sg.opts.TargetDependents {
 			d := diag.GetResourceWillBeDestroyedButWasNotSpecifiedInTargetList(urn)
 
 			// Targets were specified, but didn't include this resource to create.  Report all the
@@ -747,6 +865,11 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 		}
 	}
 
+	dels, res = sg.filterExcludedDeletes(dels)
+	if res != nil {
+		return nil, res
+	}
+
 	if deletingUnspecifiedTarget && !sg.deployment.preview {
 		// In preview we keep going so that the user will hear about all the problems and can then
 		// fix up their command once (as opposed to adding a target, rerunning, adding a target,
@@ -757,11 +880,142 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt map[resource.URN]bool) ([]St
 		return nil, result.Bail()
 	}
 
+	markDeletedWith(dels)
+
+	if res := sg.checkPlan(dels); res != nil {
+		return nil, res
+	}
 	return dels, nil
 }
 
+// filterExcludedDeletes removes the delete steps for any excluded resources from the given list. If an excluded
+// resource that is being kept depends on a resource that would still be deleted, the delete is dropped as well when
+// exclusions extend to dependents, and an error is reported otherwise.
+func (sg *stepGenerator) filterExcludedDeletes(dels []Step) ([]Step, result.Result) {
+	if sg.excludeTargetsOpt == nil || len(dels) == 0 {
+		return dels, nil
+	}
+
+	// Extend the exclusions to any old resources that depend on excluded resources, if requested. The old
+	// resources are in dependency order, so a single forward pass suffices.
+	prev := sg.deployment.prev
+	if sg.opts.ExcludeDependents {
+		for _, res := range prev.Resources {
+			if sg.isExcluded(res.URN) {
+				continue
+			}
+			if excluded := sg.dependsOnExcluded(res.Parent, res.Provider, res.Dependencies); excluded != "" {
+				logging.V(7).Infof("Planner excluded '%v' because it depends on excluded resource '%v'", res.URN, excluded)
+				sg.excludeTargetsOpt.add(res.URN)
+			}
+		}
+	}
+
+	deleting := make(map[resource.URN]bool)
+	for _, step := range dels {
+		if !sg.isExcluded(step.URN()) {
+			deleting[step.URN()] = true
+		}
+	}
+
+	// Now walk the old resources backwards, making sure that no excluded resource that we are keeping depends on a
+	// resource that we are deleting.
+	deletingDependency := false
+	for i := len(prev.Resources) - 1; i >= 0; i-- {
+		res := prev.Resources[i]
+		if deleting[res.URN] || !sg.isExcluded(res.URN) {
+			continue
+		}
+
+		// The provider of a resource that is being kept must be kept as well.
+		if res.Provider != "" {
+			if ref, err := providers.ParseReference(res.Provider); err == nil && deleting[ref.URN()] {
+				logging.V(7).Infof("Planner decided not to delete provider '%v' of excluded resource '%v'",
+					ref.URN(), res.URN)
+				sg.excludeTargetsOpt.add(ref.URN())
+				delete(deleting, ref.URN())
+			}
+		}
+
+		for _, dep := range append([]resource.URN{res.Parent}, res.Dependencies...) {
+			if !deleting[dep] {
+				continue
+			}
+			if sg.opts.ExcludeDependents {
+				logging.V(7).Infof("Planner excluded '%v' because excluded resource '%v' depends on it", dep, res.URN)
+				sg.excludeTargetsOpt.add(dep)
+				delete(deleting, dep)
+				continue
+			}
+
+			sg.deployment.Diag().Errorf(diag.GetResourceWillBeDestroyedButExcludedResourceDependsOnIt(dep), dep, res.URN)
+			sg.sawError = true
+			deletingDependency = true
+		}
+	}
+	if deletingDependency && !sg.deployment.preview {
+		return nil, result.Bail()
+	}
+
+	filtered := []Step{}
+	for _, step := range dels {
+		if deleting[step.URN()] {
+			filtered = append(filtered, step)
+		} else {
+			logging.V(7).Infof("Planner decided not to delete '%v' due to being excluded", step.URN())
+		}
+	}
+	return filtered, nil
+}
+
+// markDeletedWith marks the delete steps for resources whose DeletedWith resource is also deleted by the given steps.
+// Deleting the DeletedWith resource deletes these resources too, so their providers are not asked to delete them.
+func markDeletedWith(dels []Step) {
+	deleting := make(map[resource.URN]bool)
+	for _, step := range dels {
+		if del, ok := step.(*DeleteStep); ok && !del.old.External && !del.old.RetainOnDelete {
+			deleting[del.old.URN] = true
+		}
+	}
+
+	for _, step := range dels {
+		if del, ok := step.(*DeleteStep); ok && del.old.DeletedWith != "" && deleting[del.old.DeletedWith] {
+			logging.V(7).Infof("Planner decided that '%v' is deleted with '%v'", del.old.URN, del.old.DeletedWith)
+			del.deletedWith = true
+		}
+	}
+}
+
+// isDeletedWith returns true if the given step deletes a resource along with its DeletedWith resource.
+func isDeletedWith(step Step) bool {
+	del, ok := step.(*DeleteStep)
+	return ok && del.deletedWith
+}
+
+// onlyDeletedWith returns the steps that delete resources along with their DeletedWith resource.
+func onlyDeletedWith(steps []Step) []Step {
+	var result []Step
+	for _, step := range steps {
+		if isDeletedWith(step) {
+			result = append(result, step)
+		}
+	}
+	return result
+}
+
+// withoutDeletedWith returns the steps that do not delete resources along with their DeletedWith resource.
+func withoutDeletedWith(steps []Step) []Step {
+	var result []Step
+	for _, step := range steps {
+		if !isDeletedWith(step) {
+			result = append(result, step)
+		}
+	}
+	return result
+}
+
 func (sg *stepGenerator) determineAllowedResourcesToDeleteFromTargets(
-	targetsOpt map[resource.URN]bool) (map[resource.URN]bool, result.Result) {
+	targetsOpt *targetSet) (map[resource.URN]bool, result.Result) {
 
 	if targetsOpt == nil {
 		// no specific targets, so we won't filter down anything
@@ -771,14 +1025,12 @@ func (sg *stepGenerator) determineAllowedResourcesToDeleteFromTargets(
 	logging.V(7).Infof("Planner was asked to only delete/update '%v'", targetsOpt)
 	resourcesToDelete := make(map[resource.URN]bool)
 
-	// Now actually use all the requested targets to figure out the exact set to delete.
-	for target := range targetsOpt {
-		current := sg.deployment.olds[target]
-		if current == nil {
-			// user specified a target that didn't exist.  they will have already gotten a warning
-			// about this when we called checkTargets.  explicitly ignore this target since it won't
-			// be something we could possibly be trying to delete, nor could have dependents we
-			// might need to replace either.
+	// Now actually use all the requested targets to figure out the exact set to delete. Any target that
+	// didn't exist will have already produced a warning when we called checkTargets, and is ignored here
+	// since it won't be something we could possibly be trying to delete, nor could have dependents we
+	// might need to replace either.
+	for target, current := range sg.deployment.olds {
+		if !targetsOpt.Contains(target) {
 			continue
 		}
 
@@ -846,6 +1098,10 @@ func (sg *stepGenerator) GeneratePendingDeletes() []Step {
 			}
 		}
 	}
+
+	// Pending deletes finish the work of a previous deployment, so they are recorded in this deployment's plan but
+	// are always allowed.
+	sg.deployment.plans.recordSteps(dels)
 	return dels
 }
 
@@ -873,12 +1129,23 @@ func (sg *stepGenerator) GeneratePendingDeletes() []Step {
 // The resulting list of antichains is a list of list of steps that can be safely executed in parallel. Since we must
 // process deletes in reverse (so we don't delete resources upon which other resources depend), we reverse the list and
 // hand it back to the deployment executor for safe execution.
+//
+// Steps that delete resources along with their DeletedWith resource are not scheduled: the deployment executor performs
+// them once the resources they are deleted with have been deleted. Resources that depend on such a resource are still
+// deleted before that resource's own dependencies.
 func (sg *stepGenerator) ScheduleDeletes(deleteSteps []Step) []antichain {
 	var antichains []antichain                // the list of parallelizable steps we intend to return.
 	dg := sg.deployment.depGraph              // the current deployment's dependency graph.
 	condemned := make(graph.ResourceSet)      // the set of condemned resources.
 	stepMap := make(map[*resource.State]Step) // a map from resource states to the steps that delete them.
 
+	// Set aside the steps that delete resources along with their DeletedWith resource.
+	deletedWith := make(graph.ResourceSet)
+	for _, step := range onlyDeletedWith(deleteSteps) {
+		deletedWith[step.Res()] = true
+	}
+	deleteSteps = withoutDeletedWith(deleteSteps)
+
 	// If we don't trust the dependency graph we've been given, we must be conservative and delete everything serially.
 	if !sg.opts.TrustDependencies {
 		logging.V(7).Infof("Planner does not trust dependency graph, scheduling deletions serially")
@@ -898,12 +1165,28 @@ func (sg *stepGenerator) ScheduleDeletes(deleteSteps []Step) []antichain {
 		stepMap[step.Res()] = step
 	}
 
+	// dependenciesOf returns the dependencies of the given resource, replacing any dependencies that are deleted with
+	// their DeletedWith resource with those resources' own dependencies.
+	var dependenciesOf func(res *resource.State) graph.ResourceSet
+	dependenciesOf = func(res *resource.State) graph.ResourceSet {
+		deps := make(graph.ResourceSet)
+		for dep := range dg.DependenciesOf(res) {
+			deps[dep] = true
+			if deletedWith[dep] {
+				for d := range dependenciesOf(dep) {
+					deps[d] = true
+				}
+			}
+		}
+		return deps
+	}
+
 	for len(condemned) > 0 {
 		var steps antichain
 		logging.V(7).Infof("Planner beginning schedule of new deletion antichain")
 		for res := range condemned {
 			// Does res have any outgoing edges to resources that haven't already been removed from the graph?
-			condemnedDependencies := dg.DependenciesOf(res).Intersect(condemned)
+			condemnedDependencies := dependenciesOf(res).Intersect(condemned)
 			if len(condemnedDependencies) == 0 {
 				// If not, it's safe to delete res at this stage.
 				logging.V(7).Infof("Planner scheduling deletion of '%v'", res.URN)
@@ -1138,6 +1421,92 @@ func processIgnoreChanges(inputs, oldInputs resource.PropertyMap,
 	return ignoredInputs.ObjectValue(), nil
 }
 
+// applyReplaceOnChanges upgrades a diff into a replacement if any of the properties it changes are covered by one of
+// the given replaceOnChanges property paths. The changed properties are taken from the diff's detailed diff or
+// changed keys if the provider reported them, and are otherwise computed from the old and new inputs.
+func applyReplaceOnChanges(diff plugin.DiffResult, replaceOnChanges []string,
+	oldInputs, newInputs resource.PropertyMap) (plugin.DiffResult, result.Result) {
+
+	if diff.Changes != plugin.DiffSome || len(replaceOnChanges) == 0 {
+		return diff, nil
+	}
+
+	var replacePaths []resource.PropertyPath
+	var invalidPaths []string
+	for _, replaceOnChange := range replaceOnChanges {
+		path, err := resource.ParsePropertyPath(replaceOnChange)
+		if err != nil {
+			invalidPaths = append(invalidPaths, replaceOnChange)
+			continue
+		}
+		replacePaths = append(replacePaths, path)
+	}
+	if len(invalidPaths) != 0 {
+		return diff, result.Errorf("cannot replace on changes to the following properties because their paths are "+
+			"invalid: %q", strings.Join(invalidPaths, ", "))
+	}
+
+	// Gather the paths of the changed properties.
+	var changedPaths []string
+	switch {
+	case len(diff.DetailedDiff) != 0:
+		for k := range diff.DetailedDiff {
+			changedPaths = append(changedPaths, k)
+		}
+	case len(diff.ChangedKeys) != 0:
+		for _, k := range diff.ChangedKeys {
+			changedPaths = append(changedPaths, string(k))
+		}
+	default:
+		if inputDiff := oldInputs.Diff(newInputs); inputDiff != nil {
+			for _, k := range inputDiff.Keys() {
+				if inputDiff.Changed(k) {
+					changedPaths = append(changedPaths, string(k))
+				}
+			}
+		}
+	}
+	sort.Strings(changedPaths)
+
+	// A changed property forces a replacement if it lies within one of the replaceOnChanges paths. If it instead
+	// contains one of those paths, it only does so if the inputs at that path have changed.
+	requiresReplace := func(changed resource.PropertyPath) bool {
+		for _, path := range replacePaths {
+			if path.Contains(changed) {
+				return true
+			}
+			if changed.Contains(path) {
+				oldValue, hasOld := path.Get(resource.NewObjectProperty(oldInputs))
+				newValue, hasNew := path.Get(resource.NewObjectProperty(newInputs))
+				if hasOld != hasNew || !oldValue.DeepEquals(newValue) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	replaceKeys := make(map[resource.PropertyKey]bool)
+	for _, k := range diff.ReplaceKeys {
+		replaceKeys[k] = true
+	}
+	for _, k := range changedPaths {
+		changed, err := resource.ParsePropertyPath(k)
+		if err != nil || len(changed) == 0 || !requiresReplace(changed) {
+			continue
+		}
+		if propertyDiff, ok := diff.DetailedDiff[k]; ok {
+			propertyDiff.Kind = propertyDiff.Kind.AsReplace()
+			diff.DetailedDiff[k] = propertyDiff
+		}
+		if key, ok := changed[0].(string); ok && !replaceKeys[resource.PropertyKey(key)] {
+			replaceKeys[resource.PropertyKey(key)] = true
+			diff.ReplaceKeys = append(diff.ReplaceKeys, resource.PropertyKey(key))
+		}
+	}
+	return diff, nil
+}
+
 func (sg *stepGenerator) loadResourceProvider(
 	urn resource.URN, custom bool, provider string, typ tokens.Type) (plugin.Provider, result.Result) {
 
@@ -1261,6 +1630,10 @@ func (sg *stepGenerator) calculateDependentReplacements(root *resource.State) ([
 		if err != nil {
 			return false, nil, result.FromError(err)
 		}
+		diff, res = applyReplaceOnChanges(diff, r.ReplaceOnChanges, r.Inputs, inputsForDiff)
+		if res != nil {
+			return false, nil, res
+		}
 		return diff.Replace(), diff.ReplaceKeys, nil
 	}
 
@@ -1357,13 +1730,15 @@ func (sg *stepGenerator) AnalyzeResources() result.Result {
 
 // newStepGenerator creates a new step generator that operates on the given deployment.
 func newStepGenerator(
-	deployment *Deployment, opts Options, updateTargetsOpt, replaceTargetsOpt map[resource.URN]bool) *stepGenerator {
+	deployment *Deployment, opts Options,
+	updateTargetsOpt, replaceTargetsOpt, excludeTargetsOpt *targetSet) *stepGenerator {
 
 	return &stepGenerator{
 		deployment:           deployment,
 		opts:                 opts,
 		updateTargetsOpt:     updateTargetsOpt,
 		replaceTargetsOpt:    replaceTargetsOpt,
+		excludeTargetsOpt:    excludeTargetsOpt,
 		urns:                 make(map[resource.URN]bool),
 		reads:                make(map[resource.URN]bool),
 		creates:              make(map[resource.URN]bool),
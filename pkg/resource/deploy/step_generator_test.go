@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -113,3 +114,122 @@ func TestIgnoreChanges(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyReplaceOnChanges(t *testing.T) {
+	oldInputs := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "foo",
+			"c": "bar",
+		},
+		"d": 42,
+	}
+
+	cases := []struct {
+		name                string
+		diff                plugin.DiffResult
+		newInputs           map[string]interface{}
+		replaceOnChanges    []string
+		expectedReplace     bool
+		expectedReplaceKeys []resource.PropertyKey
+		expectedDetailDiff  map[string]plugin.PropertyDiff
+		expectFailure       bool
+	}{
+		{
+			name: "No changes",
+			diff: plugin.DiffResult{Changes: plugin.DiffNone},
+			newInputs: map[string]interface{}{
+				"a": map[string]interface{}{"b": "foo", "c": "bar"},
+				"d": 42,
+			},
+			replaceOnChanges: []string{"a", "d"},
+		},
+		{
+			name: "Changed key is replaced",
+			diff: plugin.DiffResult{Changes: plugin.DiffSome, ChangedKeys: []resource.PropertyKey{"d"}},
+			newInputs: map[string]interface{}{
+				"a": map[string]interface{}{"b": "foo", "c": "bar"},
+				"d": 43,
+			},
+			replaceOnChanges:    []string{"d"},
+			expectedReplace:     true,
+			expectedReplaceKeys: []resource.PropertyKey{"d"},
+		},
+		{
+			name: "Unrelated changed key is not replaced",
+			diff: plugin.DiffResult{Changes: plugin.DiffSome, ChangedKeys: []resource.PropertyKey{"d"}},
+			newInputs: map[string]interface{}{
+				"a": map[string]interface{}{"b": "foo", "c": "bar"},
+				"d": 43,
+			},
+			replaceOnChanges: []string{"a"},
+		},
+		{
+			name: "Changed keys are computed from inputs",
+			diff: plugin.DiffResult{Changes: plugin.DiffSome},
+			newInputs: map[string]interface{}{
+				"a": map[string]interface{}{"b": "baz", "c": "bar"},
+				"d": 42,
+			},
+			replaceOnChanges:    []string{"a.b"},
+			expectedReplace:     true,
+			expectedReplaceKeys: []resource.PropertyKey{"a"},
+		},
+		{
+			name: "Unrelated nested change is not replaced",
+			diff: plugin.DiffResult{Changes: plugin.DiffSome, ChangedKeys: []resource.PropertyKey{"a"}},
+			newInputs: map[string]interface{}{
+				"a": map[string]interface{}{"b": "foo", "c": "baz"},
+				"d": 42,
+			},
+			replaceOnChanges: []string{"a.b"},
+		},
+		{
+			name: "Detailed diff is upgraded",
+			diff: plugin.DiffResult{
+				Changes: plugin.DiffSome,
+				DetailedDiff: map[string]plugin.PropertyDiff{
+					"a.b": {Kind: plugin.DiffUpdate},
+					"d":   {Kind: plugin.DiffUpdate},
+				},
+			},
+			newInputs: map[string]interface{}{
+				"a": map[string]interface{}{"b": "baz", "c": "bar"},
+				"d": 43,
+			},
+			replaceOnChanges:    []string{"a"},
+			expectedReplace:     true,
+			expectedReplaceKeys: []resource.PropertyKey{"a"},
+			expectedDetailDiff: map[string]plugin.PropertyDiff{
+				"a.b": {Kind: plugin.DiffUpdateReplace},
+				"d":   {Kind: plugin.DiffUpdate},
+			},
+		},
+		{
+			name: "Invalid paths fail",
+			diff: plugin.DiffResult{Changes: plugin.DiffSome},
+			newInputs: map[string]interface{}{
+				"d": 43,
+			},
+			replaceOnChanges: []string{"a["},
+			expectFailure:    true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			olds, news := resource.NewPropertyMapFromMap(oldInputs), resource.NewPropertyMapFromMap(c.newInputs)
+
+			diff, res := applyReplaceOnChanges(c.diff, c.replaceOnChanges, olds, news)
+			if c.expectFailure {
+				assert.NotNil(t, res)
+				return
+			}
+			assert.Nil(t, res)
+			assert.Equal(t, c.expectedReplace, diff.Replace())
+			assert.Equal(t, c.expectedReplaceKeys, diff.ReplaceKeys)
+			if c.expectedDetailDiff != nil {
+				assert.Equal(t, c.expectedDetailDiff, diff.DetailedDiff)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+)
+
+// stepLimiter caps the number of steps that may execute concurrently against a given provider package (e.g. "aws") or
+// resource type (e.g. "aws:s3/bucket:Bucket"). It is layered on top of the step executor's global degree of
+// parallelism, so that one throttled provider can be held back without also holding back every other provider. The
+// step executor never blocks a worker on a limit: a chain whose next step cannot take its slots straight away is
+// parked until it can, and the worker moves on to other chains in the meantime.
+//
+// Each limit is a counting semaphore. A step that is subject to both a package and a type limit takes a slot from
+// the package limit first, so that steps always acquire their slots in the same order and cannot deadlock.
+type stepLimiter struct {
+	slots map[string]chan struct{} // a semaphore for each limited package or type.
+}
+
+// newStepLimiter creates a step limiter for the given limits. Limits that are not positive are ignored. It returns nil
+// if there is nothing to limit.
+func newStepLimiter(limits map[string]int) *stepLimiter {
+	slots := make(map[string]chan struct{})
+	for key, limit := range limits {
+		if limit > 0 {
+			slots[key] = make(chan struct{}, limit)
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+	return &stepLimiter{slots: slots}
+}
+
+// callsProvider returns true if applying a step with the given op may call the step's resource provider. Only these
+// steps are subject to parallelism limits.
+func callsProvider(op StepOp) bool {
+	switch op {
+	case OpCreate, OpCreateReplacement, OpUpdate, OpDelete, OpDeleteReplaced,
+		OpRead, OpReadReplacement, OpRefresh, OpImport, OpImportReplacement:
+		return true
+	default:
+		return false
+	}
+}
+
+// limitsFor returns the semaphores of the limits that apply to the given step, along with their keys.
+func (l *stepLimiter) limitsFor(step Step) ([]string, []chan struct{}) {
+	if l == nil || !callsProvider(step.Op()) {
+		return nil, nil
+	}
+
+	var keys []string
+	var slots []chan struct{}
+	for _, key := range []string{string(step.Type().Package()), string(step.Type())} {
+		if s, has := l.slots[key]; has {
+			keys, slots = append(keys, key), append(slots, s)
+		}
+	}
+	return keys, slots
+}
+
+// TryAcquire attempts to take a slot for the given step from every limit that applies to it without blocking. It
+// returns a function that releases the step's slots once it has finished executing. If one of the limits is exhausted,
+// TryAcquire gives back any slots that it has already taken and returns the key of that limit and false instead.
+func (l *stepLimiter) TryAcquire(step Step) (func(), string, bool) {
+	keys, slots := l.limitsFor(step)
+
+	acquired := 0
+	release := func() {
+		for i := 0; i < acquired; i++ {
+			<-slots[i]
+		}
+	}
+
+	for i, s := range slots {
+		select {
+		case s <- struct{}{}:
+			acquired++
+		default:
+			release()
+			return nil, keys[i], false
+		}
+	}
+
+	return release, "", true
+}
+
+// Acquire blocks until the given step may execute under every limit that applies to it, or until the given context
+// is canceled. If the step has to wait for a slot, waiting is called with the key of the limit that is holding it back
+// before blocking. Acquire returns a function that releases the step's slots once it has finished executing, and false
+// if the context was canceled before the step could acquire all of them.
+func (l *stepLimiter) Acquire(ctx context.Context, step Step, waiting func(limit string)) (func(), bool) {
+	keys, slots := l.limitsFor(step)
+
+	acquired := 0
+	release := func() {
+		for i := 0; i < acquired; i++ {
+			<-slots[i]
+		}
+	}
+
+	notified := false
+	for i, s := range slots {
+		select {
+		case s <- struct{}{}:
+			acquired++
+			continue
+		default:
+		}
+
+		if !notified {
+			waiting(keys[i])
+			notified = true
+		}
+
+		select {
+		case s <- struct{}{}:
+			acquired++
+		case <-ctx.Done():
+			release()
+			return nil, false
+		}
+	}
+
+	return release, true
+}
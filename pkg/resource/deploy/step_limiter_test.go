@@ -0,0 +1,69 @@
+package deploy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestStepLimiter(t *testing.T) {
+	bucket := &CreateStep{new: &resource.State{Type: "aws:s3/bucket:Bucket"}}
+	queue := &CreateStep{new: &resource.State{Type: "aws:sqs/queue:Queue"}}
+	same := &SameStep{new: &resource.State{Type: "aws:s3/bucket:Bucket"}}
+	other := &CreateStep{new: &resource.State{Type: "gcp:storage/bucket:Bucket"}}
+
+	assert.Nil(t, newStepLimiter(nil))
+	assert.Nil(t, newStepLimiter(map[string]int{"aws": 0}))
+
+	limiter := newStepLimiter(map[string]int{"aws": 2, "aws:s3/bucket:Bucket": 1})
+	noWait := func(limit string) { assert.Fail(t, "unexpected wait on %v", limit) }
+
+	// The first bucket takes a slot from both the package and the type limits.
+	releaseBucket, ok := limiter.Acquire(context.Background(), bucket, noWait)
+	assert.True(t, ok)
+
+	// Same steps and steps for other packages are not limited.
+	releaseSame, ok := limiter.Acquire(context.Background(), same, noWait)
+	assert.True(t, ok)
+	releaseSame()
+	releaseOther, ok := limiter.Acquire(context.Background(), other, noWait)
+	assert.True(t, ok)
+	releaseOther()
+
+	// A second bucket must wait on the type limit, and gives up if its context is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	var waited []string
+	cancel()
+	_, ok = limiter.Acquire(ctx, bucket, func(limit string) { waited = append(waited, limit) })
+	assert.False(t, ok)
+	assert.Equal(t, []string{"aws:s3/bucket:Bucket"}, waited)
+
+	// TryAcquire does not wait, and gives back the package slot that it took.
+	_, limit, ok := limiter.TryAcquire(bucket)
+	assert.False(t, ok)
+	assert.Equal(t, "aws:s3/bucket:Bucket", limit)
+
+	// The canceled bucket released its package slot, so a queue can still proceed.
+	releaseQueue, ok := limiter.Acquire(context.Background(), queue, noWait)
+	assert.True(t, ok)
+
+	// Now the package limit is exhausted, so a further queue must wait until one of the others is released.
+	acquired := make(chan bool)
+	go func() {
+		release, ok := limiter.Acquire(context.Background(), queue, func(string) {})
+		if ok {
+			release()
+		}
+		acquired <- ok
+	}()
+	releaseBucket()
+	assert.True(t, <-acquired)
+	releaseQueue()
+
+	releaseBucket, _, ok = limiter.TryAcquire(bucket)
+	assert.True(t, ok)
+	releaseBucket()
+}
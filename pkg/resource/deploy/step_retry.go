@@ -0,0 +1,92 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+)
+
+// RetryableError may be implemented by errors returned from a provider to mark whether or not the failed operation is
+// transient and may be retried. Providers that run out of process may instead fail with a gRPC status of
+// `Unavailable` or `ResourceExhausted`.
+type RetryableError interface {
+	error
+
+	// Retryable returns true if the failed operation may be retried.
+	Retryable() bool
+}
+
+// isRetryableError returns true if the given error returned by a provider is transient, e.g. because the provider's
+// cloud API throttled the request or was briefly unavailable.
+func isRetryableError(err error) bool {
+	var marker RetryableError
+	if errors.As(err, &marker) {
+		return marker.Retryable()
+	}
+
+	if rpcErr, ok := rpcerror.FromError(err); ok && rpcErr != nil {
+		switch rpcErr.Code() {
+		case codes.Unavailable, codes.ResourceExhausted:
+			return true
+		}
+	}
+	return false
+}
+
+// retryPolicy controls how the step executor retries steps that fail with transient provider errors. The delay between
+// attempts doubles after each retry, and retries stop once the next attempt would begin after the operation's timeout.
+type retryPolicy struct {
+	initialDelay   time.Duration // the delay before the first retry.
+	maxDelay       time.Duration // the maximum delay between retries.
+	defaultTimeout time.Duration // how long to keep retrying an operation that has no custom timeout.
+}
+
+var defaultRetryPolicy = retryPolicy{
+	initialDelay:   time.Second,
+	maxDelay:       30 * time.Second,
+	defaultTimeout: 5 * time.Minute,
+}
+
+// timeout returns how long the step executor may keep retrying the given step. This is the resource's custom timeout
+// for the step's operation, if it has one.
+func (p retryPolicy) timeout(step Step) time.Duration {
+	var seconds float64
+	switch step.Op() {
+	case OpCreate, OpCreateReplacement:
+		seconds = step.New().CustomTimeouts.Create
+	case OpUpdate:
+		seconds = step.New().CustomTimeouts.Update
+	case OpDelete, OpDeleteReplaced:
+		seconds = step.Old().CustomTimeouts.Delete
+	}
+
+	if seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	return p.defaultTimeout
+}
+
+// nextDelay returns the delay to wait before the retry following one that waited for the given delay.
+func (p retryPolicy) nextDelay(delay time.Duration) time.Duration {
+	if delay *= 2; delay > p.maxDelay {
+		return p.maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,43 @@
+package deploy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+)
+
+type retryableError bool
+
+func (e retryableError) Error() string   { return "marked error" }
+func (e retryableError) Retryable() bool { return bool(e) }
+
+func TestIsRetryableError(t *testing.T) {
+	assert.True(t, isRetryableError(rpcerror.New(codes.Unavailable, "unavailable")))
+	assert.True(t, isRetryableError(rpcerror.New(codes.ResourceExhausted, "throttled")))
+	assert.False(t, isRetryableError(rpcerror.New(codes.InvalidArgument, "bad input")))
+	assert.False(t, isRetryableError(errors.New("plain error")))
+
+	assert.True(t, isRetryableError(retryableError(true)))
+	assert.False(t, isRetryableError(retryableError(false)))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := retryPolicy{initialDelay: time.Second, maxDelay: 3 * time.Second, defaultTimeout: time.Minute}
+
+	assert.Equal(t, 2*time.Second, policy.nextDelay(time.Second))
+	assert.Equal(t, 3*time.Second, policy.nextDelay(2*time.Second))
+	assert.Equal(t, 3*time.Second, policy.nextDelay(3*time.Second))
+
+	timeouts := resource.CustomTimeouts{Create: 10, Update: 20, Delete: 30}
+	state := &resource.State{CustomTimeouts: timeouts}
+	assert.Equal(t, 10*time.Second, policy.timeout(&CreateStep{new: state}))
+	assert.Equal(t, 20*time.Second, policy.timeout(&UpdateStep{new: state}))
+	assert.Equal(t, 30*time.Second, policy.timeout(&DeleteStep{old: state}))
+	assert.Equal(t, time.Minute, policy.timeout(&CreateStep{new: &resource.State{}}))
+}
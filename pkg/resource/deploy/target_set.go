@@ -0,0 +1,155 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// targetPattern is a pattern in a list of targets that may match any number of resources. A pattern is either a glob
+// over the whole URN, or, if it neither starts with "urn:" nor contains "::", a type token that may itself contain
+// globs. A type token matches both the resource's own type and its type qualified by its parents' types. In a glob,
+// "**" matches any sequence of characters, "*" matches any sequence of characters that does not contain "::", and "?"
+// matches any single character other than ":".
+type targetPattern struct {
+	text    string
+	isType  bool
+	regex   *regexp.Regexp
+	matched map[resource.URN]bool
+}
+
+// isTargetPattern returns true if the given target is a pattern rather than a single URN.
+func isTargetPattern(target resource.URN) bool {
+	return !strings.HasPrefix(string(target), "urn:") || strings.ContainsAny(string(target), "*?")
+}
+
+func newTargetPattern(text string) *targetPattern {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(text); i++ {
+		switch {
+		case strings.HasPrefix(text[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case text[i] == '*':
+			expr.WriteString("(?:[^:]|:[^:])*")
+		case text[i] == '?':
+			expr.WriteString("[^:]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(text[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	return &targetPattern{
+		text:    text,
+		isType:  !strings.HasPrefix(text, "urn:") && !strings.Contains(text, "::"),
+		regex:   regexp.MustCompile(expr.String()),
+		matched: make(map[resource.URN]bool),
+	}
+}
+
+func (p *targetPattern) matches(urn resource.URN) bool {
+	if p.isType {
+		if !urn.IsValid() {
+			return false
+		}
+		return p.regex.MatchString(string(urn.Type())) || p.regex.MatchString(string(urn.QualifiedType()))
+	}
+	return p.regex.MatchString(string(urn))
+}
+
+// targetSet is a set of resources named by a list of targets, each of which is either a URN or a pattern. A nil
+// targetSet means that no targets were specified.
+type targetSet struct {
+	urns     map[resource.URN]bool
+	patterns []*targetPattern
+}
+
+// newTargetSet creates a target set from the given list of targets. It returns nil if the list is empty.
+func newTargetSet(targets []resource.URN) *targetSet {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	set := &targetSet{urns: make(map[resource.URN]bool)}
+	for _, target := range targets {
+		if isTargetPattern(target) {
+			set.patterns = append(set.patterns, newTargetPattern(string(target)))
+		} else {
+			set.urns[target] = true
+		}
+	}
+	return set
+}
+
+// Contains returns true if the given URN is named by this set, either directly or by one of its patterns.
+func (s *targetSet) Contains(urn resource.URN) bool {
+	if s == nil || urn == "" {
+		return false
+	}
+	if s.urns[urn] {
+		return true
+	}
+
+	contains := false
+	for _, p := range s.patterns {
+		if p.matches(urn) {
+			p.matched[urn] = true
+			contains = true
+		}
+	}
+	return contains
+}
+
+// String returns the targets in this set.
+func (s *targetSet) String() string {
+	var targets []string
+	for urn := range s.urns {
+		targets = append(targets, string(urn))
+	}
+	for _, p := range s.patterns {
+		targets = append(targets, p.text)
+	}
+	sort.Strings(targets)
+	return strings.Join(targets, ", ")
+}
+
+// add adds the given URN to this set.
+func (s *targetSet) add(urn resource.URN) {
+	s.urns[urn] = true
+}
+
+// patternMatches returns each pattern in this set along with the sorted list of URNs it has matched so far.
+func (s *targetSet) patternMatches() map[string][]resource.URN {
+	if s == nil {
+		return nil
+	}
+
+	matches := make(map[string][]resource.URN)
+	for _, p := range s.patterns {
+		urns := make([]resource.URN, 0, len(p.matched))
+		for urn := range p.matched {
+			urns = append(urns, urn)
+		}
+		sort.Slice(urns, func(i, j int) bool { return urns[i] < urns[j] })
+		matches[p.text] = urns
+	}
+	return matches
+}
@@ -0,0 +1,92 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestTargetSet(t *testing.T) {
+	logs1 := resource.URN("urn:pulumi:dev::proj::aws:s3/bucket:Bucket::logs-1")
+	logs2 := resource.URN("urn:pulumi:dev::proj::aws:s3/bucket:Bucket::logs-2")
+	data := resource.URN("urn:pulumi:dev::proj::aws:s3/bucket:Bucket::data")
+	child := resource.URN("urn:pulumi:dev::proj::my:component:Site$aws:s3/bucket:Bucket::logs-3")
+	queue := resource.URN("urn:pulumi:dev::proj::aws:sqs/queue:Queue::logs-4")
+	all := []resource.URN{logs1, logs2, data, child, queue}
+
+	cases := []struct {
+		name     string
+		targets  []resource.URN
+		expected []resource.URN
+	}{
+		{
+			name:     "URN",
+			targets:  []resource.URN{data},
+			expected: []resource.URN{data},
+		},
+		{
+			name:     "Glob",
+			targets:  []resource.URN{"**::aws:s3/bucket:Bucket::logs-*"},
+			expected: []resource.URN{logs1, logs2},
+		},
+		{
+			name:     "Recursive glob",
+			targets:  []resource.URN{"urn:pulumi:dev::proj::**::logs-?"},
+			expected: []resource.URN{logs1, logs2, child, queue},
+		},
+		{
+			name:     "Type",
+			targets:  []resource.URN{"aws:s3/bucket:Bucket"},
+			expected: []resource.URN{logs1, logs2, data, child},
+		},
+		{
+			name:     "Qualified type",
+			targets:  []resource.URN{"my:component:Site$*"},
+			expected: []resource.URN{child},
+		},
+		{
+			name:     "Type glob",
+			targets:  []resource.URN{"aws:*"},
+			expected: []resource.URN{logs1, logs2, data, child, queue},
+		},
+		{
+			name:     "Mixed",
+			targets:  []resource.URN{data, "aws:sqs/*"},
+			expected: []resource.URN{data, queue},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			set := newTargetSet(c.targets)
+
+			var actual []resource.URN
+			for _, urn := range all {
+				if set.Contains(urn) {
+					actual = append(actual, urn)
+				}
+			}
+			assert.ElementsMatch(t, c.expected, actual)
+		})
+	}
+}
+
+func TestTargetSetPatternMatches(t *testing.T) {
+	logs1 := resource.URN("urn:pulumi:dev::proj::aws:s3/bucket:Bucket::logs-1")
+	data := resource.URN("urn:pulumi:dev::proj::aws:s3/bucket:Bucket::data")
+
+	assert.Nil(t, newTargetSet(nil))
+	assert.False(t, newTargetSet(nil).Contains(data))
+
+	set := newTargetSet([]resource.URN{data, "**::logs-*", "aws:sqs/queue:Queue"})
+	assert.True(t, set.Contains(logs1))
+	assert.True(t, set.Contains(data))
+	assert.False(t, set.Contains(""))
+
+	assert.Equal(t, map[string][]resource.URN{
+		"**::logs-*":          {logs1},
+		"aws:sqs/queue:Queue": {},
+	}, set.patternMatches())
+}
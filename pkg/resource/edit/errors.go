@@ -39,3 +39,25 @@ type ResourceProtectedError struct {
 func (ResourceProtectedError) Error() string {
 	return "Can't delete protected resource"
 }
+
+// ResourceHasDanglingReferencesError is returned by MoveResources if moving the requested resources would leave a
+// resource in either stack referring to a resource that lives in the other stack.
+type ResourceHasDanglingReferencesError struct {
+	Resource   *resource.State
+	References []resource.URN
+}
+
+func (r ResourceHasDanglingReferencesError) Error() string {
+	return fmt.Sprintf("Can't move resources: resource %q would be left with references to resources in another stack",
+		r.Resource.URN)
+}
+
+// ResourceAlreadyExistsError is returned by MoveResources and RenameResource if the target snapshot already contains a
+// resource with the URN that an edited resource would be given.
+type ResourceAlreadyExistsError struct {
+	URN resource.URN
+}
+
+func (r ResourceAlreadyExistsError) Error() string {
+	return fmt.Sprintf("Resource %q already exists in the target stack", r.URN)
+}
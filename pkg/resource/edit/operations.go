@@ -15,6 +15,8 @@
 package edit
 
 import (
+	"fmt"
+
 	"github.com/pkg/errors"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
@@ -116,47 +118,397 @@ func RenameStack(snap *deploy.Snapshot, newName tokens.QName, newProject tokens.
 		return resource.NewURN(newName, project, "", u.QualifiedType(), u.Name())
 	}
 
-	rewriteState := func(res *resource.State) {
-		contract.Assert(res != nil)
+	if err := snap.VerifyIntegrity(); err != nil {
+		return errors.Wrap(err, "checkpoint is invalid")
+	}
 
-		res.URN = rewriteUrn(res.URN)
+	for _, res := range snap.Resources {
+		rewriteStateURNs(res, rewriteUrn)
+	}
 
-		if res.Parent != "" {
-			res.Parent = rewriteUrn(res.Parent)
+	for _, ops := range snap.PendingOperations {
+		rewriteStateURNs(ops.Resource, rewriteUrn)
+	}
+
+	return nil
+}
+
+// RenameResource changes the logical name of the given resource, rewriting its URN and every reference to it held by
+// other resources and pending operations in the snapshot. The URNs of the resource's descendants only encode the types
+// of their ancestors, so they are left unchanged; only their Parent references are updated.
+func RenameResource(snap *deploy.Snapshot, res *resource.State, newName tokens.QName) error {
+	contract.Require(snap != nil, "snap")
+	contract.Require(res != nil, "res")
+
+	oldURN := res.URN
+	newURN := resource.NewURN(oldURN.Stack(), oldURN.Project(), "", oldURN.QualifiedType(), newName)
+	if res.Type == resource.RootStackType {
+		return errors.Errorf("Can't rename the root stack resource %q", oldURN)
+	}
+	if newURN == oldURN {
+		return nil
+	}
+	for _, other := range snap.Resources {
+		if other.URN == newURN {
+			return ResourceAlreadyExistsError{URN: newURN}
 		}
+	}
+
+	rewriteURN := func(urn resource.URN) resource.URN {
+		if urn == oldURN {
+			return newURN
+		}
+		return urn
+	}
+
+	for _, other := range snap.Resources {
+		rewriteStateURNs(other, rewriteURN)
+	}
+	for _, op := range snap.PendingOperations {
+		rewriteStateURNs(op.Resource, rewriteURN)
+	}
+	return nil
+}
+
+// CollectResources returns the given resources along with, optionally, their descendants and the resources they
+// depend upon. The result is in the same order as the snapshot. Provider resources and the root stack resource are
+// never collected implicitly; MoveResources carries the providers it needs along on its own.
+func CollectResources(snap *deploy.Snapshot, roots []*resource.State,
+	includeChildren, includeDependencies bool) []*resource.State {
+	contract.Require(snap != nil, "snap")
+
+	selected := make(map[*resource.State]bool)
+	for _, res := range roots {
+		selected[res] = true
+	}
+
+	collectable := func(res *resource.State) bool {
+		return !providers.IsProviderType(res.Type) && res.Type != resource.RootStackType
+	}
+
+	// Collecting children may introduce new dependencies and vice versa, so keep going until nothing changes.
+	for changed := true; changed; {
+		changed = false
 
-		for depIdx, dep := range res.Dependencies {
-			res.Dependencies[depIdx] = rewriteUrn(dep)
+		if includeChildren {
+			// Parents precede their children in a valid snapshot, so a single forward pass picks up every descendant.
+			parents := make(map[resource.URN]bool)
+			for _, res := range snap.Resources {
+				if !selected[res] && parents[res.Parent] && collectable(res) {
+					selected[res], changed = true, true
+				}
+				if selected[res] {
+					parents[res.URN] = true
+				}
+			}
 		}
 
-		for _, propDeps := range res.PropertyDependencies {
-			for depIdx, dep := range propDeps {
-				propDeps[depIdx] = rewriteUrn(dep)
+		if includeDependencies {
+			// Dependencies precede their dependents, so a single backward pass picks up every transitive dependency.
+			required := make(map[resource.URN]bool)
+			for i := len(snap.Resources) - 1; i >= 0; i-- {
+				res := snap.Resources[i]
+				if !selected[res] && required[res.URN] && collectable(res) {
+					selected[res], changed = true, true
+				}
+				if selected[res] {
+					for _, dep := range res.Dependencies {
+						required[dep] = true
+					}
+					for _, deps := range res.PropertyDependencies {
+						for _, dep := range deps {
+							required[dep] = true
+						}
+					}
+				}
 			}
 		}
+	}
 
-		if res.Provider != "" {
-			providerRef, err := providers.ParseReference(res.Provider)
-			contract.AssertNoErrorf(err, "failed to parse provider reference from validated checkpoint")
+	var collected []*resource.State
+	for _, res := range snap.Resources {
+		if selected[res] {
+			collected = append(collected, res)
+		}
+	}
+	return collected
+}
 
-			providerRef, err = providers.NewReference(rewriteUrn(providerRef.URN()), providerRef.ID())
-			contract.AssertNoErrorf(err, "failed to generate provider reference from valid reference")
+// MoveResources moves the given resources out of the source snapshot and into the destination snapshot, rewriting
+// their URNs (and every reference between them) to belong to the given destination stack and project. Any provider
+// resources that the moved resources use are copied into the destination snapshot as well, unless an equivalent
+// provider is already present there. A provider that collides with a different provider in the destination (e.g. the
+// destination's own default provider for the same package) is copied under a new name, with a "_moved" suffix.
+// Resources parented to the source stack's root resource are re-parented to the
+// destination stack's root resource, which is created if the destination does not have one yet.
+//
+// The move is refused with a `ResourceHasDanglingReferencesError` if it would leave a resource in either snapshot
+// with a parent or dependency that lives in the other one, and with a `ResourceAlreadyExistsError` if a moved
+// resource would collide with a resource that already exists in the destination. Neither snapshot is modified if an
+// error is returned.
+//
+// Note that secret values are held in plaintext in memory, so they are re-encrypted with the destination stack's
+// secrets manager when the destination snapshot is serialized.
+func MoveResources(source, dest *deploy.Snapshot, resources []*resource.State,
+	destStack tokens.QName, destProject tokens.PackageName) error {
+	contract.Require(source != nil, "source")
+	contract.Require(dest != nil, "dest")
 
-			res.Provider = providerRef.String()
+	moving := make(map[*resource.State]bool)
+	movingURNs := make(map[resource.URN]bool)
+	for _, res := range resources {
+		if res.Type == resource.RootStackType {
+			return errors.Errorf("Can't move the root stack resource %q", res.URN)
 		}
+		moving[res] = true
+		movingURNs[res.URN] = true
 	}
 
-	if err := snap.VerifyIntegrity(); err != nil {
-		return errors.Wrap(err, "checkpoint is invalid")
+	for _, op := range source.PendingOperations {
+		if movingURNs[op.Resource.URN] {
+			return errors.Errorf("Can't move resource %q while it has a pending %s operation", op.Resource.URN, op.Type)
+		}
 	}
 
-	for _, res := range snap.Resources {
-		rewriteState(res)
+	sourceRoot, destRoot := findRootStack(source), findRootStack(dest)
+	isSourceRoot := func(urn resource.URN) bool {
+		return sourceRoot != nil && urn == sourceRoot.URN
 	}
 
-	for _, ops := range snap.PendingOperations {
-		rewriteState(ops.Resource)
+	// Work out which provider resources the moved resources need to bring with them.
+	providerStates := make(map[providers.Reference]*resource.State)
+	for _, res := range source.Resources {
+		if providers.IsProviderType(res.Type) {
+			ref, err := providers.NewReference(res.URN, res.ID)
+			if err != nil {
+				return errors.Wrapf(err, "provider %q is not referenceable", res.URN)
+			}
+			providerStates[ref] = res
+		}
+	}
+	copied := make(map[*resource.State]bool)
+	copiedURNs := make(map[resource.URN]bool)
+	for _, res := range resources {
+		if res.Provider == "" {
+			continue
+		}
+		ref, err := providers.ParseReference(res.Provider)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse provider reference for resource %q", res.URN)
+		}
+		prov, has := providerStates[ref]
+		if !has {
+			return errors.Errorf("resource %q refers to unknown provider %s", res.URN, ref)
+		}
+		if !moving[prov] {
+			copied[prov] = true
+			copiedURNs[prov.URN] = true
+		}
+	}
+
+	// Every reference held by a resource that is headed to the destination must point at another resource that is
+	// headed there too. The only exception is the root stack resource, which is replaced by the destination's own.
+	for _, res := range source.Resources {
+		if !moving[res] && !copied[res] {
+			continue
+		}
+		var dangling []resource.URN
+		for _, ref := range referencedURNs(res, false /*includeProvider*/) {
+			if movingURNs[ref] || copiedURNs[ref] || (ref == res.Parent && isSourceRoot(ref)) {
+				continue
+			}
+			dangling = append(dangling, ref)
+		}
+		if len(dangling) != 0 {
+			return ResourceHasDanglingReferencesError{Resource: res, References: dangling}
+		}
+	}
+
+	// Likewise, nothing that stays behind may refer to a resource that is leaving.
+	for _, res := range source.Resources {
+		if moving[res] {
+			continue
+		}
+		var dangling []resource.URN
+		for _, ref := range referencedURNs(res, true /*includeProvider*/) {
+			if movingURNs[ref] {
+				dangling = append(dangling, ref)
+			}
+		}
+		if len(dangling) != 0 {
+			return ResourceHasDanglingReferencesError{Resource: res, References: dangling}
+		}
+	}
+
+	// If anything headed to the destination is parented to the source's root stack resource and the destination
+	// doesn't have a root stack resource of its own yet, create one for it.
+	var newRoot *resource.State
+	if destRoot == nil {
+		for _, res := range source.Resources {
+			if (moving[res] || copied[res]) && isSourceRoot(res.Parent) {
+				rootName := tokens.QName(fmt.Sprintf("%s-%s", destProject, destStack))
+				newRoot = resource.NewState(resource.RootStackType,
+					resource.NewURN(destStack, destProject, "", resource.RootStackType, rootName), false, false, "",
+					resource.PropertyMap{}, resource.PropertyMap{}, "", false, false, nil, nil, "", nil, false, nil,
+					nil, nil, "", false, nil, "")
+				destRoot = newRoot
+				break
+			}
+		}
+	}
+
+	renamed := make(map[resource.URN]resource.URN)
+	rewriteURN := func(urn resource.URN) resource.URN {
+		if isSourceRoot(urn) {
+			return destRoot.URN
+		}
+		if newURN, has := renamed[urn]; has {
+			return newURN
+		}
+		return resource.NewURN(destStack, destProject, "", urn.QualifiedType(), urn.Name())
 	}
 
+	// Make sure that nothing we're about to add to the destination collides with what's already there. A provider that
+	// is already present with the same URN and either the same ID or the same inputs can simply be shared; the moved
+	// resources are re-pointed at it. Any other provider that collides is copied under a new name.
+	existing := make(map[resource.URN]*resource.State)
+	for _, res := range dest.Resources {
+		existing[res.URN] = res
+	}
+	reused := make(map[*resource.State]bool)
+	sharedProviders := make(map[string]string)
+	for _, res := range source.Resources {
+		if !moving[res] && !copied[res] {
+			continue
+		}
+		other, has := existing[rewriteURN(res.URN)]
+		switch {
+		case !has:
+			continue
+		case copied[res] && providers.IsProviderType(other.Type) &&
+			(other.ID == res.ID || other.Inputs.DeepEquals(res.Inputs)):
+			oldRef, err := providers.NewReference(res.URN, res.ID)
+			contract.AssertNoErrorf(err, "provider %q is not referenceable", res.URN)
+			newRef, err := providers.NewReference(other.URN, other.ID)
+			if err != nil {
+				return errors.Wrapf(err, "provider %q is not referenceable", other.URN)
+			}
+			reused[res] = true
+			sharedProviders[oldRef.String()] = newRef.String()
+		case copied[res] && providers.IsProviderType(other.Type):
+			newURN := resource.NewURN(destStack, destProject, "", res.URN.QualifiedType(), res.URN.Name()+"_moved")
+			if _, has := existing[newURN]; has {
+				return ResourceAlreadyExistsError{URN: newURN}
+			}
+			renamed[res.URN] = newURN
+		default:
+			return ResourceAlreadyExistsError{URN: other.URN}
+		}
+	}
+
+	// All checks have passed, so it is now safe to mutate the snapshots.
+	var remaining, moved []*resource.State
+	if newRoot != nil {
+		moved = append(moved, newRoot)
+	}
+	for _, res := range source.Resources {
+		switch {
+		case moving[res]:
+			provider := res.Provider
+			rewriteStateURNs(res, rewriteURN)
+			if shared, has := sharedProviders[provider]; has {
+				res.Provider = shared
+			}
+			moved = append(moved, res)
+		case copied[res]:
+			remaining = append(remaining, res)
+			if !reused[res] {
+				clone := copyState(res)
+				rewriteStateURNs(clone, rewriteURN)
+				moved = append(moved, clone)
+			}
+		default:
+			remaining = append(remaining, res)
+		}
+	}
+
+	source.Resources = remaining
+	dest.Resources = append(dest.Resources, moved...)
+	return nil
+}
+
+// findRootStack returns the root stack resource of the given snapshot, or nil if it does not have one.
+func findRootStack(snap *deploy.Snapshot) *resource.State {
+	for _, res := range snap.Resources {
+		if res.Type == resource.RootStackType && !res.Delete {
+			return res
+		}
+	}
 	return nil
 }
+
+// referencedURNs returns the URNs of every resource that the given resource refers to, optionally including its
+// provider.
+func referencedURNs(res *resource.State, includeProvider bool) []resource.URN {
+	var refs []resource.URN
+	if res.Parent != "" {
+		refs = append(refs, res.Parent)
+	}
+	refs = append(refs, res.Dependencies...)
+	for _, deps := range res.PropertyDependencies {
+		refs = append(refs, deps...)
+	}
+	if includeProvider && res.Provider != "" {
+		ref, err := providers.ParseReference(res.Provider)
+		contract.AssertNoErrorf(err, "failed to parse provider reference from validated checkpoint")
+		refs = append(refs, ref.URN())
+	}
+	return refs
+}
+
+// rewriteStateURNs rewrites the URN of the given resource, and every URN it refers to, using the given function.
+func rewriteStateURNs(res *resource.State, rewrite func(resource.URN) resource.URN) {
+	res.URN = rewrite(res.URN)
+
+	if res.Parent != "" {
+		res.Parent = rewrite(res.Parent)
+	}
+
+	for depIdx, dep := range res.Dependencies {
+		res.Dependencies[depIdx] = rewrite(dep)
+	}
+
+	for _, propDeps := range res.PropertyDependencies {
+		for depIdx, dep := range propDeps {
+			propDeps[depIdx] = rewrite(dep)
+		}
+	}
+
+	if res.DeletedWith != "" {
+		res.DeletedWith = rewrite(res.DeletedWith)
+	}
+
+	if res.Provider != "" {
+		providerRef, err := providers.ParseReference(res.Provider)
+		contract.AssertNoErrorf(err, "failed to parse provider reference from validated checkpoint")
+
+		providerRef, err = providers.NewReference(rewrite(providerRef.URN()), providerRef.ID())
+		contract.AssertNoErrorf(err, "failed to generate provider reference from valid reference")
+
+		res.Provider = providerRef.String()
+	}
+}
+
+// copyState returns a copy of the given resource whose URN references can be rewritten without affecting the
+// original. Property values are shared between the two.
+func copyState(res *resource.State) *resource.State {
+	clone := *res
+	clone.Dependencies = append([]resource.URN(nil), res.Dependencies...)
+	if res.PropertyDependencies != nil {
+		clone.PropertyDependencies = make(map[resource.PropertyKey][]resource.URN, len(res.PropertyDependencies))
+		for k, deps := range res.PropertyDependencies {
+			clone.PropertyDependencies[k] = append([]resource.URN(nil), deps...)
+		}
+	}
+	return &clone
+}
@@ -321,3 +321,307 @@ func TestRenameStack(t *testing.T) {
 		assert.Len(t, LocateResource(snap, updatedResourceURN), 1)
 	})
 }
+
+func TestCollectResources(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA)
+	c.Parent = b.URN
+	d := NewResource("d", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+		c,
+		d,
+	})
+
+	assert.Equal(t, []*resource.State{b}, CollectResources(snap, []*resource.State{b}, false, false))
+	assert.Equal(t, []*resource.State{b, c}, CollectResources(snap, []*resource.State{b}, true, false))
+	assert.Equal(t, []*resource.State{a, b}, CollectResources(snap, []*resource.State{b}, false, true))
+	assert.Equal(t, []*resource.State{a, b, c}, CollectResources(snap, []*resource.State{b}, true, true))
+}
+
+func TestMoveResources(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	b.PropertyDependencies = map[resource.PropertyKey][]resource.URN{"foo": {a.URN}}
+	c := NewResource("c", pA)
+	source := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+		c,
+	})
+	dest := NewSnapshot(nil)
+
+	err := MoveResources(source, dest, []*resource.State{a, b}, "dest-stack", "dest-project")
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.State{pA, c}, source.Resources)
+	if !assert.Len(t, dest.Resources, 3) {
+		t.FailNow()
+	}
+
+	// The provider is copied rather than moved, since c still needs it.
+	newProvider := dest.Resources[0]
+	assert.NotSame(t, pA, newProvider)
+	assert.EqualValues(t, "dest-stack", newProvider.URN.Stack())
+	assert.EqualValues(t, "dest-project", newProvider.URN.Project())
+	assert.Equal(t, pA.ID, newProvider.ID)
+	assert.EqualValues(t, "test", pA.URN.Stack())
+
+	newRef, err := providers.NewReference(newProvider.URN, newProvider.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.State{a, b}, dest.Resources[1:])
+	for _, res := range dest.Resources[1:] {
+		assert.EqualValues(t, "dest-stack", res.URN.Stack())
+		assert.EqualValues(t, "dest-project", res.URN.Project())
+		assert.Equal(t, newRef.String(), res.Provider)
+	}
+	assert.Equal(t, []resource.URN{a.URN}, b.Dependencies)
+	assert.Equal(t, []resource.URN{a.URN}, b.PropertyDependencies["foo"])
+
+	dest.Manifest.Magic = dest.Manifest.NewMagic()
+	assert.NoError(t, dest.VerifyIntegrity())
+}
+
+func TestMoveResourcesReparentsToRootStack(t *testing.T) {
+	sourceRoot := &resource.State{
+		Type: resource.RootStackType,
+		URN:  resource.NewURN("test", "test", "", resource.RootStackType, "test-test"),
+	}
+	destRoot := &resource.State{
+		Type: resource.RootStackType,
+		URN:  resource.NewURN("dest-stack", "test", "", resource.RootStackType, "test-dest-stack"),
+	}
+	pA := NewProviderResource("a", "p1", "0")
+	pA.Parent = sourceRoot.URN
+	a := NewResource("a", pA)
+	a.Parent = sourceRoot.URN
+	source := NewSnapshot([]*resource.State{
+		sourceRoot,
+		pA,
+		a,
+	})
+	dest := NewSnapshot([]*resource.State{destRoot})
+
+	err := MoveResources(source, dest, []*resource.State{a}, "dest-stack", "test")
+	assert.NoError(t, err)
+	assert.Len(t, dest.Resources, 3)
+	for _, res := range dest.Resources[1:] {
+		assert.Equal(t, destRoot.URN, res.Parent)
+	}
+}
+
+func TestMoveResourcesCreatesRootStack(t *testing.T) {
+	sourceRoot := &resource.State{
+		Type: resource.RootStackType,
+		URN:  resource.NewURN("test", "test", "", resource.RootStackType, "test-test"),
+	}
+	pA := NewProviderResource("a", "p1", "0")
+	pA.Parent = sourceRoot.URN
+	a := NewResource("a", pA)
+	a.Parent = sourceRoot.URN
+	source := NewSnapshot([]*resource.State{
+		sourceRoot,
+		pA,
+		a,
+	})
+	dest := NewSnapshot(nil)
+
+	err := MoveResources(source, dest, []*resource.State{a}, "dest-stack", "dest-project")
+	assert.NoError(t, err)
+	if !assert.Len(t, dest.Resources, 3) {
+		t.FailNow()
+	}
+
+	// The destination has no root stack resource, so the moved resources are parented to a new one.
+	destRoot := dest.Resources[0]
+	assert.Equal(t, resource.RootStackType, destRoot.Type)
+	assert.Equal(t, resource.NewURN("dest-stack", "dest-project", "", resource.RootStackType, "dest-project-dest-stack"),
+		destRoot.URN)
+	for _, res := range dest.Resources[1:] {
+		assert.Equal(t, destRoot.URN, res.Parent)
+	}
+
+	dest.Manifest.Magic = dest.Manifest.NewMagic()
+	assert.NoError(t, dest.VerifyIntegrity())
+}
+
+func TestMoveResourcesIntoStackWithDefaultProvider(t *testing.T) {
+	sourceProvider := NewProviderResource("a", "default", "source-uuid")
+	a := NewResource("a", sourceProvider)
+	source := NewSnapshot([]*resource.State{
+		sourceProvider,
+		a,
+	})
+
+	// The destination has its own default provider for the same package, with the same inputs but a different ID.
+	destProvider := NewProviderResource("a", "default", "dest-uuid")
+	dest := NewSnapshot([]*resource.State{destProvider})
+
+	err := MoveResources(source, dest, []*resource.State{a}, "test", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, []*resource.State{sourceProvider}, source.Resources)
+	assert.Equal(t, []*resource.State{destProvider, a}, dest.Resources)
+
+	// The moved resource uses the destination's provider.
+	destRef, err := providers.NewReference(destProvider.URN, destProvider.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, destRef.String(), a.Provider)
+
+	dest.Manifest.Magic = dest.Manifest.NewMagic()
+	assert.NoError(t, dest.VerifyIntegrity())
+}
+
+func TestMoveResourcesRenamesConflictingProvider(t *testing.T) {
+	sourceProvider := NewProviderResource("a", "default", "source-uuid")
+	sourceProvider.Inputs = resource.PropertyMap{"region": resource.NewStringProperty("us-west-2")}
+	a := NewResource("a", sourceProvider)
+	source := NewSnapshot([]*resource.State{
+		sourceProvider,
+		a,
+	})
+
+	// The destination's default provider is configured differently, so it can't be shared.
+	destProvider := NewProviderResource("a", "default", "dest-uuid")
+	destProvider.Inputs = resource.PropertyMap{"region": resource.NewStringProperty("us-east-1")}
+	dest := NewSnapshot([]*resource.State{destProvider})
+
+	err := MoveResources(source, dest, []*resource.State{a}, "test", "test")
+	assert.NoError(t, err)
+	if !assert.Len(t, dest.Resources, 3) {
+		t.FailNow()
+	}
+
+	// The source's provider is copied under a new name, and the moved resource uses the copy.
+	copied := dest.Resources[1]
+	assert.EqualValues(t, "default_moved", copied.URN.Name())
+	assert.Equal(t, sourceProvider.ID, copied.ID)
+	assert.Equal(t, sourceProvider.Inputs, copied.Inputs)
+	copiedRef, err := providers.NewReference(copied.URN, copied.ID)
+	assert.NoError(t, err)
+	assert.Same(t, a, dest.Resources[2])
+	assert.Equal(t, copiedRef.String(), a.Provider)
+
+	dest.Manifest.Magic = dest.Manifest.NewMagic()
+	assert.NoError(t, dest.VerifyIntegrity())
+}
+
+func TestFailedMoveResourcesMissingDependency(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	source := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+	})
+	dest := NewSnapshot(nil)
+
+	err := MoveResources(source, dest, []*resource.State{b}, "dest-stack", "dest-project")
+	depErr, ok := err.(ResourceHasDanglingReferencesError)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, b, depErr.Resource)
+	assert.Equal(t, []resource.URN{a.URN}, depErr.References)
+	assert.Equal(t, []*resource.State{pA, a, b}, source.Resources)
+	assert.Len(t, dest.Resources, 0)
+}
+
+func TestFailedMoveResourcesRemainingDependent(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	b.Parent = a.URN
+	source := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+	})
+	dest := NewSnapshot(nil)
+
+	err := MoveResources(source, dest, []*resource.State{a}, "dest-stack", "dest-project")
+	depErr, ok := err.(ResourceHasDanglingReferencesError)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Equal(t, b, depErr.Resource)
+	assert.Equal(t, []*resource.State{pA, a, b}, source.Resources)
+	assert.Len(t, dest.Resources, 0)
+}
+
+func TestFailedMoveResourcesAlreadyExists(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	source := NewSnapshot([]*resource.State{
+		pA,
+		a,
+	})
+	existing := NewResource("a", nil)
+	dest := NewSnapshot([]*resource.State{existing})
+
+	err := MoveResources(source, dest, []*resource.State{a}, "test", "test")
+	_, ok := err.(ResourceAlreadyExistsError)
+	assert.True(t, ok)
+	assert.Equal(t, []*resource.State{pA, a}, source.Resources)
+	assert.Equal(t, []*resource.State{existing}, dest.Resources)
+}
+
+func TestRenameResource(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	b.Parent = a.URN
+	b.PropertyDependencies = map[resource.PropertyKey][]resource.URN{"foo": {a.URN}}
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+	})
+
+	err := RenameResource(snap, a, "renamed")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "renamed", a.URN.Name())
+	assert.Equal(t, a.URN, b.Parent)
+	assert.Equal(t, []resource.URN{a.URN}, b.Dependencies)
+	assert.Equal(t, []resource.URN{a.URN}, b.PropertyDependencies["foo"])
+
+	snap.Manifest.Magic = snap.Manifest.NewMagic()
+	assert.NoError(t, snap.VerifyIntegrity())
+}
+
+func TestRenameProviderResource(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+	})
+
+	err := RenameResource(snap, pA, "p2")
+	assert.NoError(t, err)
+
+	ref, err := providers.NewReference(pA.URN, pA.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ref.String(), a.Provider)
+}
+
+func TestFailedRenameResourceAlreadyExists(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+	})
+
+	err := RenameResource(snap, a, "b")
+	_, ok := err.(ResourceAlreadyExistsError)
+	assert.True(t, ok)
+	assert.EqualValues(t, "a", a.URN.Name())
+}
@@ -0,0 +1,204 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// Repair describes a single fix made to a snapshot by RepairSnapshot.
+type Repair struct {
+	URN     resource.URN // the resource that was fixed, if the fix pertains to a single resource.
+	Message string       // a human-readable description of the fix.
+}
+
+func (r Repair) String() string {
+	if r.URN == "" {
+		return r.Message
+	}
+	return fmt.Sprintf("%s: %s", r.URN, r.Message)
+}
+
+// RepairSnapshot fixes the problems that cause a snapshot to fail `VerifyIntegrity` where it is possible to do so
+// without losing any resources:
+//
+//  1. References to resources that no longer exist are dropped. Dangling dependencies are removed, resources with a
+//     missing parent are re-parented to the root stack resource, and custom resources that refer to a missing
+//     provider fall back to their package's default provider.
+//  2. Resources are re-sorted so that providers, parents and dependencies precede the resources that refer to them.
+//     The sort is stable, so resources that are already in a valid position keep their relative order.
+//  3. A mismatched manifest magic cookie is recomputed.
+//
+// The snapshot is edited in-place, and every fix that was made is returned. If the snapshot still fails integrity
+// verification after repair (for example, because it contains a dependency cycle or duplicate resources), an error is
+// returned and the snapshot should be discarded.
+func RepairSnapshot(snap *deploy.Snapshot) ([]Repair, error) {
+	contract.Require(snap != nil, "snap")
+
+	var repairs []Repair
+
+	// First, work out which resources and providers actually exist, and drop any references to those that don't.
+	urns := make(map[resource.URN]bool)
+	provs := make(map[providers.Reference]bool)
+	for _, res := range snap.Resources {
+		urns[res.URN] = true
+		if providers.IsProviderType(res.Type) {
+			if ref, err := providers.NewReference(res.URN, res.ID); err == nil {
+				provs[ref] = true
+			}
+		}
+	}
+	root := findRootStack(snap)
+
+	for _, res := range snap.Resources {
+		if res.Parent != "" && !urns[res.Parent] {
+			repair := Repair{URN: res.URN, Message: fmt.Sprintf("removed reference to missing parent %s", res.Parent)}
+			res.Parent = ""
+			if root != nil && root != res {
+				res.Parent = root.URN
+				repair.Message += "; re-parented to the root stack resource"
+			}
+			repairs = append(repairs, repair)
+		}
+
+		var deps []resource.URN
+		for _, dep := range res.Dependencies {
+			if !urns[dep] {
+				repairs = append(repairs, Repair{
+					URN:     res.URN,
+					Message: fmt.Sprintf("removed dependency on missing resource %s", dep),
+				})
+				continue
+			}
+			deps = append(deps, dep)
+		}
+		if len(deps) != len(res.Dependencies) {
+			res.Dependencies = deps
+		}
+
+		for key, propDeps := range res.PropertyDependencies {
+			var deps []resource.URN
+			for _, dep := range propDeps {
+				if !urns[dep] {
+					repairs = append(repairs, Repair{
+						URN:     res.URN,
+						Message: fmt.Sprintf("removed dependency of property %q on missing resource %s", key, dep),
+					})
+					continue
+				}
+				deps = append(deps, dep)
+			}
+			if len(deps) != len(propDeps) {
+				res.PropertyDependencies[key] = deps
+			}
+		}
+
+		if res.Provider != "" {
+			ref, err := providers.ParseReference(res.Provider)
+			if err != nil || !provs[ref] {
+				// The engine injects a default provider for custom resources that don't refer to one.
+				repairs = append(repairs, Repair{
+					URN:     res.URN,
+					Message: fmt.Sprintf("removed reference to missing provider %s; the default provider will be used", res.Provider),
+				})
+				res.Provider = ""
+			}
+		}
+	}
+
+	// Next, re-sort the resources so that everything a resource refers to precedes it.
+	sorted, moves, err := sortResources(snap.Resources)
+	if err != nil {
+		return repairs, err
+	}
+	snap.Resources = sorted
+	repairs = append(repairs, moves...)
+
+	// Finally, recompute the magic cookie if it does not match.
+	if magic := snap.Manifest.NewMagic(); snap.Manifest.Magic != magic {
+		snap.Manifest.Magic = magic
+		repairs = append(repairs, Repair{Message: "recomputed the manifest's magic cookie"})
+	}
+
+	if err := snap.VerifyIntegrity(); err != nil {
+		return repairs, errors.Wrap(err, "snapshot could not be repaired")
+	}
+	return repairs, nil
+}
+
+// sortResources performs a stable topological sort of the given resources, such that every resource comes after its
+// provider, parent and dependencies. A repair is returned for each resource that had to be moved ahead of a resource
+// that refers to it.
+func sortResources(resources []*resource.State) ([]*resource.State, []Repair, error) {
+	byURN := make(map[resource.URN][]*resource.State)
+	for _, res := range resources {
+		byURN[res.URN] = append(byURN[res.URN], res)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*resource.State]int)
+
+	var sorted []*resource.State
+	var repairs []Repair
+	var visit func(res, referrer *resource.State) error
+	visit = func(res, referrer *resource.State) error {
+		switch state[res] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("resource %s is part of a dependency cycle", res.URN)
+		}
+		state[res] = visiting
+
+		for _, ref := range referencedURNs(res, true /*includeProvider*/) {
+			if ref == res.URN {
+				continue
+			}
+			for _, dep := range byURN[ref] {
+				if err := visit(dep, res); err != nil {
+					return err
+				}
+			}
+		}
+
+		state[res] = visited
+		sorted = append(sorted, res)
+		if referrer != nil {
+			repairs = append(repairs, Repair{
+				URN:     res.URN,
+				Message: fmt.Sprintf("moved ahead of %s, which refers to it", referrer.URN),
+			})
+		}
+		return nil
+	}
+
+	for _, res := range resources {
+		if err := visit(res, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return sorted, repairs, nil
+}
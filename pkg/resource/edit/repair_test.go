@@ -0,0 +1,97 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func TestRepairValidSnapshot(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+	})
+	snap.Manifest.Magic = snap.Manifest.NewMagic()
+
+	repairs, err := RepairSnapshot(snap)
+	assert.NoError(t, err)
+	assert.Empty(t, repairs)
+	assert.Equal(t, []*resource.State{pA, a, b}, snap.Resources)
+}
+
+func TestRepairOutOfOrderResources(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA)
+	c.Parent = b.URN
+	snap := NewSnapshot([]*resource.State{
+		c,
+		b,
+		pA,
+		a,
+	})
+	snap.Manifest.Magic = snap.Manifest.NewMagic()
+
+	repairs, err := RepairSnapshot(snap)
+	assert.NoError(t, err)
+	assert.Len(t, repairs, 3)
+	assert.Equal(t, []*resource.State{pA, a, b, c}, snap.Resources)
+}
+
+func TestRepairDanglingReferences(t *testing.T) {
+	pA := NewProviderResource("a", "p1", "0")
+	pMissing := NewProviderResource("b", "p2", "1")
+	missing := NewResource("missing", pA)
+	a := NewResource("a", pMissing)
+	b := NewResource("b", pA, a.URN, missing.URN)
+	b.Parent = missing.URN
+	b.PropertyDependencies = map[resource.PropertyKey][]resource.URN{"foo": {a.URN, missing.URN}}
+	snap := NewSnapshot([]*resource.State{
+		pA,
+		a,
+		b,
+	})
+	snap.Manifest.Magic = snap.Manifest.NewMagic()
+
+	repairs, err := RepairSnapshot(snap)
+	assert.NoError(t, err)
+	assert.Len(t, repairs, 4)
+	assert.Equal(t, "", a.Provider)
+	assert.Equal(t, resource.URN(""), b.Parent)
+	assert.Equal(t, []resource.URN{a.URN}, b.Dependencies)
+	assert.Equal(t, []resource.URN{a.URN}, b.PropertyDependencies["foo"])
+}
+
+func TestRepairDependencyCycle(t *testing.T) {
+	a := NewResource("a", nil)
+	b := NewResource("b", nil, a.URN)
+	a.Dependencies = []resource.URN{b.URN}
+	snap := NewSnapshot([]*resource.State{
+		a,
+		b,
+	})
+
+	_, err := RepairSnapshot(snap)
+	assert.Error(t, err)
+}
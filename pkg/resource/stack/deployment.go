@@ -295,6 +295,9 @@ func SerializeResource(res *resource.State, enc config.Encrypter, showSecrets bo
 		AdditionalSecretOutputs: res.AdditionalSecretOutputs,
 		Aliases:                 res.Aliases,
 		ImportID:                res.ImportID,
+		RetainOnDelete:          res.RetainOnDelete,
+		ReplaceOnChanges:        res.ReplaceOnChanges,
+		DeletedWith:             res.DeletedWith,
 	}
 
 	if res.CustomTimeouts.IsNotEmpty() {
@@ -456,7 +459,7 @@ func DeserializeResource(res apitype.ResourceV3, dec config.Decrypter, enc confi
 		res.Type, res.URN, res.Custom, res.Delete, res.ID,
 		inputs, outputs, res.Parent, res.Protect, res.External, res.Dependencies, res.InitErrors, res.Provider,
 		res.PropertyDependencies, res.PendingReplacement, res.AdditionalSecretOutputs, res.Aliases, res.CustomTimeouts,
-		res.ImportID), nil
+		res.ImportID, res.RetainOnDelete, res.ReplaceOnChanges, res.DeletedWith), nil
 }
 
 func DeserializeOperation(op apitype.OperationV2, dec config.Decrypter,
@@ -90,6 +90,9 @@ func TestDeploymentSerialization(t *testing.T) {
 		nil,
 		nil,
 		"",
+		false,
+		nil,
+		"",
 	)
 
 	dep, err := SerializeResource(res, config.NopEncrypter, false /* showSecrets */)
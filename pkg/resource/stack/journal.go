@@ -0,0 +1,120 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// ReplayJournal applies the entries of the given journals, in order, to the given deployment, which is updated
+// in-place. Journals recorded against any checkpoint other than the one the deployment was loaded from are stale, and
+// are ignored. The number of entries that were replayed is returned.
+func ReplayJournal(deployment *apitype.DeploymentV3, journals []apitype.JournalV1) (int, error) {
+	contract.Require(deployment != nil, "deployment")
+
+	var live []apitype.JournalV1
+	for _, journal := range journals {
+		if journal.Base.Equal(deployment.Manifest.Time) {
+			live = append(live, journal)
+		}
+	}
+	if len(live) == 0 {
+		return 0, nil
+	}
+
+	baseResources := len(deployment.Resources)
+	newResources := live[0].NewResources
+	if newResources < 0 || newResources > baseResources {
+		return 0, errors.Errorf("journal refers to %d new resources, but the checkpoint has %d", newResources, baseResources)
+	}
+
+	// Every resource and pending operation is tracked by its slot or index, so that entries can refer to them. Nothing
+	// is ever removed from these lists; removals are recorded separately.
+	slots := make([]apitype.ResourceV3, baseResources)
+	copy(slots, deployment.Resources)
+	removed := make(map[int]bool)
+
+	operations := make([]apitype.OperationV2, len(deployment.PendingOperations))
+	copy(operations, deployment.PendingOperations)
+	completed := make(map[int]bool)
+
+	replayed := 0
+	for _, journal := range live {
+		for _, entry := range journal.Entries {
+			switch entry.Kind {
+			case apitype.JournalEntryNew:
+				if entry.State == nil {
+					return 0, errors.Errorf("journal entry %d: missing resource state", replayed)
+				}
+				slots = append(slots, *entry.State)
+			case apitype.JournalEntryDone:
+				if entry.Slot < 0 || entry.Slot >= len(slots) {
+					return 0, errors.Errorf("journal entry %d: invalid slot %d", replayed, entry.Slot)
+				}
+				removed[entry.Slot] = true
+			case apitype.JournalEntryUpdate:
+				if entry.Slot < 0 || entry.Slot >= len(slots) {
+					return 0, errors.Errorf("journal entry %d: invalid slot %d", replayed, entry.Slot)
+				}
+				if entry.State == nil {
+					return 0, errors.Errorf("journal entry %d: missing resource state", replayed)
+				}
+				slots[entry.Slot] = *entry.State
+			case apitype.JournalEntryBegin:
+				if entry.State == nil {
+					return 0, errors.Errorf("journal entry %d: missing resource state", replayed)
+				}
+				operations = append(operations, apitype.OperationV2{Resource: *entry.State, Type: entry.Type})
+			case apitype.JournalEntryEnd:
+				if entry.Operation < 0 || entry.Operation >= len(operations) {
+					return 0, errors.Errorf("journal entry %d: invalid operation %d", replayed, entry.Operation)
+				}
+				completed[entry.Operation] = true
+			default:
+				return 0, errors.Errorf("journal entry %d: unknown kind %q", replayed, entry.Kind)
+			}
+			replayed++
+		}
+	}
+
+	// Resources produced by the update come first, followed by the resources produced by replayed entries, and then
+	// by the remaining resources of the checkpoint. This is the same order in which the update itself would have
+	// saved them.
+	resources := make([]apitype.ResourceV3, 0, len(slots))
+	appendSlots := func(start, end int) {
+		for i := start; i < end; i++ {
+			if !removed[i] {
+				resources = append(resources, slots[i])
+			}
+		}
+	}
+	appendSlots(0, newResources)
+	appendSlots(baseResources, len(slots))
+	appendSlots(newResources, baseResources)
+	deployment.Resources = resources
+
+	var pending []apitype.OperationV2
+	for i, op := range operations {
+		if !completed[i] {
+			pending = append(pending, op)
+		}
+	}
+	deployment.PendingOperations = pending
+
+	return replayed, nil
+}
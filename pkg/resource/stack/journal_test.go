@@ -0,0 +1,123 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func journalTestDeployment(base time.Time) *apitype.DeploymentV3 {
+	return &apitype.DeploymentV3{
+		Manifest: apitype.ManifestV1{Time: base},
+		Resources: []apitype.ResourceV3{
+			{URN: "new"},
+			{URN: "a"},
+			{URN: "b"},
+		},
+		PendingOperations: []apitype.OperationV2{
+			{Resource: apitype.ResourceV3{URN: "b"}, Type: apitype.OperationTypeUpdating},
+		},
+	}
+}
+
+func journalTestURNs(deployment *apitype.DeploymentV3) ([]resource.URN, []resource.URN) {
+	var resources, operations []resource.URN
+	for _, res := range deployment.Resources {
+		resources = append(resources, res.URN)
+	}
+	for _, op := range deployment.PendingOperations {
+		operations = append(operations, op.Resource.URN)
+	}
+	return resources, operations
+}
+
+func TestReplayJournal(t *testing.T) {
+	base := time.Now()
+	deployment := journalTestDeployment(base)
+
+	journals := []apitype.JournalV1{
+		{
+			// A stale journal, recorded against some earlier checkpoint.
+			Base:         base.Add(-time.Minute),
+			NewResources: 0,
+			Entries:      []apitype.JournalEntryV1{{Kind: apitype.JournalEntryDone, Slot: 0}},
+		},
+		{
+			Base:         base,
+			NewResources: 1,
+			Entries: []apitype.JournalEntryV1{
+				{Kind: apitype.JournalEntryEnd, Operation: 0},
+				{Kind: apitype.JournalEntryNew, State: &apitype.ResourceV3{URN: "b", Delete: false}},
+				{Kind: apitype.JournalEntryDone, Slot: 2},
+				{Kind: apitype.JournalEntryBegin, State: &apitype.ResourceV3{URN: "c"}, Type: apitype.OperationTypeCreating},
+			},
+		},
+		{
+			Base:         base,
+			NewResources: 1,
+			Entries: []apitype.JournalEntryV1{
+				{Kind: apitype.JournalEntryUpdate, Slot: 1, State: &apitype.ResourceV3{URN: "a", Delete: true}},
+			},
+		},
+	}
+
+	replayed, err := ReplayJournal(deployment, journals)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, replayed)
+
+	// Resources produced by the journal come after those the checkpoint records as new, and ahead of the rest.
+	resources, operations := journalTestURNs(deployment)
+	assert.Equal(t, []resource.URN{"new", "b", "a"}, resources)
+	assert.True(t, deployment.Resources[2].Delete)
+	assert.Equal(t, []resource.URN{"c"}, operations)
+}
+
+func TestReplayStaleJournal(t *testing.T) {
+	base := time.Now()
+	deployment := journalTestDeployment(base)
+
+	replayed, err := ReplayJournal(deployment, []apitype.JournalV1{{
+		Base:    base.Add(time.Second),
+		Entries: []apitype.JournalEntryV1{{Kind: apitype.JournalEntryDone, Slot: 1}},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replayed)
+	assert.Equal(t, journalTestDeployment(base), deployment)
+}
+
+func TestReplayInvalidJournal(t *testing.T) {
+	base := time.Now()
+
+	for _, entry := range []apitype.JournalEntryV1{
+		{Kind: apitype.JournalEntryDone, Slot: 3},
+		{Kind: apitype.JournalEntryUpdate, Slot: 1},
+		{Kind: apitype.JournalEntryEnd, Operation: 1},
+		{Kind: apitype.JournalEntryNew},
+		{Kind: "unknown"},
+	} {
+		_, err := ReplayJournal(journalTestDeployment(base), []apitype.JournalV1{{
+			Base:         base,
+			NewResources: 1,
+			Entries:      []apitype.JournalEntryV1{entry},
+		}})
+		assert.Error(t, err, "%v", entry.Kind)
+	}
+}
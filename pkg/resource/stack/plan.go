@@ -0,0 +1,131 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// SerializePlan serializes a deployment plan. Secret values in the plan are encrypted with the given secrets manager,
+// which is recorded in the plan so that it can be deserialized later.
+func SerializePlan(plan *deploy.Plan, sm secrets.Manager, showSecrets bool) (*apitype.DeploymentPlanV1, error) {
+	contract.Require(plan != nil, "plan")
+
+	var enc config.Encrypter
+	var secretsProvider *apitype.SecretsProvidersV1
+	if sm != nil {
+		e, err := sm.Encrypter()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting encrypter for plan")
+		}
+		enc = e
+
+		secretsProvider = &apitype.SecretsProvidersV1{Type: sm.Type()}
+		if state := sm.State(); state != nil {
+			rm, err := json.Marshal(state)
+			if err != nil {
+				return nil, err
+			}
+			secretsProvider.State = rm
+		}
+	} else {
+		enc = config.NewPanicCrypter()
+	}
+
+	resourcePlans := make(map[resource.URN]apitype.ResourcePlanV1)
+	for urn, rp := range plan.ResourcePlans {
+		var steps []apitype.OpType
+		for _, op := range rp.Ops {
+			steps = append(steps, apitype.OpType(op))
+		}
+
+		var inputs map[string]interface{}
+		if rp.Inputs != nil {
+			sinp, err := SerializeProperties(rp.Inputs, enc, showSecrets)
+			if err != nil {
+				return nil, errors.Wrapf(err, "serializing inputs of %v", urn)
+			}
+			inputs = sinp
+		}
+
+		resourcePlans[urn] = apitype.ResourcePlanV1{
+			Steps:  steps,
+			Inputs: inputs,
+		}
+	}
+
+	return &apitype.DeploymentPlanV1{
+		SecretsProviders: secretsProvider,
+		ResourcePlans:    resourcePlans,
+	}, nil
+}
+
+// DeserializePlan deserializes a deployment plan, using the given secrets provider to decrypt its secret values.
+func DeserializePlan(plan apitype.DeploymentPlanV1, secretsProv SecretsProvider) (*deploy.Plan, error) {
+	var dec config.Decrypter
+	var enc config.Encrypter
+	if plan.SecretsProviders != nil && plan.SecretsProviders.Type != "" {
+		if secretsProv == nil {
+			return nil, errors.New("plan uses a SecretsProvider but no SecretsProvider was provided")
+		}
+
+		sm, err := secretsProv.OfType(plan.SecretsProviders.Type, plan.SecretsProviders.State)
+		if err != nil {
+			return nil, err
+		}
+		if dec, err = sm.Decrypter(); err != nil {
+			return nil, err
+		}
+		if enc, err = sm.Encrypter(); err != nil {
+			return nil, err
+		}
+	} else {
+		dec = config.NewPanicCrypter()
+		enc = config.NewPanicCrypter()
+	}
+
+	result := deploy.NewPlan()
+	for urn, rp := range plan.ResourcePlans {
+		var ops []deploy.StepOp
+		for _, step := range rp.Steps {
+			ops = append(ops, deploy.StepOp(step))
+		}
+
+		// A resource that is only deleted has no inputs, which is distinct from having an empty set of inputs.
+		var inputs resource.PropertyMap
+		if rp.Inputs != nil {
+			dinp, err := DeserializeProperties(rp.Inputs, dec, enc)
+			if err != nil {
+				return nil, errors.Wrapf(err, "deserializing inputs of %v", urn)
+			}
+			inputs = dinp
+		}
+
+		result.ResourcePlans[urn] = &deploy.ResourcePlan{
+			Ops:    ops,
+			Inputs: inputs,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+type testSecretsProvider struct {
+	sm secrets.Manager
+}
+
+func (p testSecretsProvider) OfType(ty string, state json.RawMessage) (secrets.Manager, error) {
+	return p.sm, nil
+}
+
+func TestPlanSerialization(t *testing.T) {
+	created := resource.URN("urn:pulumi:stack::project::pkg:index:typ::created")
+	deleted := resource.URN("urn:pulumi:stack::project::pkg:index:typ::deleted")
+	empty := resource.URN("urn:pulumi:stack::project::pkg:index:typ::empty")
+
+	plan := deploy.NewPlan()
+	plan.ResourcePlans[created] = &deploy.ResourcePlan{
+		Ops: []deploy.StepOp{deploy.OpCreate},
+		Inputs: resource.PropertyMap{
+			"name":     resource.NewStringProperty("foo"),
+			"password": resource.MakeSecret(resource.NewStringProperty("hunter2")),
+			"id":       resource.MakeComputed(resource.NewStringProperty("")),
+		},
+	}
+	plan.ResourcePlans[deleted] = &deploy.ResourcePlan{Ops: []deploy.StepOp{deploy.OpDelete}}
+	plan.ResourcePlans[empty] = &deploy.ResourcePlan{
+		Ops:    []deploy.StepOp{deploy.OpSame},
+		Inputs: resource.PropertyMap{},
+	}
+
+	sm := &testSecretsManager{}
+	serialized, err := SerializePlan(plan, sm, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "test", serialized.SecretsProviders.Type)
+	assert.Equal(t, []apitype.OpType{apitype.OpCreate}, serialized.ResourcePlans[created].Steps)
+	assert.Equal(t, 1, sm.encryptCalls)
+
+	// Round-trip the plan through JSON, as it would be saved to a file.
+	b, err := json.Marshal(serialized)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), `"hunter2"`)
+	var unmarshaled apitype.DeploymentPlanV1
+	assert.NoError(t, json.Unmarshal(b, &unmarshaled))
+
+	deserialized, err := DeserializePlan(unmarshaled, testSecretsProvider{sm: sm})
+	assert.NoError(t, err)
+	assert.Equal(t, plan, deserialized)
+
+	// A plan with secrets cannot be read without a secrets provider.
+	_, err = DeserializePlan(unmarshaled, nil)
+	assert.Error(t, err)
+}
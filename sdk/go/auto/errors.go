@@ -72,6 +72,27 @@ func IsCreateStack409Error(e error) bool {
 	return regex.MatchString(ae.stderr)
 }
 
+// IsResourceProtectedError returns true if a state operation failed because the resource is protected.
+func IsResourceProtectedError(e error) bool {
+	ae, ok := e.(autoError)
+	if !ok {
+		return false
+	}
+
+	return strings.Contains(ae.stderr, "because it is protected")
+}
+
+// IsResourceHasDependentsError returns true if a state operation failed because other resources depend on the
+// resource.
+func IsResourceHasDependentsError(e error) bool {
+	ae, ok := e.(autoError)
+	if !ok {
+		return false
+	}
+
+	return strings.Contains(ae.stderr, "because the following resources depend on it")
+}
+
 // IsCompilationError returns true if the program failed at the build/run step (only Typescript, Go, .NET)
 func IsCompilationError(e error) bool {
 	as, ok := e.(autoError)
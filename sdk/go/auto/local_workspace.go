@@ -26,6 +26,7 @@ import (
 	"github.com/blang/semver"
 	"github.com/pkg/errors"
 
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optconfig"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -123,8 +124,18 @@ func (l *LocalWorkspace) PostCommandCallback(ctx context.Context, stackName stri
 // GetConfig returns the value associated with the specified stack name and key,
 // scoped to the current workspace. LocalWorkspace reads this config from the matching Pulumi.stack.yaml file.
 func (l *LocalWorkspace) GetConfig(ctx context.Context, stackName string, key string) (ConfigValue, error) {
+	return l.GetConfigWithOptions(ctx, stackName, key)
+}
+
+// GetConfigWithOptions returns the value associated with the specified stack name and key using the optional
+// optconfig.Option values, scoped to the current workspace. With optconfig.Path(), the key is a path to a property
+// in a map or list.
+func (l *LocalWorkspace) GetConfigWithOptions(ctx context.Context, stackName string, key string,
+	opts ...optconfig.Option) (ConfigValue, error) {
 	var val ConfigValue
-	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "config", "get", key, "--json", "--stack", stackName)
+	args := []string{"config", "get", key, "--json", "--stack", stackName}
+	args = appendConfigOptions(args, opts)
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, args...)
 	if err != nil {
 		return val, newAutoError(errors.Wrap(err, "unable to read config"), stdout, stderr, errCode)
 	}
@@ -153,13 +164,33 @@ func (l *LocalWorkspace) GetAllConfig(ctx context.Context, stackName string) (Co
 // SetConfig sets the specified key-value pair on the provided stack name.
 // LocalWorkspace writes this value to the matching Pulumi.<stack>.yaml file in Workspace.WorkDir().
 func (l *LocalWorkspace) SetConfig(ctx context.Context, stackName string, key string, val ConfigValue) error {
-	secretArg := "--plaintext"
+	return l.SetConfigWithOptions(ctx, stackName, key, val)
+}
+
+// SetConfigWithOptions sets the specified key-value pair on the provided stack name using the optional
+// optconfig.Option values. With optconfig.Path(), the key is a path to a property in a map or list.
+func (l *LocalWorkspace) SetConfigWithOptions(ctx context.Context, stackName string, key string, val ConfigValue,
+	opts ...optconfig.Option) error {
+	typeArg := "--plaintext"
 	if val.Secret {
-		secretArg = "--secret"
+		typeArg = "--secret"
+	}
+
+	value := val.Value
+	if val.ObjectValue != nil {
+		if val.Secret {
+			return errors.New("unable to set config: structured values can't be secret")
+		}
+		b, err := json.Marshal(val.ObjectValue)
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal config value")
+		}
+		typeArg, value = "--json", string(b)
 	}
 
-	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx,
-		"config", "set", key, val.Value, secretArg, "--stack", stackName)
+	args := []string{"config", "set", key, value, typeArg, "--stack", stackName}
+	args = appendConfigOptions(args, opts)
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, args...)
 	if err != nil {
 		return newAutoError(errors.Wrap(err, "unable to set config"), stdout, stderr, errCode)
 	}
@@ -169,14 +200,34 @@ func (l *LocalWorkspace) SetConfig(ctx context.Context, stackName string, key st
 // SetAllConfig sets all values in the provided config map for the specified stack name.
 // LocalWorkspace writes the config to the matching Pulumi.<stack>.yaml file in Workspace.WorkDir().
 func (l *LocalWorkspace) SetAllConfig(ctx context.Context, stackName string, config ConfigMap) error {
+	return l.SetAllConfigWithOptions(ctx, stackName, config)
+}
+
+// SetAllConfigWithOptions sets all values in the provided config map for the specified stack name using the
+// optional optconfig.Option values. With optconfig.Path(), the keys are paths to properties in maps or lists.
+func (l *LocalWorkspace) SetAllConfigWithOptions(ctx context.Context, stackName string, config ConfigMap,
+	opts ...optconfig.Option) error {
 	args := []string{"config", "set-all", "--stack", stackName}
+	args = appendConfigOptions(args, opts)
 
 	for k, v := range config {
-		secretArg := "--plaintext"
+		typeArg := "--plaintext"
 		if v.Secret {
-			secretArg = "--secret"
+			typeArg = "--secret"
+		}
+
+		value := v.Value
+		if v.ObjectValue != nil {
+			if v.Secret {
+				return errors.Errorf("unable to set config: structured value for %q can't be secret", k)
+			}
+			b, err := json.Marshal(v.ObjectValue)
+			if err != nil {
+				return errors.Wrapf(err, "unable to marshal config value for %q", k)
+			}
+			typeArg, value = "--json", string(b)
 		}
-		args = append(args, secretArg, fmt.Sprintf("%s=%s", k, v.Value))
+		args = append(args, typeArg, fmt.Sprintf("%s=%s", k, value))
 	}
 
 	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, args...)
@@ -189,7 +240,16 @@ func (l *LocalWorkspace) SetAllConfig(ctx context.Context, stackName string, con
 // RemoveConfig removes the specified key-value pair on the provided stack name.
 // It will remove any matching values in the Pulumi.<stack>.yaml file in Workspace.WorkDir().
 func (l *LocalWorkspace) RemoveConfig(ctx context.Context, stackName string, key string) error {
-	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "config", "rm", key, "--stack", stackName)
+	return l.RemoveConfigWithOptions(ctx, stackName, key)
+}
+
+// RemoveConfigWithOptions removes the specified key-value pair on the provided stack name using the optional
+// optconfig.Option values. With optconfig.Path(), the key is a path to a property in a map or list.
+func (l *LocalWorkspace) RemoveConfigWithOptions(ctx context.Context, stackName string, key string,
+	opts ...optconfig.Option) error {
+	args := []string{"config", "rm", key, "--stack", stackName}
+	args = appendConfigOptions(args, opts)
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, args...)
 	if err != nil {
 		return newAutoError(errors.Wrap(err, "could not remove config"), stdout, stderr, errCode)
 	}
@@ -199,7 +259,15 @@ func (l *LocalWorkspace) RemoveConfig(ctx context.Context, stackName string, key
 // RemoveAllConfig removes all values in the provided key list for the specified stack name
 // It will remove any matching values in the Pulumi.<stack>.yaml file in Workspace.WorkDir().
 func (l *LocalWorkspace) RemoveAllConfig(ctx context.Context, stackName string, keys []string) error {
+	return l.RemoveAllConfigWithOptions(ctx, stackName, keys)
+}
+
+// RemoveAllConfigWithOptions removes all values in the provided key list for the specified stack name using the
+// optional optconfig.Option values. With optconfig.Path(), the keys are paths to properties in maps or lists.
+func (l *LocalWorkspace) RemoveAllConfigWithOptions(ctx context.Context, stackName string, keys []string,
+	opts ...optconfig.Option) error {
 	args := []string{"config", "rm-all", "--stack", stackName}
+	args = appendConfigOptions(args, opts)
 	args = append(args, keys...)
 	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, args...)
 	if err != nil {
@@ -208,6 +276,19 @@ func (l *LocalWorkspace) RemoveAllConfig(ctx context.Context, stackName string,
 	return nil
 }
 
+// appendConfigOptions appends the flags for the given config options to the arguments of a config command.
+func appendConfigOptions(args []string, opts []optconfig.Option) []string {
+	configOpts := &optconfig.Options{}
+	for _, o := range opts {
+		o.ApplyOption(configOpts)
+	}
+
+	if configOpts.Path {
+		args = append(args, "--path")
+	}
+	return args
+}
+
 // RefreshConfig gets and sets the config map used with the last Update for Stack matching stack name.
 // It will overwrite all configuration in the Pulumi.<stack>.yaml file in Workspace.WorkDir().
 func (l *LocalWorkspace) RefreshConfig(ctx context.Context, stackName string) (ConfigMap, error) {
@@ -223,6 +304,47 @@ func (l *LocalWorkspace) RefreshConfig(ctx context.Context, stackName string) (C
 	return cfg, nil
 }
 
+// GetTag returns the value of the specified tag on the provided stack name.
+func (l *LocalWorkspace) GetTag(ctx context.Context, stackName string, key string) (string, error) {
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "stack", "tag", "get", key, "--stack", stackName)
+	if err != nil {
+		return "", newAutoError(errors.Wrap(err, "unable to read tag"), stdout, stderr, errCode)
+	}
+	return strings.TrimSuffix(stdout, "\n"), nil
+}
+
+// SetTag sets the specified tag to the given value on the provided stack name.
+func (l *LocalWorkspace) SetTag(ctx context.Context, stackName string, key string, value string) error {
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "stack", "tag", "set", key, value, "--stack", stackName)
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "unable to set tag"), stdout, stderr, errCode)
+	}
+	return nil
+}
+
+// RemoveTag removes the specified tag from the provided stack name.
+func (l *LocalWorkspace) RemoveTag(ctx context.Context, stackName string, key string) error {
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "stack", "tag", "rm", key, "--stack", stackName)
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "unable to remove tag"), stdout, stderr, errCode)
+	}
+	return nil
+}
+
+// ListTags returns all of the tags on the provided stack name.
+func (l *LocalWorkspace) ListTags(ctx context.Context, stackName string) (map[string]string, error) {
+	var tags map[string]string
+	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "stack", "tag", "ls", "--json", "--stack", stackName)
+	if err != nil {
+		return tags, newAutoError(errors.Wrap(err, "unable to list tags"), stdout, stderr, errCode)
+	}
+	err = json.Unmarshal([]byte(stdout), &tags)
+	if err != nil {
+		return tags, errors.Wrap(err, "unable to unmarshal tags")
+	}
+	return tags, nil
+}
+
 // GetEnvVars returns the environment values scoped to the current workspace.
 func (l *LocalWorkspace) GetEnvVars() map[string]string {
 	if l.envvars == nil {
@@ -17,6 +17,7 @@ package auto
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"os"
@@ -24,6 +25,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,7 +35,9 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optconfig"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
@@ -922,9 +926,7 @@ func TestUpsertStackInlineSource(t *testing.T) {
 	assert.Equal(t, "succeeded", dRes.Summary.Result)
 }
 
-func TestNestedStackFails(t *testing.T) {
-	// FIXME: see https://github.com/pulumi/pulumi/issues/5301
-	t.Skip("skipping test, see pulumi/pulumi#5301")
+func TestNestedStack(t *testing.T) {
 	testCtx := context.Background()
 	sName := fmt.Sprintf("int_test%d", rangeIn(10000000, 99999999))
 	parentstackName := FullyQualifiedStackName(pulumiOrg, "parent", sName)
@@ -941,8 +943,12 @@ func TestNestedStackFails(t *testing.T) {
 
 	// initialize
 	s, err := NewStackInlineSource(testCtx, parentstackName, "parent", func(ctx *pulumi.Context) error {
-		_, err := nestedStack.Up(testCtx)
-		return err
+		res, err := nestedStack.Up(testCtx)
+		if err != nil {
+			return err
+		}
+		ctx.Export("exp_nested", pulumi.Any(res.Outputs["exp_static"].Value))
+		return nil
 	})
 	if err != nil {
 		t.Errorf("failed to initialize stack, err: %v", err)
@@ -958,12 +964,15 @@ func TestNestedStackFails(t *testing.T) {
 		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
 	}()
 
-	result, err := s.Up(testCtx)
-
-	t.Log(result)
-
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "nested stack operations are not supported")
+	// -- pulumi up --
+	res, err := s.Up(testCtx)
+	if err != nil {
+		t.Errorf("up failed, err: %v", err)
+		t.FailNow()
+	}
+	assert.Equal(t, "foo", res.Outputs["exp_nested"].Value)
+	assert.Equal(t, "update", res.Summary.Kind)
+	assert.Equal(t, "succeeded", res.Summary.Result)
 
 	// -- pulumi destroy --
 
@@ -984,6 +993,62 @@ func TestNestedStackFails(t *testing.T) {
 	assert.Equal(t, "succeeded", dRes.Summary.Result)
 }
 
+func TestConcurrentInlineStacks(t *testing.T) {
+	ctx := context.Background()
+	const stackCount = 4
+	sBase := rangeIn(10000000, 99999999)
+
+	var wg sync.WaitGroup
+	for i := 0; i < stackCount; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sName := fmt.Sprintf("int_test%d_%d", sBase, i)
+			stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+			// initialize
+			s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+				ctx.Export("exp_index", pulumi.Int(i))
+				ctx.Export("exp_stack", pulumi.String(ctx.Stack()))
+				return nil
+			})
+			if !assert.NoError(t, err, "failed to initialize stack") {
+				return
+			}
+
+			defer func() {
+				// -- pulumi stack rm --
+				err = s.Workspace().RemoveStack(ctx, s.Name())
+				assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+			}()
+
+			// -- pulumi preview --
+			_, err = s.Preview(ctx)
+			if !assert.NoError(t, err, "preview failed") {
+				return
+			}
+
+			// -- pulumi up --
+			res, err := s.Up(ctx)
+			if !assert.NoError(t, err, "up failed") {
+				return
+			}
+			// Each operation must only see its own program and stack.
+			assert.Equal(t, float64(i), res.Outputs["exp_index"].Value)
+			assert.Equal(t, sName, res.Outputs["exp_stack"].Value)
+
+			// -- pulumi destroy --
+			dRes, err := s.Destroy(ctx)
+			if !assert.NoError(t, err, "destroy failed") {
+				return
+			}
+			assert.Equal(t, "succeeded", dRes.Summary.Result)
+		}(i)
+	}
+	wg.Wait()
+}
+
 func TestProgressStreams(t *testing.T) {
 	ctx := context.Background()
 	pName := "inline_progress_streams"
@@ -1127,6 +1192,313 @@ func TestImportExportStack(t *testing.T) {
 	assert.Equal(t, "succeeded", dRes.Summary.Result)
 }
 
+type testComponent struct {
+	pulumi.ResourceState
+}
+
+func TestImportResources(t *testing.T) {
+	ctx := context.Background()
+	sName := fmt.Sprintf("int_test%d", rangeIn(10000000, 99999999))
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	err = s.Workspace().InstallPlugin(ctx, "random", "v4.2.0")
+	require.NoError(t, err)
+
+	_, err = s.ImportResources(ctx, nil)
+	assert.Error(t, err)
+
+	// -- pulumi import --
+	res, err := s.ImportResources(ctx, []ImportResource{{
+		Type: "random:index/randomPassword:RandomPassword",
+		Name: "password",
+		ID:   "supersecret",
+	}}, optimport.Protect(false), optimport.Message("import a password"))
+	if err != nil {
+		t.Errorf("import failed, err: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "resource-import", res.Summary.Kind)
+	assert.Equal(t, "succeeded", res.Summary.Result)
+	assert.Equal(t, "import a password", res.Summary.Message)
+	require.NotNil(t, res.Summary.ResourceChanges)
+	assert.Equal(t, 1, (*res.Summary.ResourceChanges)["import"])
+	assert.Contains(t, res.GeneratedCode, `random.NewRandomPassword(ctx, "password"`)
+
+	state, err := s.Export(ctx)
+	require.NoError(t, err)
+	var deployment apitype.DeploymentV3
+	require.NoError(t, json.Unmarshal(state.Deployment, &deployment))
+
+	var imported *apitype.ResourceV3
+	for i := range deployment.Resources {
+		if deployment.Resources[i].Type == "random:index/randomPassword:RandomPassword" {
+			imported = &deployment.Resources[i]
+		}
+	}
+	require.NotNil(t, imported, "imported resource not found in state")
+	assert.False(t, imported.Protect)
+
+	// -- pulumi destroy --
+
+	dRes, err := s.Destroy(ctx)
+	if err != nil {
+		t.Errorf("destroy failed, err: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "destroy", dRes.Summary.Kind)
+	assert.Equal(t, "succeeded", dRes.Summary.Result)
+}
+
+func TestStateOperations(t *testing.T) {
+	ctx := context.Background()
+	sName := fmt.Sprintf("int_test%d", rangeIn(10000000, 99999999))
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		var a, b testComponent
+		if err := ctx.RegisterComponentResource("test:index:Component", "a", &a, pulumi.Protect(true)); err != nil {
+			return err
+		}
+		return ctx.RegisterComponentResource("test:index:Component", "b", &b, pulumi.DependsOn([]pulumi.Resource{&a}))
+	})
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	// -- pulumi up --
+	_, err = s.Up(ctx)
+	if err != nil {
+		t.Errorf("up failed, err: %v", err)
+		t.FailNow()
+	}
+
+	components := func() map[string]string {
+		state, err := s.Export(ctx)
+		require.NoError(t, err)
+
+		var deployment apitype.DeploymentV3
+		require.NoError(t, json.Unmarshal(state.Deployment, &deployment))
+
+		urns := map[string]string{}
+		for _, r := range deployment.Resources {
+			if r.Type == "test:index:Component" {
+				urns[string(r.URN.Name())] = string(r.URN)
+			}
+		}
+		return urns
+	}
+	urns := components()
+	require.Len(t, urns, 2)
+
+	// -- pulumi state delete --
+	err = s.DeleteResource(ctx, urns["a"])
+	assert.True(t, IsResourceProtectedError(err), "expected a protected resource error, got: %v", err)
+
+	// -- pulumi state unprotect --
+	err = s.UnprotectResource(ctx, urns["a"])
+	require.NoError(t, err)
+
+	err = s.DeleteResource(ctx, urns["a"])
+	assert.True(t, IsResourceHasDependentsError(err), "expected a dependents error, got: %v", err)
+
+	// -- pulumi state rename --
+	err = s.RenameResource(ctx, urns["b"], "c")
+	require.NoError(t, err)
+	urns = components()
+	assert.Contains(t, urns, "c")
+	assert.NotContains(t, urns, "b")
+
+	err = s.DeleteResource(ctx, urns["c"])
+	require.NoError(t, err)
+	err = s.DeleteResource(ctx, urns["a"])
+	require.NoError(t, err)
+	assert.Empty(t, components())
+
+	// -- pulumi destroy --
+
+	dRes, err := s.Destroy(ctx)
+	if err != nil {
+		t.Errorf("destroy failed, err: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "destroy", dRes.Summary.Kind)
+	assert.Equal(t, "succeeded", dRes.Summary.Result)
+}
+
+func TestStackTagsRenameAndHistoryDetails(t *testing.T) {
+	ctx := context.Background()
+	sName := fmt.Sprintf("int_test%d", rangeIn(10000000, 99999999))
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		ctx.Export("exp_static", pulumi.String("foo"))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	// -- pulumi stack tag --
+	err = s.SetTag(ctx, "owner", "automation")
+	require.NoError(t, err)
+	val, err := s.GetTag(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, "automation", val)
+	tags, err := s.ListTags(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "automation", tags["owner"])
+	err = s.RemoveTag(ctx, "owner")
+	require.NoError(t, err)
+	tags, err = s.ListTags(ctx)
+	require.NoError(t, err)
+	assert.NotContains(t, tags, "owner")
+
+	// -- pulumi up --
+	res, err := s.Up(ctx)
+	if err != nil {
+		t.Errorf("up failed, err: %v", err)
+		t.FailNow()
+	}
+
+	// -- pulumi stack export --version --
+	details, err := s.HistoryDetails(ctx, res.Summary.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "update", details.Summary.Kind)
+	assert.Equal(t, res.Summary.Version, details.Summary.Version)
+	assert.NotEmpty(t, details.Deployment.Deployment)
+
+	_, err = s.HistoryDetails(ctx, res.Summary.Version+1)
+	assert.Error(t, err)
+
+	// -- pulumi stack rename --
+	newName := FullyQualifiedStackName(pulumiOrg, pName, sName+"_renamed")
+	err = s.Rename(ctx, newName)
+	require.NoError(t, err)
+	assert.Equal(t, newName, s.Name())
+
+	// -- pulumi destroy --
+
+	dRes, err := s.Destroy(ctx)
+	if err != nil {
+		t.Errorf("destroy failed, err: %v", err)
+		t.FailNow()
+	}
+
+	assert.Equal(t, "destroy", dRes.Summary.Kind)
+	assert.Equal(t, "succeeded", dRes.Summary.Result)
+}
+
+func TestStructuredConfig(t *testing.T) {
+	ctx := context.Background()
+	sName := fmt.Sprintf("int_test%d", rangeIn(10000000, 99999999))
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("failed to initialize stack, err: %v", err)
+		t.FailNow()
+	}
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	// Structured values are stored as objects.
+	err = s.SetConfig(ctx, "servers", ConfigValue{ObjectValue: []interface{}{
+		map[string]interface{}{"host": "a.example.com", "port": 8080},
+	}})
+	require.NoError(t, err)
+
+	// Path keys address properties inside the object.
+	err = s.SetConfigWithOptions(ctx, "servers[0].tls.enabled", ConfigValue{Value: "true"}, optconfig.Path())
+	require.NoError(t, err)
+	err = s.SetAllConfigWithOptions(ctx, ConfigMap{
+		"servers[1].host": ConfigValue{Value: "b.example.com"},
+		"servers[1].tags": ConfigValue{ObjectValue: []string{"blue", "green"}},
+	}, optconfig.Path())
+	require.NoError(t, err)
+
+	type server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+		TLS  struct {
+			Enabled bool `json:"enabled"`
+		} `json:"tls"`
+		Tags []string `json:"tags"`
+	}
+
+	val, err := s.GetConfig(ctx, "servers")
+	require.NoError(t, err)
+	var servers []server
+	err = val.Decode(&servers)
+	require.NoError(t, err)
+	require.Len(t, servers, 2)
+	assert.Equal(t, "a.example.com", servers[0].Host)
+	assert.Equal(t, 8080, servers[0].Port)
+	assert.True(t, servers[0].TLS.Enabled)
+	assert.Equal(t, "b.example.com", servers[1].Host)
+	assert.Equal(t, []string{"blue", "green"}, servers[1].Tags)
+
+	val, err = s.GetConfigWithOptions(ctx, "servers[0].port", optconfig.Path())
+	require.NoError(t, err)
+	var port int
+	err = val.Decode(&port)
+	require.NoError(t, err)
+	assert.Equal(t, 8080, port)
+
+	err = s.RemoveConfigWithOptions(ctx, "servers[1]", optconfig.Path())
+	require.NoError(t, err)
+	val, err = s.GetConfig(ctx, "servers")
+	require.NoError(t, err)
+	servers = nil
+	err = val.Decode(&servers)
+	require.NoError(t, err)
+	assert.Len(t, servers, 1)
+
+	// Structured values can't be secret.
+	err = s.SetConfig(ctx, "creds", ConfigValue{ObjectValue: map[string]string{"user": "admin"}, Secret: true})
+	assert.Error(t, err)
+}
+
 func TestNestedConfig(t *testing.T) {
 	if getTestOrg() != "pulumi-test" {
 		return
@@ -0,0 +1,45 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optconfig contains functional options to be used with stack config operations
+// github.com/sdk/v2/go/x/auto Stack.SetConfig(...optconfig.Option)
+package optconfig
+
+// Path treats the key as a path to a property in a map or list, such as `outer.inner` or `names[0].first`, rather
+// than as a key of its own.
+func Path() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Path = true
+	})
+}
+
+// Option is a parameter to be applied to a config operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// Treat the key as a path to a property in a map or list
+	Path bool
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
@@ -52,6 +52,28 @@ func TargetDependents() Option {
 	})
 }
 
+// Exclude specifies a list of resource URNs to leave untouched during the destroy
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also excludes resources that depend on the resources specified in the Exclude list
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ContinueOnError keeps executing operations that do not depend on a failed operation, rather than stopping at the
+// first error
+func ContinueOnError() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ContinueOnError = true
+	})
+}
+
 // ProgressStreams allows specifying one or more io.Writers to redirect incremental destroy output
 func ProgressStreams(writers ...io.Writer) Option {
 	return optionFunc(func(opts *Options) {
@@ -98,6 +120,12 @@ type Options struct {
 	Target []string
 	// Allows updating of dependent targets discovered but not specified in the Target list
 	TargetDependents bool
+	// Specify a list of resource URNs to leave untouched during the destroy
+	Exclude []string
+	// Also exclude resources that depend on the resources in the Exclude list
+	ExcludeDependents bool
+	// Keep executing operations that do not depend on a failed operation
+	ContinueOnError bool
 	// ProgressStreams allows specifying one or more io.Writers to redirect incremental destroy output
 	ProgressStreams []io.Writer
 	// EventStreams allows specifying one or more channels to receive the Pulumi event stream
@@ -0,0 +1,107 @@
+// Copyright 2016-2020, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optimport contains functional options to be used with stack import operations
+// github.com/sdk/v2/go/x/auto Stack.ImportResources(...optimport.Option)
+package optimport
+
+import (
+	"io"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
+)
+
+// Parallel is the number of resource operations to run in parallel at once during the import
+// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+func Parallel(n int) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Parallel = n
+	})
+}
+
+// Message (optional) to associate with the import operation
+func Message(message string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Message = message
+	})
+}
+
+// NameTable maps the parent and provider names used by the imported resources to their URNs.
+// These names are also used as variable names in the generated code.
+func NameTable(names map[string]string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.NameTable = names
+	})
+}
+
+// Protect specifies whether the imported resources are protected from deletion. Defaults to true.
+func Protect(protect bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Protect = &protect
+	})
+}
+
+// ProgressStreams allows specifying one or more io.Writers to redirect incremental import output
+func ProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ProgressStreams = writers
+	})
+}
+
+// DebugLogging provides options for verbose logging to standard error, and enabling plugin logs.
+func DebugLogging(debugOpts debug.LoggingOptions) Option {
+	return optionFunc(func(opts *Options) {
+		opts.DebugLogOpts = debugOpts
+	})
+}
+
+// UserAgent specifies the agent responsible for the update, stored in backends as "environment.exec.agent"
+func UserAgent(agent string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.UserAgent = agent
+	})
+}
+
+// Option is a parameter to be applied to a Stack.ImportResources() operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// Parallel is the number of resource operations to run in parallel at once
+	// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+	Parallel int
+	// Message (optional) to associate with the import operation
+	Message string
+	// NameTable maps the parent and provider names used by the imported resources to their URNs
+	NameTable map[string]string
+	// Protect specifies whether the imported resources are protected from deletion. Defaults to true.
+	Protect *bool
+	// ProgressStreams allows specifying one or more io.Writers to redirect incremental import output
+	ProgressStreams []io.Writer
+	// DebugLogOpts specifies additional settings for debug logging
+	DebugLogOpts debug.LoggingOptions
+	// UserAgent specifies the agent responsible for the update, stored in backends as "environment.exec.agent"
+	UserAgent string
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
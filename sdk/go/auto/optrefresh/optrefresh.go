@@ -52,6 +52,27 @@ func Target(urns []string) Option {
 	})
 }
 
+// Exclude specifies a list of resource URNs to leave untouched during the refresh
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also excludes resources that depend on the resources specified in the Exclude list
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ClearPendingOperations resolves any operations left pending by an interrupted update, rather than failing
+func ClearPendingOperations() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ClearPendingOperations = true
+	})
+}
+
 // ProgressStreams allows specifying one or more io.Writers to redirect incremental refresh output
 func ProgressStreams(writers ...io.Writer) Option {
 	return optionFunc(func(opts *Options) {
@@ -98,6 +119,12 @@ type Options struct {
 	ExpectNoChanges bool
 	// Specify an exclusive list of resource URNs to re
 	Target []string
+	// Specify a list of resource URNs to leave untouched during the refresh
+	Exclude []string
+	// Also exclude resources that depend on the resources in the Exclude list
+	ExcludeDependents bool
+	// Resolve any operations left pending by an interrupted update
+	ClearPendingOperations bool
 	// ProgressStreams allows specifying one or more io.Writers to redirect incremental refresh output
 	ProgressStreams []io.Writer
 	// EventStreams allows specifying one or more channels to receive the Pulumi event stream
@@ -95,7 +95,6 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"strings"
 	"sync"
 
@@ -106,9 +105,12 @@ import (
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optconfig"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optstate"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/constant"
@@ -235,6 +237,12 @@ func (s *Stack) Preview(ctx context.Context, opts ...optpreview.Option) (Preview
 	if preOpts.TargetDependents {
 		sharedArgs = append(sharedArgs, "--target-dependents")
 	}
+	for _, eURN := range preOpts.Exclude {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if preOpts.ExcludeDependents {
+		sharedArgs = append(sharedArgs, "--exclude-dependents")
+	}
 	if preOpts.Parallel > 0 {
 		sharedArgs = append(sharedArgs, fmt.Sprintf("--parallel=%d", preOpts.Parallel))
 	}
@@ -330,6 +338,15 @@ func (s *Stack) Up(ctx context.Context, opts ...optup.Option) (UpResult, error)
 	if upOpts.TargetDependents {
 		sharedArgs = append(sharedArgs, "--target-dependents")
 	}
+	for _, eURN := range upOpts.Exclude {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if upOpts.ExcludeDependents {
+		sharedArgs = append(sharedArgs, "--exclude-dependents")
+	}
+	if upOpts.ContinueOnError {
+		sharedArgs = append(sharedArgs, "--continue-on-error")
+	}
 	if upOpts.Parallel > 0 {
 		sharedArgs = append(sharedArgs, fmt.Sprintf("--parallel=%d", upOpts.Parallel))
 	}
@@ -411,6 +428,15 @@ func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) (Refresh
 	for _, tURN := range refreshOpts.Target {
 		args = append(args, "--target %s", tURN)
 	}
+	for _, eURN := range refreshOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if refreshOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if refreshOpts.ClearPendingOperations {
+		args = append(args, "--clear-pending-operations")
+	}
 	if refreshOpts.Parallel > 0 {
 		args = append(args, fmt.Sprintf("--parallel=%d", refreshOpts.Parallel))
 	}
@@ -479,6 +505,15 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 	if destroyOpts.TargetDependents {
 		args = append(args, "--target-dependents")
 	}
+	for _, eURN := range destroyOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if destroyOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if destroyOpts.ContinueOnError {
+		args = append(args, "--continue-on-error")
+	}
 	if destroyOpts.Parallel > 0 {
 		args = append(args, fmt.Sprintf("--parallel=%d", destroyOpts.Parallel))
 	}
@@ -560,11 +595,57 @@ func (s *Stack) History(ctx context.Context, pageSize int, page int) ([]UpdateSu
 	return history, nil
 }
 
+// HistoryDetails returns the summary of the update with the given version along with the checkpoint it produced,
+// including the state of every resource in the stack at the end of that update.
+//
+// The engine events of past updates are not included: self-managed backends never store them, and the CLI has no
+// command to read them back from the Pulumi Service. To observe the events of an update as it runs, pass
+// optup.EventStreams (or the matching option of the other operations) when starting it.
+func (s *Stack) HistoryDetails(ctx context.Context, version int) (UpdateDetails, error) {
+	var details UpdateDetails
+
+	history, err := s.History(ctx, 0 /*pageSize*/, 0 /*page*/)
+	if err != nil {
+		return details, errors.Wrap(err, "failed to get update details")
+	}
+	found := false
+	for _, summary := range history {
+		if summary.Version == version {
+			details.Summary, found = summary, true
+			break
+		}
+	}
+	if !found {
+		return details, errors.Errorf("no update with version %d found", version)
+	}
+
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		"stack", "export", "--show-secrets", "--version", fmt.Sprintf("%d", version))
+	if err != nil {
+		return details, newAutoError(errors.Wrap(err, "failed to get update details"), stdout, stderr, errCode)
+	}
+
+	err = json.Unmarshal([]byte(stdout), &details.Deployment)
+	if err != nil {
+		return details, errors.Wrap(err, "unable to unmarshal update checkpoint")
+	}
+
+	return details, nil
+}
+
 // GetConfig returns the config value associated with the specified key.
 func (s *Stack) GetConfig(ctx context.Context, key string) (ConfigValue, error) {
 	return s.Workspace().GetConfig(ctx, s.Name(), key)
 }
 
+// GetConfigWithOptions returns the config value associated with the specified key using the optional
+// optconfig.Option values.
+func (s *Stack) GetConfigWithOptions(ctx context.Context, key string, opts ...optconfig.Option) (ConfigValue, error) {
+	return s.Workspace().GetConfigWithOptions(ctx, s.Name(), key, opts...)
+}
+
 // GetAllConfig returns the full config map.
 func (s *Stack) GetAllConfig(ctx context.Context) (ConfigMap, error) {
 	return s.Workspace().GetAllConfig(ctx, s.Name())
@@ -575,26 +656,85 @@ func (s *Stack) SetConfig(ctx context.Context, key string, val ConfigValue) erro
 	return s.Workspace().SetConfig(ctx, s.Name(), key, val)
 }
 
+// SetConfigWithOptions sets the specified config key-value pair using the optional optconfig.Option values.
+func (s *Stack) SetConfigWithOptions(ctx context.Context, key string, val ConfigValue,
+	opts ...optconfig.Option) error {
+	return s.Workspace().SetConfigWithOptions(ctx, s.Name(), key, val, opts...)
+}
+
 // SetAllConfig sets all values in the provided config map.
 func (s *Stack) SetAllConfig(ctx context.Context, config ConfigMap) error {
 	return s.Workspace().SetAllConfig(ctx, s.Name(), config)
 }
 
+// SetAllConfigWithOptions sets all values in the provided config map using the optional optconfig.Option values.
+func (s *Stack) SetAllConfigWithOptions(ctx context.Context, config ConfigMap, opts ...optconfig.Option) error {
+	return s.Workspace().SetAllConfigWithOptions(ctx, s.Name(), config, opts...)
+}
+
 // RemoveConfig removes the specified config key-value pair.
 func (s *Stack) RemoveConfig(ctx context.Context, key string) error {
 	return s.Workspace().RemoveConfig(ctx, s.Name(), key)
 }
 
+// RemoveConfigWithOptions removes the specified config key-value pair using the optional optconfig.Option values.
+func (s *Stack) RemoveConfigWithOptions(ctx context.Context, key string, opts ...optconfig.Option) error {
+	return s.Workspace().RemoveConfigWithOptions(ctx, s.Name(), key, opts...)
+}
+
 // RemoveAllConfig removes all values in the provided list of keys.
 func (s *Stack) RemoveAllConfig(ctx context.Context, keys []string) error {
 	return s.Workspace().RemoveAllConfig(ctx, s.Name(), keys)
 }
 
+// RemoveAllConfigWithOptions removes all values in the provided list of keys using the optional optconfig.Option
+// values.
+func (s *Stack) RemoveAllConfigWithOptions(ctx context.Context, keys []string, opts ...optconfig.Option) error {
+	return s.Workspace().RemoveAllConfigWithOptions(ctx, s.Name(), keys, opts...)
+}
+
 // RefreshConfig gets and sets the config map used with the last Update.
 func (s *Stack) RefreshConfig(ctx context.Context) (ConfigMap, error) {
 	return s.Workspace().RefreshConfig(ctx, s.Name())
 }
 
+// GetTag returns the value of the specified stack tag.
+func (s *Stack) GetTag(ctx context.Context, key string) (string, error) {
+	return s.Workspace().GetTag(ctx, s.Name(), key)
+}
+
+// SetTag sets the specified stack tag to the given value.
+func (s *Stack) SetTag(ctx context.Context, key string, value string) error {
+	return s.Workspace().SetTag(ctx, s.Name(), key, value)
+}
+
+// RemoveTag removes the specified stack tag.
+func (s *Stack) RemoveTag(ctx context.Context, key string) error {
+	return s.Workspace().RemoveTag(ctx, s.Name(), key)
+}
+
+// ListTags returns all of the stack's tags.
+func (s *Stack) ListTags(ctx context.Context) (map[string]string, error) {
+	return s.Workspace().ListTags(ctx, s.Name())
+}
+
+// Rename renames the stack, along with its configuration in the Workspace, and selects it under its new name.
+// Note that renaming a stack changes the value of `pulumi.Context.Stack()` in the program, so the next update will
+// replace any resources whose names are derived from it.
+// https://www.pulumi.com/docs/reference/cli/pulumi_stack_rename/
+func (s *Stack) Rename(ctx context.Context, newName string) error {
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		"stack", "rename", newName)
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to rename stack"), stdout, stderr, errCode)
+	}
+
+	s.stackName = newName
+	return nil
+}
+
 // Info returns a summary of the Stack including its URL.
 func (s *Stack) Info(ctx context.Context) (StackSummary, error) {
 	var info StackSummary
@@ -643,6 +783,158 @@ func (s *Stack) Import(ctx context.Context, state apitype.UntypedDeployment) err
 	return s.Workspace().ImportStack(ctx, s.Name(), state)
 }
 
+// ImportResources adopts existing cloud resources into the stack, protecting them from deletion unless
+// optimport.Protect(false) is given. The code that declares the imported resources in the project's language is
+// returned in the result; it should be added to the program so that the next update does not delete them.
+// https://www.pulumi.com/docs/reference/cli/pulumi_import/
+func (s *Stack) ImportResources(ctx context.Context, resources []ImportResource,
+	opts ...optimport.Option) (ImportResult, error) {
+	var res ImportResult
+
+	importOpts := &optimport.Options{}
+	for _, o := range opts {
+		o.ApplyOption(importOpts)
+	}
+
+	if len(resources) == 0 {
+		return res, errors.New("no resources to import")
+	}
+
+	dir, err := ioutil.TempDir("", "automation-import-")
+	if err != nil {
+		return res, errors.Wrap(err, "failed to create import directory")
+	}
+	defer os.RemoveAll(dir)
+
+	importFile, err := json.Marshal(struct {
+		NameTable map[string]string `json:"nameTable,omitempty"`
+		Resources []ImportResource  `json:"resources"`
+	}{importOpts.NameTable, resources})
+	if err != nil {
+		return res, errors.Wrap(err, "failed to marshal import file")
+	}
+	importPath, outPath := filepath.Join(dir, "import.json"), filepath.Join(dir, "out")
+	if err = ioutil.WriteFile(importPath, importFile, 0600); err != nil {
+		return res, errors.Wrap(err, "failed to write import file")
+	}
+
+	var args []string
+
+	args = debug.AddArgs(&importOpts.DebugLogOpts, args)
+	args = append(args, "import", "--yes", "--skip-preview", "--file", importPath, "--out", outPath)
+	if importOpts.Message != "" {
+		args = append(args, fmt.Sprintf("--message=%q", importOpts.Message))
+	}
+	if importOpts.Protect != nil {
+		args = append(args, fmt.Sprintf("--protect=%t", *importOpts.Protect))
+	}
+	if importOpts.Parallel > 0 {
+		args = append(args, fmt.Sprintf("--parallel=%d", importOpts.Parallel))
+	}
+	if importOpts.UserAgent != "" {
+		args = append(args, fmt.Sprintf("--exec-agent=%s", importOpts.UserAgent))
+	}
+	execKind := constant.ExecKindAutoLocal
+	if s.Workspace().Program() != nil {
+		execKind = constant.ExecKindAutoInline
+	}
+	args = append(args, fmt.Sprintf("--exec-kind=%s", execKind))
+
+	stdout, stderr, code, err := s.runPulumiCmdSync(ctx, importOpts.ProgressStreams, args...)
+	if err != nil {
+		return res, newAutoError(errors.Wrap(err, "failed to import resources"), stdout, stderr, code)
+	}
+
+	// The generated code is only written if at least one resource could be imported.
+	generated, err := ioutil.ReadFile(outPath)
+	if err != nil && !os.IsNotExist(err) {
+		return res, errors.Wrap(err, "failed to read generated code")
+	}
+
+	history, err := s.History(ctx, 1 /*pageSize*/, 1 /*page*/)
+	if err != nil {
+		return res, errors.Wrap(err, "failed to import resources")
+	}
+
+	var summary UpdateSummary
+	if len(history) > 0 {
+		summary = history[0]
+	}
+
+	res = ImportResult{
+		GeneratedCode: string(generated),
+		Summary:       summary,
+		StdOut:        stdout,
+		StdErr:        stderr,
+	}
+
+	return res, nil
+}
+
+// DeleteResource removes the resource with the given URN from the stack's state without deleting it from the
+// cloud provider. Resources that other resources depend on can't be deleted, and protected resources can only be
+// deleted if optstate.Force() is given.
+// https://www.pulumi.com/docs/reference/cli/pulumi_state_delete/
+func (s *Stack) DeleteResource(ctx context.Context, urn string, opts ...optstate.Option) error {
+	stateOpts := &optstate.Options{}
+	for _, o := range opts {
+		o.ApplyOption(stateOpts)
+	}
+
+	args := []string{"state", "delete", urn, "--yes"}
+	if stateOpts.Force {
+		args = append(args, "--force")
+	}
+
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(ctx, nil /* additionalOutput */, args...)
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to delete resource"), stdout, stderr, errCode)
+	}
+
+	return nil
+}
+
+// UnprotectResource clears the protect bit on the resource with the given URN, allowing it to be deleted.
+// https://www.pulumi.com/docs/reference/cli/pulumi_state_unprotect/
+func (s *Stack) UnprotectResource(ctx context.Context, urn string) error {
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		"state", "unprotect", urn, "--yes")
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to unprotect resource"), stdout, stderr, errCode)
+	}
+
+	return nil
+}
+
+// UnprotectAllResources clears the protect bit on every resource in the stack.
+func (s *Stack) UnprotectAllResources(ctx context.Context) error {
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		"state", "unprotect", "--all", "--yes")
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to unprotect resources"), stdout, stderr, errCode)
+	}
+
+	return nil
+}
+
+// RenameResource changes the name of the resource with the given URN in the stack's state.
+// https://www.pulumi.com/docs/reference/cli/pulumi_state_rename/
+func (s *Stack) RenameResource(ctx context.Context, urn, newName string) error {
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		"state", "rename", urn, newName, "--yes")
+	if err != nil {
+		return newAutoError(errors.Wrap(err, "failed to rename resource"), stdout, stderr, errCode)
+	}
+
+	return nil
+}
+
 // UpdateSummary provides a summary of a Stack lifecycle operation (up/preview/refresh/destroy).
 type UpdateSummary struct {
 	Version     int               `json:"version"`
@@ -658,6 +950,13 @@ type UpdateSummary struct {
 	ResourceChanges *map[string]int `json:"resourceChanges,omitempty"`
 }
 
+// UpdateDetails contains the summary of a single Stack lifecycle operation along with the checkpoint it produced.
+// It does not include the operation's engine events, which are only available while the operation runs.
+type UpdateDetails struct {
+	Summary    UpdateSummary
+	Deployment apitype.UntypedDeployment
+}
+
 // OutputValue models a Pulumi Stack output, providing the plaintext value and a boolean indicating secretness.
 type OutputValue struct {
 	Value  interface{}
@@ -771,6 +1070,36 @@ func (dr *DestroyResult) GetPermalink() (string, error) {
 	return GetPermalink(dr.StdOut)
 }
 
+// ImportResource describes an existing cloud resource to adopt into a stack with Stack.ImportResources.
+type ImportResource struct {
+	// Type is the type token of the resource, such as "aws:s3/bucket:Bucket".
+	Type string `json:"type"`
+	// Name is the name to give the resource in the stack.
+	Name string `json:"name"`
+	// ID is the provider-specific ID of the resource to import.
+	ID string `json:"id"`
+	// Parent is the optional name of the resource's parent in the import name table.
+	Parent string `json:"parent,omitempty"`
+	// Provider is the optional name of the resource's provider in the import name table.
+	Provider string `json:"provider,omitempty"`
+	// Version is the optional version of the provider to use for the import.
+	Version string `json:"version,omitempty"`
+}
+
+// ImportResult contains information about a Stack.ImportResources operation,
+// including the generated code for the imported resources and a summary of the changes.
+type ImportResult struct {
+	StdOut        string
+	StdErr        string
+	GeneratedCode string
+	Summary       UpdateSummary
+}
+
+// GetPermalink returns the permalink URL in the Pulumi Console for the import operation.
+func (ir *ImportResult) GetPermalink() (string, error) {
+	return GetPermalink(ir.StdOut)
+}
+
 // secretSentinel represents the CLI response for an output marked as "secret"
 const secretSentinel = "[secret]"
 
@@ -829,30 +1158,10 @@ type languageRuntimeServer struct {
 	done   chan error
 }
 
-// isNestedInvocation returns true if pulumi.RunWithContext is on the stack.
-func isNestedInvocation() bool {
-	depth, callers := 0, make([]uintptr, 32)
-	for {
-		n := runtime.Callers(depth, callers)
-		if n == 0 {
-			return false
-		}
-		depth += n
-
-		frames := runtime.CallersFrames(callers)
-		for f, more := frames.Next(); more; f, more = frames.Next() {
-			if f.Function == "github.com/pulumi/pulumi/sdk/v3/go/pulumi.RunWithContext" {
-				return true
-			}
-		}
-	}
-}
-
+// startLanguageRuntimeServer serves the given inline program to a single stack operation. Each operation gets its own
+// server and its own pulumi.Context, so any number of inline operations may run at once in the same process, including
+// operations started from within another inline program.
 func startLanguageRuntimeServer(fn pulumi.RunFunc) (*languageRuntimeServer, error) {
-	if isNestedInvocation() {
-		return nil, errors.New("nested stack operations are not supported https://github.com/pulumi/pulumi/issues/5058")
-	}
-
 	s := &languageRuntimeServer{
 		fn:     fn,
 		cancel: make(chan bool),
@@ -933,6 +1242,7 @@ func (s *languageRuntimeServer) Run(ctx context.Context, req *pulumirpc.RunReque
 	if err != nil {
 		return nil, err
 	}
+	defer contract.IgnoreClose(pulumiCtx)
 
 	err = func() (err error) {
 		defer func() {
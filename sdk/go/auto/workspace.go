@@ -16,7 +16,11 @@ package auto
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/pkg/errors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optconfig"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
@@ -45,18 +49,41 @@ type Workspace interface {
 	// GetConfig returns the value associated with the specified stack name and key,
 	// scoped to the current workspace.
 	GetConfig(context.Context, string, string) (ConfigValue, error)
+	// GetConfigWithOptions returns the value associated with the specified stack name and key using the optional
+	// optconfig.Option values, scoped to the current workspace.
+	GetConfigWithOptions(context.Context, string, string, ...optconfig.Option) (ConfigValue, error)
 	// GetAllConfig returns the config map for the specified stack name, scoped to the current workspace.
 	GetAllConfig(context.Context, string) (ConfigMap, error)
 	// SetConfig sets the specified key-value pair on the provided stack name.
 	SetConfig(context.Context, string, string, ConfigValue) error
+	// SetConfigWithOptions sets the specified key-value pair on the provided stack name using the optional
+	// optconfig.Option values.
+	SetConfigWithOptions(context.Context, string, string, ConfigValue, ...optconfig.Option) error
 	// SetAllConfig sets all values in the provided config map for the specified stack name.
 	SetAllConfig(context.Context, string, ConfigMap) error
+	// SetAllConfigWithOptions sets all values in the provided config map for the specified stack name using the
+	// optional optconfig.Option values.
+	SetAllConfigWithOptions(context.Context, string, ConfigMap, ...optconfig.Option) error
 	// RemoveConfig removes the specified key-value pair on the provided stack name.
 	RemoveConfig(context.Context, string, string) error
+	// RemoveConfigWithOptions removes the specified key-value pair on the provided stack name using the optional
+	// optconfig.Option values.
+	RemoveConfigWithOptions(context.Context, string, string, ...optconfig.Option) error
 	// RemoveAllConfig removes all values in the provided key list for the specified stack name.
 	RemoveAllConfig(context.Context, string, []string) error
+	// RemoveAllConfigWithOptions removes all values in the provided key list for the specified stack name using the
+	// optional optconfig.Option values.
+	RemoveAllConfigWithOptions(context.Context, string, []string, ...optconfig.Option) error
 	// RefreshConfig gets and sets the config map used with the last Update for Stack matching stack name.
 	RefreshConfig(context.Context, string) (ConfigMap, error)
+	// GetTag returns the value of the specified tag on the provided stack name.
+	GetTag(context.Context, string, string) (string, error)
+	// SetTag sets the specified tag to the given value on the provided stack name.
+	SetTag(context.Context, string, string, string) error
+	// RemoveTag removes the specified tag from the provided stack name.
+	RemoveTag(context.Context, string, string) error
+	// ListTags returns all of the tags on the provided stack name.
+	ListTags(context.Context, string) (map[string]string, error)
 	// GetEnvVars returns the environment values scoped to the current workspace.
 	GetEnvVars() map[string]string
 	// SetEnvVars sets the specified map of environment values scoped to the current workspace.
@@ -111,9 +138,39 @@ type Workspace interface {
 
 // ConfigValue is a configuration value used by a Pulumi program.
 // Allows differentiating between secret and plaintext values by setting the `Secret` property.
+// Structured values (maps and lists) are held in `ObjectValue`, which takes precedence over `Value` when set.
 type ConfigValue struct {
 	Value  string
 	Secret bool
+	// ObjectValue is the structured form of the value, if it is a map or a list. When set, it is written as JSON
+	// and stored in the stack's config as an object rather than a string.
+	ObjectValue interface{}
+}
+
+// Decode unmarshals the config value into the value pointed to by target, in the same way as json.Unmarshal.
+// Structured values are decoded from their object form; plain values are decoded as JSON where possible and as a
+// string otherwise.
+func (v ConfigValue) Decode(target interface{}) error {
+	if v.ObjectValue != nil {
+		b, err := json.Marshal(v.ObjectValue)
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal config value")
+		}
+		return json.Unmarshal(b, target)
+	}
+
+	if s, ok := target.(*string); ok {
+		*s = v.Value
+		return nil
+	}
+	if err := json.Unmarshal([]byte(v.Value), target); err == nil {
+		return nil
+	}
+	b, err := json.Marshal(v.Value)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal config value")
+	}
+	return json.Unmarshal(b, target)
 }
 
 // ConfigMap is a map of ConfigValue used by Pulumi programs.
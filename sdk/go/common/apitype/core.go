@@ -289,6 +289,14 @@ type ResourceV3 struct {
 	CustomTimeouts *resource.CustomTimeouts `json:"customTimeouts,omitempty" yaml:"customTimeouts,omitempty"`
 	// ImportID is the import input used for imported resources.
 	ImportID resource.ID `json:"importID,omitempty" yaml:"importID,omitempty"`
+	// If set to True, the providers Delete method will not be called for this resource. Pulumi simply stops tracking
+	// the deleted resource.
+	RetainOnDelete bool `json:"retainOnDelete,omitempty" yaml:"retainOnDelete,omitempty"`
+	// ReplaceOnChanges is a list of property paths that force a replacement of the resource when they change.
+	ReplaceOnChanges []string `json:"replaceOnChanges,omitempty" yaml:"replaceOnChanges,omitempty"`
+	// DeletedWith is the URN of a resource that deletes this resource when it is itself deleted. If both resources
+	// are deleted in the same deployment, this resource's provider is not asked to delete it.
+	DeletedWith resource.URN `json:"deletedWith,omitempty" yaml:"deletedWith,omitempty"`
 }
 
 // ManifestV1 captures meta-information about this checkpoint file, such as versions of binaries, etc.
@@ -150,6 +150,9 @@ type StepEventStateMetadata struct {
 	Parent string `json:"parent"`
 	// Protect is true to "protect" this resource (protected resources cannot be deleted).
 	Protect bool `json:"protect,omitempty"`
+	// RetainOnDelete is true if deleting this resource only removes it from the stack's state, without deleting it
+	// from its provider.
+	RetainOnDelete bool `json:"retainOnDelete,omitempty"`
 	// Inputs contains the resource's input properties (as specified by the program). Secrets have
 	// filtered out, and large assets have been replaced by hashes as applicable.
 	Inputs map[string]interface{} `json:"inputs"`
@@ -167,6 +170,15 @@ type ResourcePreEvent struct {
 	Planning bool              `json:"planning,omitempty"`
 }
 
+// ResWaitingEvent is emitted when a resource operation is queued behind a parallelism limit. A
+// ResourcePreEvent is emitted once the operation begins.
+type ResWaitingEvent struct {
+	Metadata StepEventMetadata `json:"metadata"`
+	// Limit is the provider package or resource type whose parallelism limit the operation is waiting on.
+	Limit    string `json:"limit"`
+	Planning bool   `json:"planning,omitempty"`
+}
+
 // ResOutputsEvent is emitted when a resource is finished being provisioned.
 type ResOutputsEvent struct {
 	Metadata StepEventMetadata `json:"metadata"`
@@ -203,6 +215,7 @@ type EngineEvent struct {
 	PreludeEvent     *PreludeEvent      `json:"preludeEvent,omitempty"`
 	SummaryEvent     *SummaryEvent      `json:"summaryEvent,omitempty"`
 	ResourcePreEvent *ResourcePreEvent  `json:"resourcePreEvent,omitempty"`
+	ResWaitingEvent  *ResWaitingEvent   `json:"resWaitingEvent,omitempty"`
 	ResOutputsEvent  *ResOutputsEvent   `json:"resOutputsEvent,omitempty"`
 	ResOpFailedEvent *ResOpFailedEvent  `json:"resOpFailedEvent,omitempty"`
 	PolicyEvent      *PolicyEvent       `json:"policyEvent,omitempty"`
@@ -0,0 +1,68 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitype
+
+import (
+	"time"
+)
+
+// JournalEntryKind is the kind of change to a snapshot that is recorded by a journal entry.
+type JournalEntryKind string
+
+const (
+	// JournalEntryNew records that State was produced by the update. The new state occupies the next free slot.
+	JournalEntryNew JournalEntryKind = "new"
+	// JournalEntryDone records that the resource in Slot was removed from the snapshot.
+	JournalEntryDone JournalEntryKind = "done"
+	// JournalEntryUpdate records that the resource in Slot was replaced by State.
+	JournalEntryUpdate JournalEntryKind = "update"
+	// JournalEntryBegin records that an operation of the given Type was started on State. The operation occupies the
+	// next free operation index.
+	JournalEntryBegin JournalEntryKind = "begin"
+	// JournalEntryEnd records that the pending operation at index Operation has completed.
+	JournalEntryEnd JournalEntryKind = "end"
+)
+
+// JournalEntryV1 is a single change to a snapshot recorded in a journal.
+//
+// Resources are identified by slot. The resources of the checkpoint to which the journal applies occupy the slots
+// numbered from zero, in order, and each resource produced by a subsequent JournalEntryNew occupies the next slot.
+// Pending operations are identified by index in the same way, starting with the pending operations of the checkpoint.
+type JournalEntryV1 struct {
+	// Kind is the kind of change that this entry records.
+	Kind JournalEntryKind `json:"kind" yaml:"kind"`
+	// Slot is the slot of the resource that was removed or replaced, if any.
+	Slot int `json:"slot,omitempty" yaml:"slot,omitempty"`
+	// Operation is the index of the pending operation that completed, if any.
+	Operation int `json:"operation,omitempty" yaml:"operation,omitempty"`
+	// State is the state of the resource that was produced, replaced, or operated upon, if any.
+	State *ResourceV3 `json:"state,omitempty" yaml:"state,omitempty"`
+	// Type is the type of the operation that was started, if any.
+	Type OperationType `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// JournalV1 is a batch of changes to a checkpoint, recorded while an update is in progress. Replaying every batch
+// recorded against a checkpoint recovers the state of the update when the last batch was written, even if the update
+// was interrupted before it could save a new checkpoint.
+type JournalV1 struct {
+	// Base is the time recorded in the manifest of the checkpoint to which the entries apply. Entries that were
+	// recorded against any other checkpoint are stale, and must be ignored.
+	Base time.Time `json:"base" yaml:"base"`
+	// NewResources is the number of resources at the start of the checkpoint that were produced by the update. The
+	// resources produced by replayed entries follow them, ahead of the remaining resources of the checkpoint.
+	NewResources int `json:"newResources" yaml:"newResources"`
+	// Entries are the changes that were recorded, in order.
+	Entries []JournalEntryV1 `json:"entries" yaml:"entries"`
+}
@@ -0,0 +1,38 @@
+// Copyright 2016-2021, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitype
+
+import (
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// DeploymentPlanV1 is the serializable version of a deployment plan, which records the operations that a preview
+// expects an update to perform on each resource. An update that is given a plan refuses to perform any operation
+// that the plan does not allow.
+type DeploymentPlanV1 struct {
+	// SecretsProviders is the secrets provider used to encrypt the secret values in the plan, if any.
+	SecretsProviders *SecretsProvidersV1 `json:"secrets_providers,omitempty" yaml:"secrets_providers,omitempty"`
+	// ResourcePlans are the plans for each resource, keyed by URN.
+	ResourcePlans map[resource.URN]ResourcePlanV1 `json:"resourcePlans,omitempty" yaml:"resourcePlans,omitempty"`
+}
+
+// ResourcePlanV1 is the serializable version of the plan for a single resource.
+type ResourcePlanV1 struct {
+	// Steps are the operations planned for the resource, in order.
+	Steps []OpType `json:"steps,omitempty" yaml:"steps,omitempty"`
+	// Inputs are the expected inputs of the resource, if it is registered or read by the program. Inputs are always
+	// written so that an empty set of inputs is distinguished from none.
+	Inputs map[string]interface{} `json:"inputs" yaml:"inputs"`
+}
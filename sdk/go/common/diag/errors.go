@@ -68,6 +68,10 @@ func GetTargetCouldNotBeFoundDidYouForgetError() *Diag {
 		"Did you forget to escape $ in your shell?")
 }
 
+func GetTargetPatternDidNotMatchError() *Diag {
+	return newError("", 2017, "Target pattern '%v' did not match any resources in the stack.")
+}
+
 func GetCannotDeleteParentResourceWithoutAlsoDeletingChildError(urn resource.URN) *Diag {
 	return newError(urn, 2012, "Cannot delete parent resource '%v' without also deleting child '%v'.")
 }
@@ -80,3 +84,13 @@ func GetResourceWillBeDestroyedButWasNotSpecifiedInTargetList(urn resource.URN)
 	return newError(urn, 2014, `Resource '%v' will be destroyed but was not specified in --target list.
 Either include resource in --target list or pass --target-dependents to proceed.`)
 }
+
+func GetResourceDependsOnExcludedResourceError(urn resource.URN) *Diag {
+	return newError(urn, 2015, `Resource '%v' depends on '%v' which was specified in --exclude list.
+Either remove resource from --exclude list or pass --exclude-dependents to proceed.`)
+}
+
+func GetResourceWillBeDestroyedButExcludedResourceDependsOnIt(urn resource.URN) *Diag {
+	return newError(urn, 2016, `Resource '%v' will be destroyed but excluded resource '%v' depends on it.
+Either include resource in --exclude list or pass --exclude-dependents to proceed.`)
+}
@@ -313,8 +313,16 @@ func (m Map) Set(k Key, v Value, path bool) error {
 		cursorKey = pkey
 	}
 
-	// Adjust the value (e.g. convert "true"/"false" to booleans and integers to ints) and set it.
-	adjustedValue := adjustObjectValue(v, path)
+	// Adjust the value (e.g. convert "true"/"false" to booleans and integers to ints) and set it. Object values are
+	// set as the maps, lists, numbers or booleans they hold.
+	var adjustedValue interface{}
+	if v.Object() {
+		if adjustedValue, err = v.ToObject(); err != nil {
+			return err
+		}
+	} else {
+		adjustedValue = adjustObjectValue(v, path)
+	}
 	if _, err = setValue(cursor, cursorKey, adjustedValue, parent, parentKey); err != nil {
 		return err
 	}
@@ -1135,6 +1135,33 @@ func TestSetSuccess(t *testing.T) {
 				MustMakeKey("my", "key"): NewObjectValue(`{"bar":"baz","secure":"value"}`),
 			},
 		},
+		{
+			Key:   `my:outer.inner`,
+			Path:  true,
+			Value: NewObjectValue(`{"list":[1,"true",false]}`),
+			Config: Map{
+				MustMakeKey("my", "outer"): NewObjectValue(`{"other":"value"}`),
+			},
+			Expected: Map{
+				MustMakeKey("my", "outer"): NewObjectValue(`{"inner":{"list":[1,"true",false]},"other":"value"}`),
+			},
+		},
+		{
+			Key:   `my:testKey[0]`,
+			Path:  true,
+			Value: NewObjectValue(`1.5`),
+			Expected: Map{
+				MustMakeKey("my", "testKey"): NewObjectValue(`[1.5]`),
+			},
+		},
+		{
+			Key:   `my:testKey`,
+			Path:  true,
+			Value: NewObjectValue(`{"a":"b"}`),
+			Expected: Map{
+				MustMakeKey("my", "testKey"): NewObjectValue(`{"a":"b"}`),
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -151,6 +151,20 @@ func (d DiffKind) IsReplace() bool {
 	}
 }
 
+// AsReplace converts a DiffKind into the equivalent replacement if it not already a replacement.
+func (d DiffKind) AsReplace() DiffKind {
+	switch d {
+	case DiffAdd:
+		return DiffAddReplace
+	case DiffDelete:
+		return DiffDeleteReplace
+	case DiffUpdate:
+		return DiffUpdateReplace
+	default:
+		return d
+	}
+}
+
 const (
 	// DiffAdd indicates that the property was added.
 	DiffAdd DiffKind = 0
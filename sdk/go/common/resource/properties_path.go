@@ -257,3 +257,16 @@ func (p PropertyPath) Delete(dest PropertyValue) bool {
 	return true
 
 }
+
+// Contains returns true if the given path is equal to or nested within this path.
+func (p PropertyPath) Contains(other PropertyPath) bool {
+	if len(other) < len(p) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -179,3 +179,26 @@ func TestAddResizePropertyPath(t *testing.T) {
 	_, ok := path.Add(NewArrayProperty([]PropertyValue{}), NewNumberProperty(42))
 	assert.True(t, ok)
 }
+
+func TestPropertyPathContains(t *testing.T) {
+	cases := []struct {
+		p, other string
+		expected bool
+	}{
+		{"root", "root", true},
+		{"root", "root.nested", true},
+		{"root", `root["nested"][0]`, true},
+		{"root.nested", "root", false},
+		{"root.nested", "root.other", false},
+		{"root[0]", "root[0].nested", true},
+		{"root[0]", "root[1].nested", false},
+		{"root", "other", false},
+	}
+	for _, c := range cases {
+		p, err := ParsePropertyPath(c.p)
+		assert.NoError(t, err)
+		other, err := ParsePropertyPath(c.other)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, p.Contains(other), "%s contains %s", c.p, c.other)
+	}
+}
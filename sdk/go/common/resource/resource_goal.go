@@ -37,13 +37,17 @@ type Goal struct {
 	Aliases                 []URN                 // additional URNs that should be aliased to this resource.
 	ID                      ID                    // the expected ID of the resource, if any.
 	CustomTimeouts          CustomTimeouts        // an optional config object for resource options
+	RetainOnDelete          bool                  // if set to True, the providers Delete method will not be called for this resource.
+	ReplaceOnChanges        []string              // a list of property paths that force a replacement when changed.
+	DeletedWith             URN                   // a resource whose deletion also deletes this resource, if any.
 }
 
 // NewGoal allocates a new resource goal state.
 func NewGoal(t tokens.Type, name tokens.QName, custom bool, props PropertyMap,
 	parent URN, protect bool, dependencies []URN, provider string, initErrors []string,
 	propertyDependencies map[PropertyKey][]URN, deleteBeforeReplace *bool, ignoreChanges []string,
-	additionalSecretOutputs []PropertyKey, aliases []URN, id ID, customTimeouts *CustomTimeouts) *Goal {
+	additionalSecretOutputs []PropertyKey, aliases []URN, id ID, customTimeouts *CustomTimeouts,
+	retainOnDelete bool, replaceOnChanges []string, deletedWith URN) *Goal {
 
 	g := &Goal{
 		Type:                    t,
@@ -61,6 +65,9 @@ func NewGoal(t tokens.Type, name tokens.QName, custom bool, props PropertyMap,
 		AdditionalSecretOutputs: additionalSecretOutputs,
 		Aliases:                 aliases,
 		ID:                      id,
+		RetainOnDelete:          retainOnDelete,
+		ReplaceOnChanges:        replaceOnChanges,
+		DeletedWith:             deletedWith,
 	}
 
 	if customTimeouts != nil {
@@ -43,6 +43,9 @@ type State struct {
 	Aliases                 []URN                 // TODO
 	CustomTimeouts          CustomTimeouts        // A config block that will be used to configure timeouts for CRUD operations
 	ImportID                ID                    // the resource's import id, if this was an imported resource.
+	RetainOnDelete          bool                  // if set to True, the providers Delete method will not be called for this resource.
+	ReplaceOnChanges        []string              // a list of property paths that force a replacement when changed.
+	DeletedWith             URN                   // a resource whose deletion also deletes this resource, if any.
 }
 
 // NewState creates a new resource value from existing resource state information.
@@ -51,7 +54,7 @@ func NewState(t tokens.Type, urn URN, custom bool, del bool, id ID,
 	external bool, dependencies []URN, initErrors []string, provider string,
 	propertyDependencies map[PropertyKey][]URN, pendingReplacement bool,
 	additionalSecretOutputs []PropertyKey, aliases []URN, timeouts *CustomTimeouts,
-	importID ID) *State {
+	importID ID, retainOnDelete bool, replaceOnChanges []string, deletedWith URN) *State {
 
 	contract.Assertf(t != "", "type was empty")
 	contract.Assertf(custom || id == "", "is custom or had empty ID")
@@ -76,6 +79,9 @@ func NewState(t tokens.Type, urn URN, custom bool, del bool, id ID,
 		AdditionalSecretOutputs: additionalSecretOutputs,
 		Aliases:                 aliases,
 		ImportID:                importID,
+		RetainOnDelete:          retainOnDelete,
+		ReplaceOnChanges:        replaceOnChanges,
+		DeletedWith:             deletedWith,
 	}
 
 	if timeouts != nil {
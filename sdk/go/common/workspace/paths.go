@@ -45,8 +45,14 @@ const (
 	PolicyDir = "policies"
 	// StackDir is the name of the directory that holds stack information for projects.
 	StackDir = "stacks"
+	// JournalDir is the name of the directory that holds the snapshot journals of stacks with updates in progress.
+	JournalDir = "journals"
 	// LockDir is the name of the directory that holds locking information for projects.
 	LockDir = "locks"
+	// ObjectDir is the name of the directory that holds content-addressed objects, such as historical resource states.
+	ObjectDir = "objects"
+	// TagDir is the name of the directory that holds stack tags for projects.
+	TagDir = "tags"
 	// TemplateDir is the name of the directory containing templates.
 	TemplateDir = "templates"
 	// TemplatePolicyDir is the name of the directory containing templates for Policy Packs.
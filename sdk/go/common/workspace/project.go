@@ -90,6 +90,10 @@ type Project struct {
 
 	// Backend is an optional backend configuration
 	Backend *ProjectBackend `json:"backend,omitempty" yaml:"backend,omitempty"`
+
+	// ParallelLimits optionally caps the number of concurrent operations on resources of a given provider package
+	// (e.g. "aws") or resource type (e.g. "aws:s3/bucket:Bucket").
+	ParallelLimits map[string]int `json:"parallelLimits,omitempty" yaml:"parallelLimits,omitempty"`
 }
 
 func (proj *Project) Validate() error {
@@ -99,6 +103,11 @@ func (proj *Project) Validate() error {
 	if proj.Runtime.Name() == "" {
 		return errors.New("project is missing a 'runtime' attribute")
 	}
+	for key, limit := range proj.ParallelLimits {
+		if limit < 1 {
+			return errors.Errorf("parallel limit for '%s' must be at least 1", key)
+		}
+	}
 
 	return nil
 }
@@ -610,6 +610,9 @@ func (ctx *Context) registerResource(
 				AdditionalSecretOutputs: inputs.additionalSecretOutputs,
 				Version:                 inputs.version,
 				Remote:                  remote,
+				RetainOnDelete:          inputs.retainOnDelete,
+				ReplaceOnChanges:        inputs.replaceOnChanges,
+				DeletedWith:             inputs.deletedWith,
 			})
 			if err != nil {
 				logging.V(9).Infof("RegisterResource(%s, %s): error: %v", t, name, err)
@@ -931,6 +934,9 @@ type resourceInputs struct {
 	aliases                 []string
 	additionalSecretOutputs []string
 	version                 string
+	retainOnDelete          bool
+	replaceOnChanges        []string
+	deletedWith             string
 }
 
 // prepareResourceInputs prepares the inputs for a resource operation, shared between read and register.
@@ -999,6 +1005,16 @@ func (ctx *Context) prepareResourceInputs(props Input, t string, opts *resourceO
 		aliases[i] = string(urn)
 	}
 
+	// Await the URN of the resource that deletes this resource, if any.
+	var deletedWith string
+	if opts.DeletedWith != nil {
+		urn, _, _, err := opts.DeletedWith.URN().awaitURN(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("error waiting for DeletedWith URN to resolve: %w", err)
+		}
+		deletedWith = string(urn)
+	}
+
 	return &resourceInputs{
 		parent:                  string(parent),
 		deps:                    deps,
@@ -1015,6 +1031,9 @@ func (ctx *Context) prepareResourceInputs(props Input, t string, opts *resourceO
 		aliases:                 aliases,
 		additionalSecretOutputs: additionalSecretOutputs,
 		version:                 version,
+		retainOnDelete:          opts.RetainOnDelete,
+		replaceOnChanges:        opts.ReplaceOnChanges,
+		deletedWith:             deletedWith,
 	}, nil
 }
 
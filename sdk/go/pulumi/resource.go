@@ -188,6 +188,9 @@ type resourceOptions struct {
 	CustomTimeouts *CustomTimeouts
 	// DeleteBeforeReplace, when set to true, ensures that this resource is deleted prior to replacement.
 	DeleteBeforeReplace bool
+	// DeletedWith is an optional resource that deletes this resource when it is itself deleted. If both resources are
+	// deleted in the same update, this resource's provider is not asked to delete it.
+	DeletedWith Resource
 	// DependsOn is an optional array of explicit dependencies on other resources.
 	DependsOn []Resource
 	// IgnoreChanges ignores changes to any of the specified properties.
@@ -205,6 +208,11 @@ type resourceOptions struct {
 	Provider ProviderResource
 	// Providers is an optional map of package to provider resource for a component resource.
 	Providers map[string]ProviderResource
+	// ReplaceOnChanges forces a replacement of the resource when any of the specified properties change.
+	ReplaceOnChanges []string
+	// RetainOnDelete, when set to true, ensures that the resource's provider is not asked to delete it when it is
+	// deleted from the stack. The resource is simply no longer managed by Pulumi.
+	RetainOnDelete bool
 	// Transformations is an optional list of transformations to apply to this resource during construction.
 	// The transformations are applied in order, and are applied prior to transformation and to parents
 	// walking from the resource up to the stack.
@@ -289,6 +297,14 @@ func DeleteBeforeReplace(o bool) ResourceOption {
 	})
 }
 
+// DeletedWith is an optional resource that deletes this resource when it is itself deleted. If both resources are
+// deleted in the same update, this resource's provider is not asked to delete it.
+func DeletedWith(r Resource) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.DeletedWith = r
+	})
+}
+
 // DependsOn is an optional array of explicit dependencies on other resources.
 func DependsOn(o []Resource) ResourceOption {
 	return resourceOption(func(ro *resourceOptions) {
@@ -367,6 +383,22 @@ func Providers(o ...ProviderResource) ResourceOption {
 	return ProviderMap(m)
 }
 
+// ReplaceOnChanges forces a replacement of the resource when any of the specified properties change. Properties are
+// given as property paths, such as "tags" or "settings.name".
+func ReplaceOnChanges(o []string) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.ReplaceOnChanges = append(ro.ReplaceOnChanges, o...)
+	})
+}
+
+// RetainOnDelete, when set to true, ensures that the resource's provider is not asked to delete it when it is
+// deleted from the stack. The resource is simply no longer managed by Pulumi.
+func RetainOnDelete(o bool) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.RetainOnDelete = o
+	})
+}
+
 // Timeouts is an optional configuration block used for CRUD operations
 func Timeouts(o *CustomTimeouts) ResourceOption {
 	return resourceOption(func(ro *resourceOptions) {
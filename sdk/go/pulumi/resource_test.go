@@ -133,12 +133,50 @@ func TestResourceOptionMergingProtect(t *testing.T) {
 	assert.Equal(t, false, opts.Protect)
 }
 
+func TestResourceOptionMergingRetainOnDelete(t *testing.T) {
+	// last value wins
+	opts := merge(RetainOnDelete(true), RetainOnDelete(false))
+	assert.Equal(t, false, opts.RetainOnDelete)
+}
+
+func TestResourceOptionMergingReplaceOnChanges(t *testing.T) {
+	// ReplaceOnChanges arrays are always appended together
+	r1 := "a"
+	r2 := "b.c"
+	r3 := "d[0]"
+
+	// two singleton options
+	opts := merge(ReplaceOnChanges([]string{r1}), ReplaceOnChanges([]string{r2}))
+	assert.Equal(t, []string{r1, r2}, opts.ReplaceOnChanges)
+
+	// nil r1
+	opts = merge(ReplaceOnChanges(nil), ReplaceOnChanges([]string{r2}))
+	assert.Equal(t, []string{r2}, opts.ReplaceOnChanges)
+
+	// multivalue arrays
+	opts = merge(ReplaceOnChanges([]string{r1, r2}), ReplaceOnChanges([]string{r2, r3}))
+	assert.Equal(t, []string{r1, r2, r2, r3}, opts.ReplaceOnChanges)
+}
+
 func TestResourceOptionMergingDeleteBeforeReplace(t *testing.T) {
 	// last value wins
 	opts := merge(DeleteBeforeReplace(true), DeleteBeforeReplace(false))
 	assert.Equal(t, false, opts.DeleteBeforeReplace)
 }
 
+func TestResourceOptionMergingDeletedWith(t *testing.T) {
+	r1 := &testRes{foo: "a"}
+	r2 := &testRes{foo: "b"}
+
+	// last value wins
+	opts := merge(DeletedWith(r1), DeletedWith(r2))
+	assert.Equal(t, r2, opts.DeletedWith)
+
+	// second value nil
+	opts = merge(DeletedWith(r1), DeletedWith(nil))
+	assert.Equal(t, nil, opts.DeletedWith)
+}
+
 func TestResourceOptionMergingImport(t *testing.T) {
 	id1 := ID("a")
 	id2 := ID("a")
@@ -1230,7 +1230,7 @@ proto.pulumirpc.ReadResourceResponse.prototype.hasProperties = function() {
  * @private {!Array<number>}
  * @const
  */
-proto.pulumirpc.RegisterResourceRequest.repeatedFields_ = [7,12,14,15];
+proto.pulumirpc.RegisterResourceRequest.repeatedFields_ = [7,12,14,15,24];
 
 
 
@@ -1284,7 +1284,10 @@ proto.pulumirpc.RegisterResourceRequest.toObject = function(includeInstance, msg
     supportspartialvalues: jspb.Message.getBooleanFieldWithDefault(msg, 19, false),
     remote: jspb.Message.getBooleanFieldWithDefault(msg, 20, false),
     acceptresources: jspb.Message.getBooleanFieldWithDefault(msg, 21, false),
-    providersMap: (f = msg.getProvidersMap()) ? f.toObject(includeInstance, undefined) : []
+    providersMap: (f = msg.getProvidersMap()) ? f.toObject(includeInstance, undefined) : [],
+    retainondelete: jspb.Message.getBooleanFieldWithDefault(msg, 23, false),
+    replaceonchangesList: (f = jspb.Message.getRepeatedField(msg, 24)) == null ? undefined : f,
+    deletedwith: jspb.Message.getFieldWithDefault(msg, 25, "")
   };
 
   if (includeInstance) {
@@ -1415,6 +1418,18 @@ proto.pulumirpc.RegisterResourceRequest.deserializeBinaryFromReader = function(m
         jspb.Map.deserializeBinary(message, reader, jspb.BinaryReader.prototype.readString, jspb.BinaryReader.prototype.readString, null, "", "");
          });
       break;
+    case 23:
+      var value = /** @type {boolean} */ (reader.readBool());
+      msg.setRetainondelete(value);
+      break;
+    case 24:
+      var value = /** @type {string} */ (reader.readString());
+      msg.addReplaceonchanges(value);
+      break;
+    case 25:
+      var value = /** @type {string} */ (reader.readString());
+      msg.setDeletedwith(value);
+      break;
     default:
       reader.skipField();
       break;
@@ -1594,6 +1609,27 @@ proto.pulumirpc.RegisterResourceRequest.serializeBinaryToWriter = function(messa
   if (f && f.getLength() > 0) {
     f.serializeBinary(22, writer, jspb.BinaryWriter.prototype.writeString, jspb.BinaryWriter.prototype.writeString);
   }
+  f = message.getRetainondelete();
+  if (f) {
+    writer.writeBool(
+      23,
+      f
+    );
+  }
+  f = message.getReplaceonchangesList();
+  if (f.length > 0) {
+    writer.writeRepeatedString(
+      24,
+      f
+    );
+  }
+  f = message.getDeletedwith();
+  if (f.length > 0) {
+    writer.writeString(
+      25,
+      f
+    );
+  }
 };
 
 
@@ -2461,6 +2497,79 @@ proto.pulumirpc.RegisterResourceRequest.prototype.clearProvidersMap = function()
   return this;};
 
 
+/**
+ * optional bool retainOnDelete = 23;
+ * @return {boolean}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getRetainondelete = function() {
+  return /** @type {boolean} */ (jspb.Message.getBooleanFieldWithDefault(this, 23, false));
+};
+
+
+/**
+ * @param {boolean} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setRetainondelete = function(value) {
+  return jspb.Message.setProto3BooleanField(this, 23, value);
+};
+
+
+/**
+ * repeated string replaceOnChanges = 24;
+ * @return {!Array<string>}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getReplaceonchangesList = function() {
+  return /** @type {!Array<string>} */ (jspb.Message.getRepeatedField(this, 24));
+};
+
+
+/**
+ * @param {!Array<string>} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setReplaceonchangesList = function(value) {
+  return jspb.Message.setField(this, 24, value || []);
+};
+
+
+/**
+ * @param {string} value
+ * @param {number=} opt_index
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.addReplaceonchanges = function(value, opt_index) {
+  return jspb.Message.addToRepeatedField(this, 24, value, opt_index);
+};
+
+
+/**
+ * Clears the list making it empty but non-null.
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.clearReplaceonchangesList = function() {
+  return this.setReplaceonchangesList([]);
+};
+
+
+/**
+ * optional string deletedWith = 25;
+ * @return {string}
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.getDeletedwith = function() {
+  return /** @type {string} */ (jspb.Message.getFieldWithDefault(this, 25, ""));
+};
+
+
+/**
+ * @param {string} value
+ * @return {!proto.pulumirpc.RegisterResourceRequest} returns this
+ */
+proto.pulumirpc.RegisterResourceRequest.prototype.setDeletedwith = function(value) {
+  return jspb.Message.setProto3StringField(this, 25, value);
+};
+
+
 
 /**
  * List of repeated fields within this message type.
@@ -306,6 +306,9 @@ type RegisterResourceRequest struct {
 	Remote                     bool                                                     `protobuf:"varint,20,opt,name=remote,proto3" json:"remote,omitempty"`
 	AcceptResources            bool                                                     `protobuf:"varint,21,opt,name=acceptResources,proto3" json:"acceptResources,omitempty"`
 	Providers                  map[string]string                                        `protobuf:"bytes,22,rep,name=providers,proto3" json:"providers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	RetainOnDelete             bool                                                     `protobuf:"varint,23,opt,name=retainOnDelete,proto3" json:"retainOnDelete,omitempty"`
+	ReplaceOnChanges           []string                                                 `protobuf:"bytes,24,rep,name=replaceOnChanges,proto3" json:"replaceOnChanges,omitempty"`
+	DeletedWith                string                                                   `protobuf:"bytes,25,opt,name=deletedWith,proto3" json:"deletedWith,omitempty"`
 	XXX_NoUnkeyedLiteral       struct{}                                                 `json:"-"`
 	XXX_unrecognized           []byte                                                   `json:"-"`
 	XXX_sizecache              int32                                                    `json:"-"`
@@ -490,6 +493,27 @@ func (m *RegisterResourceRequest) GetProviders() map[string]string {
 	return nil
 }
 
+func (m *RegisterResourceRequest) GetRetainOnDelete() bool {
+	if m != nil {
+		return m.RetainOnDelete
+	}
+	return false
+}
+
+func (m *RegisterResourceRequest) GetReplaceOnChanges() []string {
+	if m != nil {
+		return m.ReplaceOnChanges
+	}
+	return nil
+}
+
+func (m *RegisterResourceRequest) GetDeletedWith() string {
+	if m != nil {
+		return m.DeletedWith
+	}
+	return ""
+}
+
 // PropertyDependencies describes the resources that a particular property depends on.
 type RegisterResourceRequest_PropertyDependencies struct {
 	Urns                 []string `protobuf:"bytes,1,rep,name=urns,proto3" json:"urns,omitempty"`
@@ -784,69 +808,71 @@ func init() {
 func init() { proto.RegisterFile("resource.proto", fileDescriptor_d1b72f771c35e3b8) }
 
 var fileDescriptor_d1b72f771c35e3b8 = []byte{
-	// 977 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0x4f, 0x73, 0xdb, 0x44,
-	0x14, 0x8f, 0xed, 0xd4, 0xb1, 0x5f, 0x52, 0x27, 0x6c, 0x5c, 0x67, 0x2b, 0x98, 0x10, 0x04, 0x07,
-	0xc3, 0xc1, 0x69, 0x02, 0x33, 0x0d, 0x4c, 0x81, 0x19, 0xda, 0xc2, 0xf4, 0x50, 0x5a, 0x14, 0x86,
-	0x01, 0x66, 0x60, 0x66, 0x23, 0xbd, 0xb8, 0x22, 0xb6, 0x56, 0xdd, 0x5d, 0x65, 0xc6, 0x37, 0x38,
-	0xf2, 0x1d, 0xb8, 0xf0, 0x55, 0xf8, 0x64, 0xcc, 0xee, 0x6a, 0x5d, 0xc9, 0x92, 0x13, 0x27, 0xbd,
-	0xe9, 0xfd, 0xd7, 0xfe, 0xde, 0xef, 0xbd, 0x5d, 0xe8, 0x09, 0x94, 0x3c, 0x13, 0x21, 0x8e, 0x52,
-	0xc1, 0x15, 0x27, 0xdd, 0x34, 0x9b, 0x64, 0xd3, 0x58, 0xa4, 0xa1, 0xf7, 0xee, 0x98, 0xf3, 0xf1,
-	0x04, 0x0f, 0x8d, 0xe1, 0x2c, 0x3b, 0x3f, 0xc4, 0x69, 0xaa, 0x66, 0xd6, 0xcf, 0x7b, 0x6f, 0xd1,
-	0x28, 0x95, 0xc8, 0x42, 0x95, 0x5b, 0x7b, 0xa9, 0xe0, 0x97, 0x71, 0x84, 0xc2, 0xca, 0xfe, 0x10,
-	0x06, 0xa7, 0x59, 0x9a, 0x72, 0xa1, 0xe4, 0xb7, 0xc8, 0x54, 0x26, 0x30, 0xc0, 0xd7, 0x19, 0x4a,
-	0x45, 0x7a, 0xd0, 0x8c, 0x23, 0xda, 0x38, 0x68, 0x0c, 0xbb, 0x41, 0x33, 0x8e, 0xfc, 0xcf, 0x61,
-	0xaf, 0xe2, 0x29, 0x53, 0x9e, 0x48, 0x24, 0xfb, 0x00, 0xaf, 0x98, 0xcc, 0xad, 0x26, 0xa4, 0x13,
-	0x14, 0x34, 0xfe, 0x3f, 0x2d, 0xd8, 0x0d, 0x90, 0x45, 0x41, 0x7e, 0xa2, 0x25, 0x25, 0x08, 0x81,
-	0x75, 0x35, 0x4b, 0x91, 0x36, 0x8d, 0xc6, 0x7c, 0x6b, 0x5d, 0xc2, 0xa6, 0x48, 0x5b, 0x56, 0xa7,
-	0xbf, 0xc9, 0x00, 0xda, 0x29, 0x13, 0x98, 0x28, 0xba, 0x6e, 0xb4, 0xb9, 0x44, 0x1e, 0x02, 0xa4,
-	0x82, 0xa7, 0x28, 0x54, 0x8c, 0x92, 0xde, 0x39, 0x68, 0x0c, 0x37, 0x8f, 0xf7, 0x46, 0x16, 0x8f,
-	0x91, 0xc3, 0x63, 0x74, 0x6a, 0xf0, 0x08, 0x0a, 0xae, 0xc4, 0x87, 0xad, 0x08, 0x53, 0x4c, 0x22,
-	0x4c, 0x42, 0x1d, 0xda, 0x3e, 0x68, 0x0d, 0xbb, 0x41, 0x49, 0x47, 0x3c, 0xe8, 0x38, 0xec, 0xe8,
-	0x86, 0x29, 0x3b, 0x97, 0x09, 0x85, 0x8d, 0x4b, 0x14, 0x32, 0xe6, 0x09, 0xed, 0x18, 0x93, 0x13,
-	0xc9, 0x47, 0x70, 0x97, 0x85, 0x21, 0xa6, 0xea, 0x14, 0x43, 0x81, 0x4a, 0xd2, 0xae, 0x41, 0xa7,
-	0xac, 0x24, 0x27, 0xb0, 0xc7, 0xa2, 0x28, 0x56, 0x31, 0x4f, 0xd8, 0xc4, 0x2a, 0x5f, 0x64, 0x2a,
-	0xcd, 0x94, 0xa4, 0x60, 0x7e, 0x65, 0x99, 0x59, 0x57, 0x66, 0x93, 0x98, 0x49, 0x94, 0x74, 0xd3,
-	0x78, 0x3a, 0x91, 0x0c, 0x61, 0xdb, 0x16, 0x71, 0xa8, 0x4b, 0xba, 0x65, 0x6a, 0x2f, 0xaa, 0x7d,
-	0x06, 0xfd, 0x72, 0x77, 0xf2, 0xb6, 0xee, 0x40, 0x2b, 0x13, 0x49, 0xde, 0x1f, 0xfd, 0xb9, 0x00,
-	0x70, 0x73, 0x65, 0x80, 0xfd, 0x7f, 0x01, 0xf6, 0x02, 0x1c, 0xc7, 0x52, 0xa1, 0x58, 0x64, 0x81,
-	0xeb, 0x7a, 0xa3, 0xa6, 0xeb, 0xcd, 0xda, 0xae, 0xb7, 0x4a, 0x5d, 0x1f, 0x40, 0x3b, 0xcc, 0xa4,
-	0xe2, 0x53, 0xc3, 0x86, 0x4e, 0x90, 0x4b, 0xe4, 0x10, 0xda, 0xfc, 0xec, 0x0f, 0x0c, 0xd5, 0x75,
-	0x4c, 0xc8, 0xdd, 0x34, 0x96, 0xda, 0xa4, 0x23, 0xda, 0x26, 0x93, 0x13, 0x2b, 0xfc, 0xd8, 0xb8,
-	0x86, 0x1f, 0x9d, 0x05, 0x7e, 0xa4, 0xd0, 0xcf, 0xc1, 0x98, 0x3d, 0x29, 0xe6, 0xe9, 0x1e, 0xb4,
-	0x86, 0x9b, 0xc7, 0x8f, 0x46, 0xf3, 0xd1, 0x1e, 0x2d, 0x01, 0x69, 0xf4, 0xb2, 0x26, 0xfc, 0x69,
-	0xa2, 0xc4, 0x2c, 0xa8, 0xcd, 0x4c, 0x1e, 0xc0, 0x6e, 0x84, 0x13, 0x54, 0xf8, 0x0d, 0x9e, 0x73,
-	0x3d, 0xaa, 0xe9, 0x84, 0x85, 0x48, 0xc1, 0x9c, 0xab, 0xce, 0x54, 0xe4, 0xf0, 0x66, 0x85, 0xc3,
-	0xf1, 0x38, 0xe1, 0x02, 0x1f, 0xbf, 0x62, 0xc9, 0xd8, 0xf0, 0x48, 0x1f, 0xbf, 0xac, 0xac, 0x32,
-	0xfd, 0xee, 0x0d, 0x99, 0xde, 0x5b, 0x99, 0xe9, 0xdb, 0x65, 0xa6, 0x7b, 0xd0, 0x89, 0xa7, 0x7a,
-	0xd1, 0x3c, 0x8b, 0xe8, 0x8e, 0x45, 0xde, 0xc9, 0xe4, 0x17, 0xe8, 0x59, 0x3a, 0xfc, 0x18, 0x4f,
-	0x91, 0xeb, 0x32, 0xef, 0x18, 0x32, 0x1c, 0xad, 0x80, 0xf9, 0xe3, 0x52, 0x60, 0xb0, 0x90, 0x88,
-	0x7c, 0x05, 0x5e, 0x0d, 0x8e, 0x4f, 0xf0, 0x3c, 0x4e, 0x30, 0xa2, 0xc4, 0x9c, 0xfe, 0x0a, 0x0f,
-	0xf2, 0x19, 0xdc, 0x93, 0xf9, 0x42, 0x7d, 0xc9, 0x84, 0x8a, 0xd9, 0xe4, 0x27, 0x36, 0xc9, 0x50,
-	0xd2, 0x5d, 0x13, 0x5a, 0x6f, 0xd4, 0x6c, 0x17, 0x38, 0xe5, 0x0a, 0x69, 0xdf, 0xb2, 0xdd, 0x4a,
-	0x75, 0xe3, 0x7e, 0xaf, 0x76, 0xdc, 0xc9, 0x0b, 0xe8, 0x3a, 0x62, 0x4a, 0x3a, 0x30, 0x0c, 0x3c,
-	0x5a, 0x8d, 0x81, 0x36, 0xc6, 0xd2, 0xee, 0x4d, 0x0e, 0xef, 0x13, 0xe8, 0xd7, 0xd1, 0x53, 0x0f,
-	0x71, 0x26, 0x12, 0x49, 0x1b, 0xa6, 0x5d, 0xe6, 0xdb, 0xfb, 0x19, 0x7a, 0x65, 0x58, 0xcd, 0xf8,
-	0x0a, 0x64, 0xca, 0x2d, 0x80, 0x5c, 0xd2, 0xfa, 0x2c, 0x8d, 0xb4, 0xde, 0x2e, 0x81, 0x5c, 0xd2,
-	0x7a, 0x0b, 0xaa, 0x5b, 0x03, 0x56, 0xf2, 0xfe, 0x6c, 0xc0, 0xfd, 0xa5, 0x53, 0xa2, 0x77, 0xd9,
-	0x05, 0xce, 0xdc, 0x2e, 0xbb, 0xc0, 0x19, 0x79, 0x0e, 0x77, 0x2e, 0x35, 0xa4, 0xf9, 0x1a, 0x7b,
-	0x78, 0xcb, 0x21, 0x0c, 0x6c, 0x96, 0x2f, 0x9a, 0x27, 0x0d, 0xef, 0x11, 0xf4, 0xca, 0x28, 0xd5,
-	0x94, 0xed, 0x17, 0xcb, 0x76, 0x0b, 0xd1, 0xfe, 0x7f, 0x2d, 0xa0, 0xd5, 0xca, 0x4b, 0x77, 0xb1,
-	0xbd, 0x3c, 0x9b, 0xf3, 0xcb, 0xf3, 0xcd, 0xba, 0x6b, 0xad, 0xb6, 0xee, 0x06, 0xd0, 0x96, 0x8a,
-	0x9d, 0x4d, 0xd0, 0xed, 0x4d, 0x2b, 0xe9, 0x41, 0xb3, 0x5f, 0xfa, 0x0a, 0x35, 0x83, 0x96, 0x8b,
-	0xe4, 0xf5, 0x92, 0x35, 0xd6, 0x36, 0x24, 0xfa, 0xf2, 0x4a, 0x04, 0xed, 0x39, 0x6e, 0xba, 0xc7,
-	0x6e, 0xc4, 0xad, 0xbf, 0x6e, 0xc8, 0x80, 0xef, 0xcb, 0x0c, 0x38, 0xb9, 0xed, 0xff, 0x17, 0x9b,
-	0x88, 0xb0, 0xbf, 0x18, 0x9b, 0x2f, 0x30, 0x77, 0xdd, 0x55, 0x3b, 0x79, 0x04, 0x1b, 0x3c, 0xdf,
-	0x81, 0xd7, 0x5c, 0xa9, 0xce, 0xef, 0xf8, 0xef, 0x75, 0xd8, 0x76, 0xf9, 0x9f, 0xf3, 0x24, 0x56,
-	0x5c, 0x90, 0x5f, 0x61, 0x7b, 0xe1, 0x81, 0x46, 0x3e, 0x28, 0x1c, 0xa9, 0xfe, 0x99, 0xe7, 0xf9,
-	0x57, 0xb9, 0xd8, 0x43, 0xfb, 0x6b, 0xe4, 0x6b, 0x68, 0x3f, 0x4b, 0x2e, 0xf9, 0x05, 0x12, 0x5a,
-	0xf0, 0xb7, 0x2a, 0x97, 0xe9, 0x7e, 0x8d, 0x65, 0x9e, 0xe0, 0x3b, 0xd8, 0x3a, 0x55, 0x02, 0xd9,
-	0xf4, 0xad, 0xd2, 0x3c, 0x68, 0x90, 0x1f, 0x60, 0xab, 0xf8, 0x58, 0x21, 0xfb, 0xa5, 0xae, 0x55,
-	0xde, 0x98, 0xde, 0xfb, 0x4b, 0xed, 0xf3, 0x7f, 0xfb, 0x0d, 0x76, 0x16, 0x7b, 0x46, 0xfc, 0xeb,
-	0xd7, 0x81, 0xf7, 0xe1, 0x0a, 0x84, 0xf1, 0xd7, 0xc8, 0xef, 0xd5, 0xa7, 0x8f, 0xbb, 0xd3, 0x3e,
-	0xbe, 0x22, 0x43, 0x99, 0x36, 0xde, 0xa0, 0xc2, 0x89, 0xa7, 0xfa, 0xd1, 0xef, 0xaf, 0x9d, 0xb5,
-	0x8d, 0xe6, 0xd3, 0xff, 0x03, 0x00, 0x00, 0xff, 0xff, 0x8a, 0xe7, 0x38, 0x56, 0x31, 0x0c, 0x00,
-	0x00,
+	// 1023 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x57, 0x5f, 0x6f, 0x23, 0x35,
+	0x10, 0x6f, 0x92, 0x5e, 0x9a, 0x4c, 0x7a, 0x69, 0x71, 0x7b, 0x89, 0xbb, 0xa0, 0x12, 0x16, 0x84,
+	0xc2, 0x3d, 0xa4, 0x77, 0x05, 0xe9, 0x0a, 0x3a, 0x40, 0xe2, 0x7a, 0xa0, 0x7b, 0x38, 0x5a, 0xb6,
+	0x88, 0x7f, 0x12, 0x48, 0x6e, 0x76, 0xda, 0x2e, 0x4d, 0xd6, 0x7b, 0xb6, 0xb7, 0x52, 0xde, 0xe0,
+	0x91, 0x4f, 0xc0, 0x0b, 0x9f, 0x86, 0x4f, 0x86, 0x6c, 0xaf, 0x7b, 0xbb, 0x9b, 0x4d, 0x9b, 0x1e,
+	0x6f, 0x9e, 0x19, 0xcf, 0x4c, 0xfc, 0x9b, 0x9f, 0x7f, 0xde, 0x40, 0x57, 0xa0, 0xe4, 0xa9, 0x18,
+	0xe3, 0x28, 0x11, 0x5c, 0x71, 0xd2, 0x4e, 0xd2, 0x49, 0x3a, 0x8d, 0x44, 0x32, 0xf6, 0xde, 0x3e,
+	0xe7, 0xfc, 0x7c, 0x82, 0x7b, 0x26, 0x70, 0x9a, 0x9e, 0xed, 0xe1, 0x34, 0x51, 0x33, 0xbb, 0xcf,
+	0x7b, 0xa7, 0x1c, 0x94, 0x4a, 0xa4, 0x63, 0x95, 0x45, 0xbb, 0x89, 0xe0, 0x57, 0x51, 0x88, 0xc2,
+	0xda, 0xfe, 0x10, 0x7a, 0x27, 0x69, 0x92, 0x70, 0xa1, 0xe4, 0xd7, 0xc8, 0x54, 0x2a, 0x30, 0xc0,
+	0x57, 0x29, 0x4a, 0x45, 0xba, 0x50, 0x8f, 0x42, 0x5a, 0x1b, 0xd4, 0x86, 0xed, 0xa0, 0x1e, 0x85,
+	0xfe, 0xa7, 0xd0, 0x9f, 0xdb, 0x29, 0x13, 0x1e, 0x4b, 0x24, 0xbb, 0x00, 0x17, 0x4c, 0x66, 0x51,
+	0x93, 0xd2, 0x0a, 0x72, 0x1e, 0xff, 0x9f, 0x06, 0x6c, 0x05, 0xc8, 0xc2, 0x20, 0x3b, 0xd1, 0x82,
+	0x16, 0x84, 0xc0, 0xaa, 0x9a, 0x25, 0x48, 0xeb, 0xc6, 0x63, 0xd6, 0xda, 0x17, 0xb3, 0x29, 0xd2,
+	0x86, 0xf5, 0xe9, 0x35, 0xe9, 0x41, 0x33, 0x61, 0x02, 0x63, 0x45, 0x57, 0x8d, 0x37, 0xb3, 0xc8,
+	0x13, 0x80, 0x44, 0xf0, 0x04, 0x85, 0x8a, 0x50, 0xd2, 0x7b, 0x83, 0xda, 0xb0, 0xb3, 0xdf, 0x1f,
+	0x59, 0x3c, 0x46, 0x0e, 0x8f, 0xd1, 0x89, 0xc1, 0x23, 0xc8, 0x6d, 0x25, 0x3e, 0xac, 0x87, 0x98,
+	0x60, 0x1c, 0x62, 0x3c, 0xd6, 0xa9, 0xcd, 0x41, 0x63, 0xd8, 0x0e, 0x0a, 0x3e, 0xe2, 0x41, 0xcb,
+	0x61, 0x47, 0xd7, 0x4c, 0xdb, 0x6b, 0x9b, 0x50, 0x58, 0xbb, 0x42, 0x21, 0x23, 0x1e, 0xd3, 0x96,
+	0x09, 0x39, 0x93, 0x7c, 0x00, 0xf7, 0xd9, 0x78, 0x8c, 0x89, 0x3a, 0xc1, 0xb1, 0x40, 0x25, 0x69,
+	0xdb, 0xa0, 0x53, 0x74, 0x92, 0x03, 0xe8, 0xb3, 0x30, 0x8c, 0x54, 0xc4, 0x63, 0x36, 0xb1, 0xce,
+	0xa3, 0x54, 0x25, 0xa9, 0x92, 0x14, 0xcc, 0x4f, 0x59, 0x14, 0xd6, 0x9d, 0xd9, 0x24, 0x62, 0x12,
+	0x25, 0xed, 0x98, 0x9d, 0xce, 0x24, 0x43, 0xd8, 0xb0, 0x4d, 0x1c, 0xea, 0x92, 0xae, 0x9b, 0xde,
+	0x65, 0xb7, 0xcf, 0x60, 0xbb, 0x38, 0x9d, 0x6c, 0xac, 0x9b, 0xd0, 0x48, 0x45, 0x9c, 0xcd, 0x47,
+	0x2f, 0x4b, 0x00, 0xd7, 0x97, 0x06, 0xd8, 0xff, 0xbb, 0x03, 0xfd, 0x00, 0xcf, 0x23, 0xa9, 0x50,
+	0x94, 0x59, 0xe0, 0xa6, 0x5e, 0xab, 0x98, 0x7a, 0xbd, 0x72, 0xea, 0x8d, 0xc2, 0xd4, 0x7b, 0xd0,
+	0x1c, 0xa7, 0x52, 0xf1, 0xa9, 0x61, 0x43, 0x2b, 0xc8, 0x2c, 0xb2, 0x07, 0x4d, 0x7e, 0xfa, 0x3b,
+	0x8e, 0xd5, 0x6d, 0x4c, 0xc8, 0xb6, 0x69, 0x2c, 0x75, 0x48, 0x67, 0x34, 0x4d, 0x25, 0x67, 0xce,
+	0xf1, 0x63, 0xed, 0x16, 0x7e, 0xb4, 0x4a, 0xfc, 0x48, 0x60, 0x3b, 0x03, 0x63, 0x76, 0x98, 0xaf,
+	0xd3, 0x1e, 0x34, 0x86, 0x9d, 0xfd, 0xa7, 0xa3, 0xeb, 0xab, 0x3d, 0x5a, 0x00, 0xd2, 0xe8, 0xb8,
+	0x22, 0xfd, 0x79, 0xac, 0xc4, 0x2c, 0xa8, 0xac, 0x4c, 0x1e, 0xc1, 0x56, 0x88, 0x13, 0x54, 0xf8,
+	0x15, 0x9e, 0x71, 0x81, 0x01, 0x26, 0x13, 0x36, 0x46, 0x0a, 0xe6, 0x5c, 0x55, 0xa1, 0x3c, 0x87,
+	0x3b, 0x73, 0x1c, 0x8e, 0xce, 0x63, 0x2e, 0xf0, 0xd9, 0x05, 0x8b, 0xcf, 0x0d, 0x8f, 0xf4, 0xf1,
+	0x8b, 0xce, 0x79, 0xa6, 0xdf, 0xbf, 0x23, 0xd3, 0xbb, 0x4b, 0x33, 0x7d, 0xa3, 0xc8, 0x74, 0x0f,
+	0x5a, 0xd1, 0x34, 0xe1, 0x42, 0xbd, 0x08, 0xe9, 0xa6, 0x45, 0xde, 0xd9, 0xe4, 0x67, 0xe8, 0x5a,
+	0x3a, 0x7c, 0x1f, 0x4d, 0x91, 0xeb, 0x36, 0x6f, 0x19, 0x32, 0x3c, 0x5e, 0x02, 0xf3, 0x67, 0x85,
+	0xc4, 0xa0, 0x54, 0x88, 0x7c, 0x01, 0x5e, 0x05, 0x8e, 0x87, 0x78, 0x16, 0xc5, 0x18, 0x52, 0x62,
+	0x4e, 0x7f, 0xc3, 0x0e, 0xf2, 0x09, 0x3c, 0x90, 0x99, 0xa0, 0x1e, 0x33, 0xa1, 0x22, 0x36, 0xf9,
+	0x81, 0x4d, 0x52, 0x94, 0x74, 0xcb, 0xa4, 0x56, 0x07, 0x35, 0xdb, 0x05, 0x4e, 0xb9, 0x42, 0xba,
+	0x6d, 0xd9, 0x6e, 0xad, 0xaa, 0xeb, 0xfe, 0xa0, 0xf2, 0xba, 0x93, 0x23, 0x68, 0x3b, 0x62, 0x4a,
+	0xda, 0x1b, 0x34, 0x96, 0x44, 0xe3, 0xd8, 0xe5, 0x58, 0xda, 0xbd, 0xae, 0x41, 0x3e, 0xd4, 0x6f,
+	0x95, 0x62, 0x51, 0x7c, 0x14, 0x1f, 0x9a, 0xe3, 0xd2, 0xbe, 0xe9, 0x5c, 0xf2, 0x92, 0x87, 0xb0,
+	0x29, 0x2c, 0x04, 0x47, 0xb1, 0xa3, 0x12, 0x35, 0xa3, 0x9c, 0xf3, 0x93, 0x01, 0x74, 0x2c, 0x74,
+	0xe1, 0x8f, 0x91, 0xba, 0xa0, 0x3b, 0x66, 0xac, 0x79, 0x97, 0xf7, 0x10, 0xb6, 0xab, 0x2e, 0x85,
+	0x96, 0x8e, 0x54, 0xc4, 0x92, 0xd6, 0x4c, 0x65, 0xb3, 0xf6, 0x7e, 0x82, 0x6e, 0x71, 0x98, 0x46,
+	0x34, 0x04, 0x32, 0xe5, 0x64, 0x27, 0xb3, 0xb4, 0x3f, 0x4d, 0x42, 0xa6, 0x9c, 0xf4, 0x64, 0x96,
+	0xf6, 0xdb, 0xe6, 0x4e, 0x7c, 0xac, 0xe5, 0xfd, 0x51, 0x83, 0x9d, 0x85, 0x77, 0x53, 0x2b, 0xe8,
+	0x25, 0xce, 0x9c, 0x82, 0x5e, 0xe2, 0x8c, 0xbc, 0x84, 0x7b, 0x57, 0x7a, 0x90, 0x99, 0x78, 0x3e,
+	0x79, 0xc3, 0xab, 0x1f, 0xd8, 0x2a, 0x9f, 0xd5, 0x0f, 0x6a, 0xde, 0x53, 0xe8, 0x16, 0x67, 0x53,
+	0xd1, 0x76, 0x3b, 0xdf, 0xb6, 0x9d, 0xcb, 0xf6, 0xff, 0x6d, 0x00, 0x9d, 0xef, 0xbc, 0xf0, 0x05,
+	0xb0, 0x4f, 0x76, 0xfd, 0xfa, 0xc9, 0x7e, 0x2d, 0xb2, 0x8d, 0xe5, 0x44, 0xb6, 0x07, 0x4d, 0xa9,
+	0xd8, 0xe9, 0x04, 0x9d, 0x5a, 0x5b, 0x4b, 0x5f, 0x6f, 0xbb, 0xd2, 0x0f, 0xb7, 0xb9, 0xde, 0x99,
+	0x49, 0x5e, 0x2d, 0x10, 0xcf, 0xa6, 0xa1, 0xee, 0xe7, 0x37, 0x22, 0x68, 0xcf, 0x71, 0x57, 0xf5,
+	0xbc, 0x13, 0xb7, 0xfe, 0xbc, 0x23, 0x03, 0xbe, 0x2d, 0x32, 0xe0, 0xe0, 0x4d, 0x7f, 0x7f, 0x7e,
+	0x88, 0x08, 0xbb, 0xe5, 0xdc, 0x4c, 0x36, 0xdd, 0x23, 0x3b, 0x3f, 0xc9, 0xc7, 0xb0, 0xc6, 0x33,
+	0xe5, 0xbd, 0xe5, 0x21, 0x77, 0xfb, 0xf6, 0xff, 0x5a, 0x85, 0x0d, 0x57, 0xff, 0x25, 0x8f, 0x23,
+	0xc5, 0x05, 0xf9, 0x05, 0x36, 0x4a, 0x9f, 0x85, 0xe4, 0xbd, 0xdc, 0x91, 0xaa, 0x3f, 0x2e, 0x3d,
+	0xff, 0xa6, 0x2d, 0xf6, 0xd0, 0xfe, 0x0a, 0xf9, 0x12, 0x9a, 0x2f, 0xe2, 0x2b, 0x7e, 0x89, 0x84,
+	0xe6, 0xf6, 0x5b, 0x97, 0xab, 0xb4, 0x53, 0x11, 0xb9, 0x2e, 0xf0, 0x0d, 0xac, 0x9f, 0x28, 0x81,
+	0x6c, 0xfa, 0xbf, 0xca, 0x3c, 0xaa, 0x91, 0xef, 0x60, 0x3d, 0xff, 0x89, 0x44, 0x76, 0x0b, 0x53,
+	0x9b, 0xfb, 0xb2, 0xf5, 0xde, 0x5d, 0x18, 0xbf, 0xfe, 0x6d, 0xbf, 0xc2, 0x66, 0x79, 0x66, 0xc4,
+	0xbf, 0x5d, 0x0e, 0xbc, 0xf7, 0x97, 0x20, 0x8c, 0xbf, 0x42, 0x7e, 0x83, 0xfe, 0x02, 0x4a, 0x90,
+	0x8f, 0x6e, 0xa8, 0x50, 0xa4, 0x8d, 0xd7, 0x9b, 0xe3, 0xc4, 0x73, 0xfd, 0x57, 0xc3, 0x5f, 0x39,
+	0x6d, 0x1a, 0xcf, 0xc7, 0xff, 0x0d, 0x00, 0x31, 0x93, 0x34, 0xc6, 0xa7, 0x0c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.